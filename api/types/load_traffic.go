@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/validation/path"
 	apitypes "k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
 )
 
 // ContentType represents the format of response.
@@ -31,6 +33,44 @@ func (ct ContentType) Validate() error {
 	}
 }
 
+// MediaType returns the media type used in Accept header.
+func (ct ContentType) MediaType() string {
+	switch ct {
+	case ContentTypeProtobuffer:
+		return "application/vnd.kubernetes.protobuf"
+	default:
+		return "application/json"
+	}
+}
+
+// protobufGroups are built-in API groups which support protobuf. Custom
+// resources only support JSON.
+var protobufGroups = map[string]bool{
+	"":                             true,
+	"admissionregistration.k8s.io": true,
+	"apiextensions.k8s.io":         true,
+	"apiregistration.k8s.io":       true,
+	"apps":                         true,
+	"authentication.k8s.io":        true,
+	"authorization.k8s.io":         true,
+	"autoscaling":                  true,
+	"batch":                        true,
+	"certificates.k8s.io":          true,
+	"coordination.k8s.io":          true,
+	"discovery.k8s.io":             true,
+	"events.k8s.io":                true,
+	"flowcontrol.apiserver.k8s.io": true,
+	"internal.apiserver.k8s.io":    true,
+	"networking.k8s.io":            true,
+	"node.k8s.io":                  true,
+	"policy":                       true,
+	"rbac.authorization.k8s.io":    true,
+	"resource.k8s.io":              true,
+	"scheduling.k8s.io":            true,
+	"storage.k8s.io":               true,
+	"storagemigration.k8s.io":      true,
+}
+
 // LoadProfile defines how to create load traffic from one host to kube-apiserver.
 type LoadProfile struct {
 	// Version defines the version of this object.
@@ -49,23 +89,146 @@ type LoadProfileSpec struct {
 	Total int `json:"total" yaml:"total"`
 	// Duration defines the running time in seconds.
 	Duration int `json:"duration" yaml:"duration"`
+	// WarmupSeconds defines how long to send requests before measuring,
+	// so that connection setup and cold caches don't inflate latencies.
+	// The warmup requests are rate limited but they aren't counted in
+	// Total nor recorded in the report. (0 means no warmup).
+	WarmupSeconds int `json:"warmupSeconds,omitempty" yaml:"warmupSeconds,omitempty"`
+	// RampUpSeconds defines how long the rate climbs linearly to Rate
+	// from the start of traffic, including warmup, to avoid a spike
+	// against a cold kube-apiserver. It doesn't work if Rate is zero.
+	// (0 means full rate immediately).
+	RampUpSeconds int `json:"rampUpSeconds,omitempty" yaml:"rampUpSeconds,omitempty"`
+	// RandomSeed makes the sequence of requests and object names
+	// reproducible across runs. It's not cryptographically secure.
+	// (nil means crypto/rand).
+	RandomSeed *int64 `json:"randomSeed,omitempty" yaml:"randomSeed,omitempty"`
 	// Conns defines total number of long connections used for traffic.
 	Conns int `json:"conns" yaml:"conns"`
 	// Client defines total number of HTTP clients.
 	Client int `json:"client" yaml:"client"`
+	// ConnPerClient gives each client its own connection, like many
+	// kubelets, instead of sharing Conns connections. Conns is ignored
+	// if it's true.
+	ConnPerClient bool `json:"connPerClient,omitempty" yaml:"connPerClient,omitempty"`
 	// ContentType defines response's content type.
 	ContentType ContentType `json:"contentType" yaml:"contentType"`
 	// DisableHTTP2 means client will use HTTP/1.1 protocol if it's true.
 	DisableHTTP2 bool `json:"disableHTTP2" yaml:"disableHTTP2"`
+	// DisableKeepAlives forces new TCP and TLS connection for each request
+	// instead of reusing pooled connections. It surfaces the handshake cost
+	// on kube-apiserver.
+	DisableKeepAlives bool `json:"disableKeepAlives,omitempty" yaml:"disableKeepAlives,omitempty"`
 	// MaxRetries makes the request use the given integer as a ceiling of
 	// retrying upon receiving "Retry-After" headers and 429 status-code
 	// in the response (<= 0 means no retry).
 	MaxRetries int `json:"maxRetries" yaml:"maxRetries"`
+	// RequestTimeoutSeconds defines the timeout of each request.
+	// (0 means 60 seconds).
+	RequestTimeoutSeconds int `json:"requestTimeoutSeconds,omitempty" yaml:"requestTimeoutSeconds,omitempty"`
+	// WatchTimeoutSeconds defines the timeout of each watch-list request,
+	// which is expected to be long-lived. (0 means RequestTimeoutSeconds).
+	WatchTimeoutSeconds int `json:"watchTimeoutSeconds,omitempty" yaml:"watchTimeoutSeconds,omitempty"`
+	// DrainTimeoutSeconds defines how long to wait for in-flight requests
+	// to finish after the run stops sending new requests. The requests
+	// still in flight after the timeout are aborted. (0 means aborting
+	// in-flight requests immediately when duration elapses).
+	DrainTimeoutSeconds int `json:"drainTimeoutSeconds,omitempty" yaml:"drainTimeoutSeconds,omitempty"`
+	// NetworkDelayMs defines the artificial latency in milliseconds
+	// injected into each round trip to simulate high-RTT clients, like
+	// cross-region clients. The injected delay is excluded from reported
+	// latencies. (0 means no delay).
+	NetworkDelayMs int `json:"networkDelayMs,omitempty" yaml:"networkDelayMs,omitempty"`
+	// AcceptEncoding asks kube-apiserver for compressed response. Only
+	// gzip is supported. The bytes read from the wire are reported
+	// separately from the decompressed bytes. (empty means transparent
+	// compression by Go's HTTP client, whose wire bytes aren't reported).
+	AcceptEncoding string `json:"acceptEncoding,omitempty" yaml:"acceptEncoding,omitempty"`
+	// InstanceHeader defines the response header which identifies the
+	// kube-apiserver instance serving the request, like the one set by
+	// load balancer in front of multiple replicas. The latencies are
+	// reported by its value as well so that a single slow replica doesn't
+	// hide in the aggregate. (empty means no per-instance breakdown).
+	InstanceHeader string `json:"instanceHeader,omitempty" yaml:"instanceHeader,omitempty"`
+	// AuthRefreshIntervalSeconds defines the minimum interval in seconds
+	// between rebuilding clients from kubeconfig when requests fail with
+	// 401 or 403, like when the token expires in long runs. These
+	// requests are reported as auth failures instead of failures, unless
+	// the rebuilt clients still fail within the interval.
+	// (0 means disabled).
+	AuthRefreshIntervalSeconds int `json:"authRefreshIntervalSeconds,omitempty" yaml:"authRefreshIntervalSeconds,omitempty"`
+	// RetryBackoffBaseMs defines the base delay in milliseconds of
+	// exponential backoff with full jitter between retries. It only works
+	// with MaxRetries. (0 means client-go's default backoff).
+	RetryBackoffBaseMs int `json:"retryBackoffBaseMs,omitempty" yaml:"retryBackoffBaseMs,omitempty"`
+	// RetryBackoffMaxMs caps the backoff delay in milliseconds between
+	// retries. (0 means no cap).
+	RetryBackoffMaxMs int `json:"retryBackoffMaxMs,omitempty" yaml:"retryBackoffMaxMs,omitempty"`
+	// ValidateResponse decodes the response of GET and LIST requests and
+	// validates that the object matches the request. The mismatch or decode
+	// failure is counted as decode error. It costs CPU so it's opt-in and
+	// it only supports json content type.
+	ValidateResponse bool `json:"validateResponse,omitempty" yaml:"validateResponse,omitempty"`
+	// MaxFailureSamples defines the maximum number of recent failures kept
+	// in the report. All the failures are still counted in error stats.
+	// (<= 0 means no limit).
+	MaxFailureSamples int `json:"maxFailureSamples,omitempty" yaml:"maxFailureSamples,omitempty"`
+	// MaxFailureRateByVerb defines the expected failure rate (0 to 1) for
+	// each verb, like GET or PATCH. The report flags the verb whose failure
+	// rate exceeds its threshold. The verb without threshold isn't flagged.
+	MaxFailureRateByVerb map[string]float64 `json:"maxFailureRateByVerb,omitempty" yaml:"maxFailureRateByVerb,omitempty"`
+	// Percentiles defines the latency percentiles in [0, 1] reported in
+	// the result, like 0.999 for tail-latency SLOs. The 0 means min and 1
+	// means max. Empty means the
+	// default ones, which are min, p50, p90, p95, p99 and max.
+	Percentiles []float64 `json:"percentiles,omitempty" yaml:"percentiles,omitempty"`
+	// LatencyHistogram records latencies in buckets instead of keeping
+	// all the raw latencies. The histograms can be merged across runners
+	// and the memory usage doesn't grow with the number of requests.
+	LatencyHistogram bool `json:"latencyHistogram,omitempty" yaml:"latencyHistogram,omitempty"`
+	// LatencyBuckets defines the upper bounds in seconds of histogram
+	// buckets in increasing order. Empty means the default buckets, which
+	// range from 5ms to 60s.
+	LatencyBuckets []float64 `json:"latencyBuckets,omitempty" yaml:"latencyBuckets,omitempty"`
+	// MixPhases defines how many phases the run is split into to report
+	// effective request mix. It only works when any request has EndShares
+	// (<= 0 means 4 phases).
+	MixPhases int `json:"mixPhases,omitempty" yaml:"mixPhases,omitempty"`
+	// StopCondition defines when to halt the run based on kube-apiserver's
+	// health. It's used to avoid taking down a shared cluster.
+	StopCondition *HealthStopCondition `json:"stopCondition,omitempty" yaml:"stopCondition,omitempty"`
+	// MinRequestsPerVerb guarantees that each verb is issued at least
+	// this many times before the remainder is distributed by weight. It's
+	// used to get measurable percentiles for low-share verbs in short run.
+	// The verb whose requests have zero shares isn't counted.
+	MinRequestsPerVerb int `json:"minRequestsPerVerb,omitempty" yaml:"minRequestsPerVerb,omitempty"`
+	// CleanupLeakedObjects deletes objects leaked by post-delete requests
+	// at the end of run.
+	CleanupLeakedObjects bool `json:"cleanupLeakedObjects,omitempty" yaml:"cleanupLeakedObjects,omitempty"`
+	// Targets defines the clusters which requests are distributed to by
+	// weight, like federation members or mirrors. Each request is sent to
+	// one target picked randomly. (empty means the runner's cluster).
+	// It can't be used with postDel, createNamespace or fromCache since
+	// the created objects are tracked regardless of target.
+	Targets []*Target `json:"targets,omitempty" yaml:"targets,omitempty"`
 	// Requests defines the different kinds of requests with weights.
 	// The executor should randomly pick by weight.
 	Requests []*WeightedRequest `json:"requests" yaml:"requests"`
 }
 
+// Target represents a cluster with weight.
+type Target struct {
+	// Name identifies the target in the report.
+	Name string `json:"name" yaml:"name"`
+	// Kubeconfig is the path to kubeconfig of the target. (empty means
+	// the runner's kubeconfig).
+	Kubeconfig string `json:"kubeconfig,omitempty" yaml:"kubeconfig,omitempty"`
+	// Context is the context in kubeconfig. (empty means current context).
+	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	// Shares defines weight among targets.
+	Shares int `json:"shares" yaml:"shares"`
+}
+
 // KubeGroupVersionResource identifies the resource URI.
 type KubeGroupVersionResource struct {
 	// Group is the name about a collection of related functionality.
@@ -74,6 +237,11 @@ type KubeGroupVersionResource struct {
 	Version string `json:"version" yaml:"version"`
 	// Resource is a type in that versioned group APIs.
 	Resource string `json:"resource" yaml:"resource"`
+	// Kind is the kubectl-style type name, like Deployment. If Resource
+	// is empty, it will be resolved into group, version and resource by
+	// discovery before sending requests. Group and Version are optional
+	// hints for that resolution.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
 }
 
 // WeightedRequest represents request with weight.
@@ -81,6 +249,10 @@ type KubeGroupVersionResource struct {
 type WeightedRequest struct {
 	// Shares defines weight in the same group.
 	Shares int `json:"shares" yaml:"shares"`
+	// EndShares defines weight at the end of run. If it's set, the weight
+	// changes linearly from Shares to EndShares over the run. It's useful
+	// to simulate changing workloads.
+	EndShares *int `json:"endShares,omitempty" yaml:"endShares,omitempty"`
 	// StaleList means this list request with zero resource version.
 	StaleList *RequestList `json:"staleList,omitempty" yaml:"staleList,omitempty"`
 	// QuorumList means this list request without kube-apiserver cache.
@@ -99,6 +271,42 @@ type WeightedRequest struct {
 	GetPodLog *RequestGetPodLog `json:"getPodLog,omitempty" yaml:"getPodLog,omitempty"`
 	// PostDelete means this is a post-delete operation request.
 	PostDel *RequestPostDel `json:"postDel,omitempty" yaml:"postDel,omitempty"`
+	// Post means this is a create-only request which never deletes.
+	Post *RequestPost `json:"post,omitempty" yaml:"post,omitempty"`
+	// DeleteCollection means this is to delete a collection of objects.
+	DeleteCollection *RequestDeleteCollection `json:"deleteCollection,omitempty" yaml:"deleteCollection,omitempty"`
+	// CreateNamespace means this is to create namespaces and optionally
+	// delete them.
+	CreateNamespace *RequestCreateNamespace `json:"createNamespace,omitempty" yaml:"createNamespace,omitempty"`
+}
+
+// GroupVersionResource returns the KubeGroupVersionResource of the
+// specified request. It returns nil if that request doesn't have it.
+func (r *WeightedRequest) GroupVersionResource() *KubeGroupVersionResource {
+	switch {
+	case r.StaleList != nil:
+		return &r.StaleList.KubeGroupVersionResource
+	case r.QuorumList != nil:
+		return &r.QuorumList.KubeGroupVersionResource
+	case r.WatchList != nil:
+		return &r.WatchList.KubeGroupVersionResource
+	case r.StaleGet != nil:
+		return &r.StaleGet.KubeGroupVersionResource
+	case r.QuorumGet != nil:
+		return &r.QuorumGet.KubeGroupVersionResource
+	case r.Put != nil:
+		return &r.Put.KubeGroupVersionResource
+	case r.Patch != nil:
+		return &r.Patch.KubeGroupVersionResource
+	case r.PostDel != nil:
+		return &r.PostDel.KubeGroupVersionResource
+	case r.Post != nil:
+		return &r.Post.KubeGroupVersionResource
+	case r.DeleteCollection != nil:
+		return &r.DeleteCollection.KubeGroupVersionResource
+	default:
+		return nil
+	}
 }
 
 // RequestGet defines GET request for target object.
@@ -109,6 +317,13 @@ type RequestGet struct {
 	Namespace string `json:"namespace" yaml:"namespace"`
 	// Name is object's name.
 	Name string `json:"name" yaml:"name"`
+	// FromCache is the cacheName of postDel request. If it's set, the
+	// request gets the object randomly picked from names created by that
+	// postDel request, and falls back to Name if there is no object yet.
+	FromCache string `json:"fromCache,omitempty" yaml:"fromCache,omitempty"`
+	// Subresource is the subresource of object to get, like status or
+	// scale. Empty means the object itself.
+	Subresource string `json:"subresource,omitempty" yaml:"subresource,omitempty"`
 }
 
 // RequestList defines LIST request for target objects.
@@ -119,10 +334,23 @@ type RequestList struct {
 	Namespace string `json:"namespace" yaml:"namespace"`
 	// Limit defines the page size.
 	Limit int `json:"limit" yaml:"limit"`
-	// Selector defines how to identify a set of objects.
+	// Selector defines how to identify a set of objects. It supports
+	// placeholders expanded with random values for each request, like
+	// {{rand MIN MAX}} for an integer in [MIN, MAX) and {{pick A B}} for
+	// one of the values.
 	Selector string `json:"seletor" yaml:"seletor"`
 	// FieldSelector defines how to identify a set of objects with field selector.
 	FieldSelector string `json:"fieldSelector" yaml:"fieldSelector"`
+	// NodeNameFromNodepool is the name of virtual node pool. If it's set,
+	// each pod list request uses spec.nodeName=<node> field selector,
+	// rotated across the nodes in that pool, like kubelets.
+	NodeNameFromNodepool string `json:"nodeNameFromNodepool,omitempty" yaml:"nodeNameFromNodepool,omitempty"`
+	// AsTable asks for Table, a.k.a server-side printing, like kubectl
+	// and dashboards, instead of raw object list.
+	AsTable bool `json:"asTable,omitempty" yaml:"asTable,omitempty"`
+	// MetadataOnly asks for PartialObjectMetadataList, like metadata-only
+	// informers, instead of full objects.
+	MetadataOnly bool `json:"metadataOnly,omitempty" yaml:"metadataOnly,omitempty"`
 }
 
 type RequestWatchList struct {
@@ -134,6 +362,14 @@ type RequestWatchList struct {
 	Selector string `json:"selector" yaml:"selector"`
 	// FieldSelector defines how to identify a set of objects with field selector.
 	FieldSelector string `json:"fieldSelector" yaml:"fieldSelector"`
+	// MetadataOnly asks for PartialObjectMetadata, like metadata-only
+	// informers, instead of full objects.
+	MetadataOnly bool `json:"metadataOnly,omitempty" yaml:"metadataOnly,omitempty"`
+	// Resume keeps watching after the initial events end, like informers.
+	// If the server closes the stream, it reconnects from the latest
+	// bookmark's resourceVersion without initial events until the watch
+	// timeout. The reconnects are counted as one watch.
+	Resume bool `json:"resume,omitempty" yaml:"resume,omitempty"`
 }
 
 // RequestPut defines PUT request for target resource type.
@@ -152,8 +388,12 @@ type RequestPut struct {
 	Name string `json:"name" yaml:"name"`
 	// KeySpaceSize is used to generate random number as name's suffix.
 	KeySpaceSize int `json:"keySpaceSize" yaml:"keySpaceSize"`
-	// ValueSize is the object's size in bytes.
+	// ValueSize is the object's size in bytes. It's used to generate
+	// random data for configmaps or secrets if Body is empty.
 	ValueSize int `json:"valueSize" yaml:"valueSize"`
+	// Body is the whole object in JSON format. The name and namespace
+	// in metadata are overridden by each request.
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
 }
 
 // RequestPatch defines PATCH request for target resource type.
@@ -165,12 +405,51 @@ type RequestPatch struct {
 	Name string `json:"name" yaml:"name"`
 	// KeySpaceSize is used to generate random number as name's suffix.
 	KeySpaceSize int `json:"keySpaceSize" yaml:"keySpaceSize"`
-	// PatchType is the type of patch, e.g. "json", "merge", "strategic-merge".
+	// PatchType is the type of patch, e.g. "json", "merge", "strategic-merge"
+	// or "apply" for server-side apply.
 	PatchType string `json:"patchType" yaml:"patchType"`
+	// FieldManager is the name of the actor making changes. It's required
+	// by server-side apply.
+	FieldManager string `json:"fieldManager,omitempty" yaml:"fieldManager,omitempty"`
+	// Force takes over the conflicting fields owned by other field
+	// managers instead of failing with conflict. It's only for apply patch.
+	Force bool `json:"force,omitempty" yaml:"force,omitempty"`
 	// Body is the request body, for fields to be changed.
 	Body string `json:"body" yaml:"body"`
+	// Bodies are the request bodies to rotate through. It's used to
+	// model clients patching different fields. Body is prepended if both
+	// are set.
+	Bodies []string `json:"bodies,omitempty" yaml:"bodies,omitempty"`
+	// BodyOrder defines how to pick up body from Bodies. (roundRobin or
+	// random, default is roundRobin)
+	BodyOrder PatchBodyOrder `json:"bodyOrder,omitempty" yaml:"bodyOrder,omitempty"`
+}
+
+// HealthStopCondition defines the danger thresholds of kube-apiserver's
+// health. The run is halted once any threshold is crossed.
+type HealthStopCondition struct {
+	// MaxInflightRequests is the maximum sum of
+	// apiserver_current_inflight_requests in the kube-apiserver instance
+	// serving the check (<= 0 means disabled).
+	MaxInflightRequests int `json:"maxInflightRequests,omitempty" yaml:"maxInflightRequests,omitempty"`
+	// MaxReadyzFailures is the maximum number of consecutive /readyz
+	// failures (<= 0 means disabled).
+	MaxReadyzFailures int `json:"maxReadyzFailures,omitempty" yaml:"maxReadyzFailures,omitempty"`
+	// CheckIntervalSeconds is the interval between health checks
+	// (<= 0 means 10 seconds).
+	CheckIntervalSeconds int `json:"checkIntervalSeconds,omitempty" yaml:"checkIntervalSeconds,omitempty"`
 }
 
+// PatchBodyOrder defines how to rotate through patch bodies.
+type PatchBodyOrder string
+
+const (
+	// PatchBodyOrderRoundRobin picks up bodies in order.
+	PatchBodyOrderRoundRobin PatchBodyOrder = "roundRobin"
+	// PatchBodyOrderRandom picks up bodies randomly.
+	PatchBodyOrderRandom PatchBodyOrder = "random"
+)
+
 // RequestGetPodLog defines GetLog request for target pod.
 type RequestGetPodLog struct {
 	// Namespace is pod's namespace.
@@ -191,6 +470,65 @@ type RequestPostDel struct {
 	KubeGroupVersionResource `yaml:",inline"`
 	Namespace                string  `json:"namespace" yaml:"namespace"`
 	DeleteRatio              float64 `json:"deleteRatio" yaml:"deleteRatio"`
+	// Template is the object template in Go template format for POST.
+	// It overrides the built-in template for the resource. The template
+	// can use {{ .Values.namePattern }} and {{ .Values.namespace }}.
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+	// GracePeriodSeconds is the duration in seconds before the object
+	// should be deleted. Nil means the default value for the resource.
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty" yaml:"gracePeriodSeconds,omitempty"`
+	// PropagationPolicy determines how garbage collection is performed
+	// for DELETE. (Foreground, Background or Orphan)
+	PropagationPolicy string `json:"propagationPolicy,omitempty" yaml:"propagationPolicy,omitempty"`
+	// CacheCap is the maximum number of created object names tracked for
+	// DELETE. The oldest name is dropped if it's full and that object is
+	// reported as leaked object after run. Zero means no limitation.
+	CacheCap int `json:"cacheCap,omitempty" yaml:"cacheCap,omitempty"`
+	// CacheName names the created object names so that GET requests can
+	// read them by fromCache. It must be unique in the load profile.
+	CacheName string `json:"cacheName,omitempty" yaml:"cacheName,omitempty"`
+}
+
+// RequestPost defines POST request which only creates objects with unique
+// names. The created objects aren't deleted.
+type RequestPost struct {
+	KubeGroupVersionResource `yaml:",inline"`
+	Namespace                string `json:"namespace" yaml:"namespace"`
+	// Template is the object template in Go template format for POST.
+	// It overrides the built-in template for the resource. The template
+	// can use {{ .Values.namePattern }} and {{ .Values.namespace }}.
+	Template string `json:"template,omitempty" yaml:"template,omitempty"`
+}
+
+// RequestCreateNamespace defines POST request which creates namespaces with
+// unique names, like postDel request. Namespace creation is expensive since
+// it triggers default service account, configmap and RBAC creation. It
+// doesn't require group and version.
+type RequestCreateNamespace struct {
+	// DeleteRatio is the ratio of requests which delete created
+	// namespaces. Zero means create-only.
+	DeleteRatio float64 `json:"deleteRatio,omitempty" yaml:"deleteRatio,omitempty"`
+	// CacheCap is the maximum number of created namespace names tracked
+	// for DELETE. Zero means no limitation.
+	CacheCap int `json:"cacheCap,omitempty" yaml:"cacheCap,omitempty"`
+}
+
+// RequestDeleteCollection defines DELETE request for a collection of objects.
+type RequestDeleteCollection struct {
+	// KubeGroupVersionResource identifies the resource URI.
+	KubeGroupVersionResource `yaml:",inline"`
+	// Namespace is object's namespace.
+	Namespace string `json:"namespace" yaml:"namespace"`
+	// Selector defines how to identify a set of objects.
+	Selector string `json:"selector" yaml:"selector"`
+	// FieldSelector defines how to identify a set of objects with field selector.
+	FieldSelector string `json:"fieldSelector" yaml:"fieldSelector"`
+	// GracePeriodSeconds is the duration in seconds before the objects
+	// should be deleted. Nil means the default value for the resource.
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty" yaml:"gracePeriodSeconds,omitempty"`
+	// PropagationPolicy determines how garbage collection is performed.
+	// (Foreground, Background or Orphan)
+	PropagationPolicy string `json:"propagationPolicy,omitempty" yaml:"propagationPolicy,omitempty"`
 }
 
 // Validate verifies fields of LoadProfile.
@@ -211,6 +549,14 @@ func (spec LoadProfileSpec) Validate() error {
 		return fmt.Errorf("rate requires >= 0: %v", spec.Rate)
 	}
 
+	if spec.RampUpSeconds < 0 {
+		return fmt.Errorf("rampUpSeconds requires >= 0: %v", spec.RampUpSeconds)
+	}
+
+	if spec.WarmupSeconds < 0 {
+		return fmt.Errorf("warmupSeconds requires >= 0: %v", spec.WarmupSeconds)
+	}
+
 	if spec.Total <= 0 && spec.Duration <= 0 {
 		return fmt.Errorf("total requires > 0: %v or duration > 0s: %v", spec.Total, spec.Duration)
 	}
@@ -224,11 +570,211 @@ func (spec LoadProfileSpec) Validate() error {
 		return err
 	}
 
+	if spec.ValidateResponse && spec.ContentType != ContentTypeJSON {
+		return fmt.Errorf("validateResponse only supports %s content type: %v", ContentTypeJSON, spec.ContentType)
+	}
+
+	// NOTE: Neither Table nor PartialObjectMetadataList is the object
+	// list which can be validated. The subresource other than status,
+	// like scale, isn't the requested object either.
+	if spec.ValidateResponse {
+		for idx, r := range spec.Requests {
+			for _, l := range []*RequestList{r.StaleList, r.QuorumList} {
+				if l != nil && (l.AsTable || l.MetadataOnly) {
+					return fmt.Errorf("idx: %v request: validateResponse doesn't support asTable or metadataOnly", idx)
+				}
+			}
+			for _, g := range []*RequestGet{r.StaleGet, r.QuorumGet} {
+				if g != nil && g.Subresource != "" && g.Subresource != "status" {
+					return fmt.Errorf("idx: %v request: validateResponse doesn't support subresource %s", idx, g.Subresource)
+				}
+			}
+		}
+	}
+
+	// NOTE: The Accept header has no JSON fallback, so that kube-apiserver
+	// responds 406 Not Acceptable for custom resources.
+	if spec.ContentType == ContentTypeProtobuffer {
+		for idx, r := range spec.Requests {
+			if gvr := r.GroupVersionResource(); gvr != nil && !gvr.unresolved() && !protobufGroups[gvr.Group] {
+				return fmt.Errorf("idx: %v request: group %s doesn't support %s content type",
+					idx, gvr.Group, ContentTypeProtobuffer)
+			}
+		}
+	}
+
+	for idx, b := range spec.LatencyBuckets {
+		if b <= 0 || (idx > 0 && b <= spec.LatencyBuckets[idx-1]) {
+			return fmt.Errorf("latencyBuckets requires positive values in increasing order: %v", spec.LatencyBuckets)
+		}
+	}
+
+	for _, p := range spec.Percentiles {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("percentiles requires in [0, 1]: %v", p)
+		}
+	}
+
+	for verb, rate := range spec.MaxFailureRateByVerb {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("maxFailureRateByVerb[%s] requires between 0 and 1: %v", verb, rate)
+		}
+	}
+
+	if spec.RequestTimeoutSeconds < 0 {
+		return fmt.Errorf("requestTimeoutSeconds requires >= 0: %v", spec.RequestTimeoutSeconds)
+	}
+
+	if spec.WatchTimeoutSeconds < 0 {
+		return fmt.Errorf("watchTimeoutSeconds requires >= 0: %v", spec.WatchTimeoutSeconds)
+	}
+
+	if spec.DrainTimeoutSeconds < 0 {
+		return fmt.Errorf("drainTimeoutSeconds requires >= 0: %v", spec.DrainTimeoutSeconds)
+	}
+
+	if spec.NetworkDelayMs < 0 {
+		return fmt.Errorf("networkDelayMs requires >= 0: %v", spec.NetworkDelayMs)
+	}
+
+	if spec.AuthRefreshIntervalSeconds < 0 {
+		return fmt.Errorf("authRefreshIntervalSeconds requires >= 0: %v", spec.AuthRefreshIntervalSeconds)
+	}
+
+	if spec.AcceptEncoding != "" && spec.AcceptEncoding != "gzip" {
+		return fmt.Errorf("acceptEncoding only supports gzip: %v", spec.AcceptEncoding)
+	}
+
+	if spec.RetryBackoffBaseMs < 0 {
+		return fmt.Errorf("retryBackoffBaseMs requires >= 0: %v", spec.RetryBackoffBaseMs)
+	}
+
+	if spec.RetryBackoffMaxMs < 0 {
+		return fmt.Errorf("retryBackoffMaxMs requires >= 0: %v", spec.RetryBackoffMaxMs)
+	}
+
+	if spec.RetryBackoffMaxMs > 0 && spec.RetryBackoffMaxMs < spec.RetryBackoffBaseMs {
+		return fmt.Errorf("retryBackoffMaxMs requires >= retryBackoffBaseMs: %v < %v",
+			spec.RetryBackoffMaxMs, spec.RetryBackoffBaseMs)
+	}
+
+	if spec.MinRequestsPerVerb < 0 {
+		return fmt.Errorf("minRequestsPerVerb requires >= 0: %v", spec.MinRequestsPerVerb)
+	}
+
+	if spec.StopCondition != nil {
+		if err := spec.StopCondition.Validate(); err != nil {
+			return fmt.Errorf("stopCondition: %v", err)
+		}
+	}
+
+	if err := validateTargets(spec.Targets); err != nil {
+		return err
+	}
+
+	// NOTE: Each request is sent to a random target, so the object
+	// created in one target might be read or deleted in another one.
+	if len(spec.Targets) > 0 {
+		for idx, r := range spec.Requests {
+			if r.PostDel != nil || r.CreateNamespace != nil ||
+				(r.StaleGet != nil && r.StaleGet.FromCache != "") ||
+				(r.QuorumGet != nil && r.QuorumGet.FromCache != "") {
+				return fmt.Errorf("idx: %v request: targets don't support postDel, createNamespace or fromCache", idx)
+			}
+		}
+	}
+
 	for idx, req := range spec.Requests {
 		if err := req.Validate(); err != nil {
 			return fmt.Errorf("idx: %v request: %v", idx, err)
 		}
 	}
+	if err := validateShares(spec.Requests); err != nil {
+		return err
+	}
+	return validateCacheLinks(spec.Requests)
+}
+
+// validateShares verifies that requests can be picked by weight at both
+// the start and the end of run.
+func validateShares(reqs []*WeightedRequest) error {
+	shares, endShares := 0, 0
+	for _, req := range reqs {
+		shares += req.Shares
+		if req.EndShares != nil {
+			endShares += *req.EndShares
+		} else {
+			endShares += req.Shares
+		}
+	}
+	if shares == 0 {
+		return fmt.Errorf("all the requests have zero shares")
+	}
+	if endShares == 0 {
+		return fmt.Errorf("all the requests have zero endShares")
+	}
+	return nil
+}
+
+// validateTargets verifies that targets have unique names and positive
+// shares.
+func validateTargets(targets []*Target) error {
+	names := map[string]bool{}
+	for idx, t := range targets {
+		if t.Name == "" {
+			return fmt.Errorf("idx: %v target: name is required", idx)
+		}
+		if names[t.Name] {
+			return fmt.Errorf("idx: %v target: duplicate name %s", idx, t.Name)
+		}
+		names[t.Name] = true
+
+		if t.Shares <= 0 {
+			return fmt.Errorf("idx: %v target: shares requires > 0: %v", idx, t.Shares)
+		}
+	}
+	return nil
+}
+
+// validateCacheLinks verifies that each GET request's fromCache refers to
+// a postDel request with the same resource and namespace.
+func validateCacheLinks(reqs []*WeightedRequest) error {
+	caches := map[string]*RequestPostDel{}
+	for idx, req := range reqs {
+		if req.PostDel == nil || req.PostDel.CacheName == "" {
+			continue
+		}
+		if _, ok := caches[req.PostDel.CacheName]; ok {
+			return fmt.Errorf("idx: %v request: duplicate cacheName %s", idx, req.PostDel.CacheName)
+		}
+		caches[req.PostDel.CacheName] = req.PostDel
+	}
+
+	for idx, req := range reqs {
+		get := req.StaleGet
+		if get == nil {
+			get = req.QuorumGet
+		}
+		if get == nil || get.FromCache == "" {
+			continue
+		}
+
+		src, ok := caches[get.FromCache]
+		if !ok {
+			return fmt.Errorf("idx: %v request: unknown fromCache %s", idx, get.FromCache)
+		}
+		if src.KubeGroupVersionResource != get.KubeGroupVersionResource || src.Namespace != get.Namespace {
+			return fmt.Errorf("idx: %v request: fromCache %s requires the same resource and namespace", idx, get.FromCache)
+		}
+	}
+	return nil
+}
+
+// Validate verifies fields of HealthStopCondition.
+func (c *HealthStopCondition) Validate() error {
+	if c.MaxInflightRequests <= 0 && c.MaxReadyzFailures <= 0 {
+		return fmt.Errorf("requires maxInflightRequests > 0 or maxReadyzFailures > 0")
+	}
 	return nil
 }
 
@@ -238,6 +784,10 @@ func (r WeightedRequest) Validate() error {
 		return fmt.Errorf("shares(%v) requires >= 0", r.Shares)
 	}
 
+	if r.EndShares != nil && *r.EndShares < 0 {
+		return fmt.Errorf("endShares(%v) requires >= 0", *r.EndShares)
+	}
+
 	switch {
 	case r.StaleList != nil:
 		return r.StaleList.Validate(true)
@@ -257,6 +807,12 @@ func (r WeightedRequest) Validate() error {
 		return r.GetPodLog.Validate()
 	case r.PostDel != nil:
 		return r.PostDel.Validate()
+	case r.Post != nil:
+		return r.Post.Validate()
+	case r.DeleteCollection != nil:
+		return r.DeleteCollection.Validate()
+	case r.CreateNamespace != nil:
+		return r.CreateNamespace.Validate()
 	default:
 		return fmt.Errorf("empty request value")
 	}
@@ -275,6 +831,19 @@ func (r *RequestList) Validate(stale bool) error {
 	if stale && r.Limit != 0 {
 		return fmt.Errorf("stale list doesn't support pagination option: https://github.com/kubernetes/kubernetes/issues/108003")
 	}
+
+	if r.AsTable && r.MetadataOnly {
+		return fmt.Errorf("asTable can't be used with metadataOnly")
+	}
+
+	if r.NodeNameFromNodepool != "" {
+		if r.Group != "" || (r.Resource != "pods" && r.Kind != "Pod") {
+			return fmt.Errorf("nodeNameFromNodepool only supports pods")
+		}
+		if r.FieldSelector != "" {
+			return fmt.Errorf("nodeNameFromNodepool can't be used with fieldSelector")
+		}
+	}
 	return nil
 }
 
@@ -294,6 +863,12 @@ func (r *RequestGet) Validate() error {
 	if r.Name == "" {
 		return fmt.Errorf("name is required")
 	}
+
+	if r.Subresource != "" {
+		if msgs := path.IsValidPathSegmentName(r.Subresource); len(msgs) > 0 {
+			return fmt.Errorf("invalid subresource %q: %s", r.Subresource, strings.Join(msgs, ", "))
+		}
+	}
 	return nil
 }
 
@@ -303,13 +878,29 @@ func (r *RequestPut) Validate() error {
 		return fmt.Errorf("kube metadata: %v", err)
 	}
 
-	// TODO: check resource type
 	if r.Name == "" {
 		return fmt.Errorf("name pattern is required")
 	}
 	if r.KeySpaceSize <= 0 {
 		return fmt.Errorf("keySpaceSize must > 0")
 	}
+
+	if r.Body != "" {
+		if err := json.Unmarshal([]byte(r.Body), &map[string]interface{}{}); err != nil {
+			return fmt.Errorf("invalid put body %q: %w", r.Body, err)
+		}
+		return nil
+	}
+
+	// NOTE: Without body, only the resource with blob data can be
+	// generated randomly. The request specified by kind is validated
+	// again after it's resolved.
+	if r.unresolved() {
+		return nil
+	}
+	if r.Resource != "configmaps" && r.Resource != "secrets" {
+		return fmt.Errorf("body is required for %s, only configmaps or secrets can be generated", r.Resource)
+	}
 	if r.ValueSize <= 0 {
 		return fmt.Errorf("valueSize must > 0")
 	}
@@ -329,16 +920,27 @@ func (r *RequestGetPodLog) Validate() error {
 
 // Validate validates KubeGroupVersionResource.
 func (m *KubeGroupVersionResource) Validate() error {
+	// NOTE: It's expected to be resolved by discovery later.
+	if m.unresolved() {
+		return nil
+	}
+
 	if m.Version == "" {
 		return fmt.Errorf("version is required")
 	}
 
 	if m.Resource == "" {
-		return fmt.Errorf("resource is required")
+		return fmt.Errorf("resource or kind is required")
 	}
 	return nil
 }
 
+// unresolved returns true if it's specified by kind and the group, version
+// and resource haven't been resolved yet.
+func (m *KubeGroupVersionResource) unresolved() bool {
+	return m.Resource == "" && m.Kind != ""
+}
+
 // GetPatchType returns the Kubernetes PatchType for a given patch type string.
 // Returns the PatchType and an error if the patch type is invalid.
 func GetPatchType(patchType string) (apitypes.PatchType, bool) {
@@ -349,6 +951,8 @@ func GetPatchType(patchType string) (apitypes.PatchType, bool) {
 		return apitypes.MergePatchType, true
 	case "strategic-merge":
 		return apitypes.StrategicMergePatchType, true
+	case "apply":
+		return apitypes.ApplyPatchType, true
 	default:
 		return "", false
 	}
@@ -362,25 +966,122 @@ func (r *RequestPatch) Validate() error {
 	if r.Name == "" {
 		return fmt.Errorf("name is required")
 	}
-	if r.Body == "" {
-		return fmt.Errorf("body is required")
+	if r.Body == "" && len(r.Bodies) == 0 {
+		return fmt.Errorf("body or bodies is required")
 	}
 
 	// Validate patch type
-	_, ok := GetPatchType(r.PatchType)
+	patchType, ok := GetPatchType(r.PatchType)
+	if !ok {
+		return fmt.Errorf("unknown patch type: %s (valid types: json, merge, strategic-merge, apply)", r.PatchType)
+	}
+
+	// NOTE: kube-apiserver rejects server-side apply without fieldManager.
+	if patchType == apitypes.ApplyPatchType && r.FieldManager == "" {
+		return fmt.Errorf("fieldManager is required by apply patch")
+	}
+	if r.Force && patchType != apitypes.ApplyPatchType {
+		return fmt.Errorf("force is only supported by apply patch")
+	}
+	if len(r.FieldManager) > maxFieldManagerLength {
+		return fmt.Errorf("fieldManager must be no more than %d characters", maxFieldManagerLength)
+	}
+
+	switch r.BodyOrder {
+	case "", PatchBodyOrderRoundRobin, PatchBodyOrderRandom:
+	default:
+		return fmt.Errorf("unknown body order: %s (valid orders: roundRobin, random)", r.BodyOrder)
+	}
+
+	_, err := r.NormalizedBodies()
+	return err
+}
+
+// NormalizedBodies validates body and bodies, and returns them in order
+// with the format sent to kube-apiserver. The JSON body is trimmed and the
+// apply body in YAML is converted into JSON. The RequestPatch is unchanged.
+func (r *RequestPatch) NormalizedBodies() ([]string, error) {
+	patchType, ok := GetPatchType(r.PatchType)
 	if !ok {
-		return fmt.Errorf("unknown patch type: %s (valid types: json, merge, strategic-merge)", r.PatchType)
+		return nil, fmt.Errorf("unknown patch type: %s", r.PatchType)
+	}
+
+	res := make([]string, 0, len(r.Bodies)+1)
+	if r.Body != "" {
+		body, err := validatePatchBody(patchType, r.Body, r.KeySpaceSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, body)
+	}
+
+	for idx, body := range r.Bodies {
+		normalized, err := validatePatchBody(patchType, body, r.KeySpaceSize)
+		if err != nil {
+			return nil, fmt.Errorf("bodies[%d]: %w", idx, err)
+		}
+		res = append(res, normalized)
+	}
+	return res, nil
+}
+
+// maxFieldManagerLength is the maximum length of fieldManager accepted by
+// kube-apiserver.
+const maxFieldManagerLength = 128
+
+// validatePatchBody verifies that body can be parsed for the patch type and
+// returns the trimmed body. The JSON patch should be an array of operations
+// and the others should be an object. The apply patch can be in YAML and
+// it's converted into JSON.
+func validatePatchBody(patchType apitypes.PatchType, body string, keySpaceSize int) (string, error) {
+	if patchType == apitypes.ApplyPatchType {
+		return validateApplyPatchBody(body, keySpaceSize)
 	}
 
-	// Validate JSON body and trim it
-	trimmed := strings.TrimSpace(r.Body)
+	trimmed := strings.TrimSpace(body)
 	if !json.Valid([]byte(trimmed)) {
-		return fmt.Errorf("invalid JSON in patch body: %q", r.Body)
+		return "", fmt.Errorf("invalid JSON in patch body: %q", body)
+	}
+
+	var err error
+	if patchType == apitypes.JSONPatchType {
+		err = json.Unmarshal([]byte(trimmed), &[]map[string]interface{}{})
+	} else {
+		err = json.Unmarshal([]byte(trimmed), &map[string]interface{}{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid %s patch body %q: %w", patchType, body, err)
 	}
+	return trimmed, nil
+}
 
-	r.Body = trimmed // Store the trimmed body
+// validateApplyPatchBody verifies that body is a fully specified intent
+// object in YAML or JSON and returns it in JSON. The name in metadata is
+// rejected with keySpaceSize because it must match the generated name in
+// URL.
+func validateApplyPatchBody(body string, keySpaceSize int) (string, error) {
+	data, err := yaml.YAMLToJSON([]byte(body))
+	if err != nil {
+		return "", fmt.Errorf("invalid apply patch body %q: %w", body, err)
+	}
 
-	return nil
+	var obj struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return "", fmt.Errorf("invalid apply patch body %q: %w", body, err)
+	}
+	if obj.APIVersion == "" || obj.Kind == "" {
+		return "", fmt.Errorf("apply patch body %q requires apiVersion and kind", body)
+	}
+	if obj.Metadata.Name != "" && keySpaceSize > 0 {
+		return "", fmt.Errorf("apply patch body %q can't set metadata.name with keySpaceSize", body)
+	}
+	return string(data), nil
 }
 
 func (r *RequestPostDel) Validate() error {
@@ -392,5 +1093,56 @@ func (r *RequestPostDel) Validate() error {
 		return fmt.Errorf("delete ratio must be between 0 and 0.5: %v, create proportion should be greater than delete", r.DeleteRatio)
 	}
 
+	if r.GracePeriodSeconds != nil && *r.GracePeriodSeconds < 0 {
+		return fmt.Errorf("grace period seconds requires >= 0: %v", *r.GracePeriodSeconds)
+	}
+
+	if r.CacheCap < 0 {
+		return fmt.Errorf("cacheCap requires >= 0: %v", r.CacheCap)
+	}
+
+	if err := validatePropagationPolicy(r.PropagationPolicy); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *RequestPost) Validate() error {
+	if err := r.KubeGroupVersionResource.Validate(); err != nil {
+		return fmt.Errorf("kube metadata: %v", err)
+	}
 	return nil
 }
+
+func (r *RequestCreateNamespace) Validate() error {
+	if r.DeleteRatio < 0 || r.DeleteRatio > 0.5 {
+		return fmt.Errorf("delete ratio must be between 0 and 0.5: %v, create proportion should be greater than delete", r.DeleteRatio)
+	}
+
+	if r.CacheCap < 0 {
+		return fmt.Errorf("cacheCap requires >= 0: %v", r.CacheCap)
+	}
+	return nil
+}
+
+func (r *RequestDeleteCollection) Validate() error {
+	if err := r.KubeGroupVersionResource.Validate(); err != nil {
+		return fmt.Errorf("kube metadata: %v", err)
+	}
+
+	if r.GracePeriodSeconds != nil && *r.GracePeriodSeconds < 0 {
+		return fmt.Errorf("grace period seconds requires >= 0: %v", *r.GracePeriodSeconds)
+	}
+	return validatePropagationPolicy(r.PropagationPolicy)
+}
+
+// validatePropagationPolicy returns error if policy isn't supported. Empty
+// policy means the default one for the resource.
+func validatePropagationPolicy(policy string) error {
+	switch policy {
+	case "", "Foreground", "Background", "Orphan":
+		return nil
+	default:
+		return fmt.Errorf("unsupported propagation policy %s (Foreground, Background or Orphan)", policy)
+	}
+}
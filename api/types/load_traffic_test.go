@@ -191,6 +191,237 @@ func TestWeightedRequest(t *testing.T) {
 			},
 			hasErr: true,
 		},
+		{
+			name: "json patch body isn't array",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "json",
+					Bodies: []string{
+						`[{"op":"add","path":"/data/a","value":"1"}]`,
+						`{"data":{"a":"1"}}`,
+					},
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "apply patch in yaml",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "apply",
+					FieldManager: "kperf",
+					Body:         "apiVersion: v1\nkind: ConfigMap\ndata:\n  a: \"1\"\n",
+				},
+			},
+		},
+		{
+			name: "apply patch without field manager",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "apply",
+					Body:         `{"apiVersion":"v1","kind":"ConfigMap"}`,
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "force without apply patch",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "merge",
+					Force:        true,
+					Body:         `{"data":{"a":"1"}}`,
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "apply patch without kind",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "apply",
+					FieldManager: "kperf",
+					Body:         `{"data":{"a":"1"}}`,
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "apply patch with name and key space",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "apply",
+					FieldManager: "kperf",
+					Body:         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n",
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "apply patch with name",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					PatchType:    "apply",
+					FieldManager: "kperf",
+					Body:         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm\n",
+				},
+			},
+		},
+		{
+			name: "multiple merge patch bodies",
+			req: &WeightedRequest{
+				Shares: 10,
+				Patch: &RequestPatch{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 10,
+					PatchType:    "merge",
+					Bodies: []string{
+						` {"data":{"a":"1"}}`,
+						`{"metadata":{"labels":{"b":"2"}}}`,
+					},
+					BodyOrder: PatchBodyOrderRandom,
+				},
+			},
+		},
+		{
+			name: "wrong propagation policy",
+			req: &WeightedRequest{
+				Shares: 10,
+				PostDel: &RequestPostDel{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:         "default",
+					DeleteRatio:       0.5,
+					PropagationPolicy: "Cascade",
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "delete collection without resource",
+			req: &WeightedRequest{
+				Shares: 10,
+				DeleteCollection: &RequestDeleteCollection{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version: "v1",
+					},
+					Namespace: "default",
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "delete collection",
+			req: &WeightedRequest{
+				Shares: 10,
+				DeleteCollection: &RequestDeleteCollection{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:         "default",
+					Selector:          "app=kperf",
+					PropagationPolicy: "Background",
+				},
+			},
+		},
+		{
+			name: "create namespace without group version",
+			req: &WeightedRequest{
+				Shares: 10,
+				CreateNamespace: &RequestCreateNamespace{
+					DeleteRatio: 0.3,
+				},
+			},
+		},
+		{
+			name: "create namespace with too many deletes",
+			req: &WeightedRequest{
+				Shares: 10,
+				CreateNamespace: &RequestCreateNamespace{
+					DeleteRatio: 0.6,
+				},
+			},
+			hasErr: true,
+		},
+		{
+			name: "negative grace period",
+			req: &WeightedRequest{
+				Shares: 10,
+				PostDel: &RequestPostDel{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:          "default",
+					DeleteRatio:        0.5,
+					GracePeriodSeconds: func() *int64 { v := int64(-1); return &v }(),
+				},
+			},
+			hasErr: true,
+		},
 		{
 			name: "no error",
 			req: &WeightedRequest{
@@ -218,3 +449,309 @@ func TestWeightedRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadProfileSpecValidateCacheLinks(t *testing.T) {
+	gvr := KubeGroupVersionResource{
+		Version:  "v1",
+		Resource: "configmaps",
+	}
+	newSpec := func(cacheName string, get RequestGet) LoadProfileSpec {
+		get.KubeGroupVersionResource = gvr
+		get.Name = "cm"
+		return LoadProfileSpec{
+			Rate:        1,
+			Total:       1,
+			Conns:       1,
+			Client:      1,
+			ContentType: ContentTypeJSON,
+			Requests: []*WeightedRequest{
+				{
+					Shares: 1,
+					PostDel: &RequestPostDel{
+						KubeGroupVersionResource: gvr,
+						Namespace:                "default",
+						CacheName:                cacheName,
+					},
+				},
+				{
+					Shares:    1,
+					QuorumGet: &get,
+				},
+			},
+		}
+	}
+
+	assert.NoError(t, newSpec("cms", RequestGet{Namespace: "default", FromCache: "cms"}).Validate())
+	assert.NoError(t, newSpec("", RequestGet{Namespace: "default"}).Validate())
+	assert.Error(t, newSpec("", RequestGet{Namespace: "default", FromCache: "cms"}).Validate())
+	assert.Error(t, newSpec("cms", RequestGet{Namespace: "other", FromCache: "cms"}).Validate())
+}
+
+func TestLoadProfileSpecValidateProtobuf(t *testing.T) {
+	newSpec := func(ct ContentType, group string) LoadProfileSpec {
+		return LoadProfileSpec{
+			Rate:        1,
+			Total:       1,
+			Conns:       1,
+			Client:      1,
+			ContentType: ct,
+			Requests: []*WeightedRequest{
+				{
+					Shares: 1,
+					StaleList: &RequestList{
+						KubeGroupVersionResource: KubeGroupVersionResource{
+							Group:    group,
+							Version:  "v1",
+							Resource: "foos",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	assert.NoError(t, newSpec(ContentTypeProtobuffer, "").Validate())
+	assert.NoError(t, newSpec(ContentTypeProtobuffer, "apps").Validate())
+	assert.NoError(t, newSpec(ContentTypeJSON, "example.com").Validate())
+	assert.Error(t, newSpec(ContentTypeProtobuffer, "example.com").Validate())
+	assert.Error(t, newSpec(ContentTypeProtobuffer, "core").Validate())
+
+	// NOTE: The request specified by kind is checked after resolution.
+	spec := newSpec(ContentTypeProtobuffer, "example.com")
+	spec.Requests[0].StaleList.Resource = ""
+	spec.Requests[0].StaleList.Kind = "Foo"
+	assert.NoError(t, spec.Validate())
+	spec.Requests[0].StaleList.Resource = "foos"
+	assert.Error(t, spec.Validate())
+}
+
+func TestRequestPutValidateKind(t *testing.T) {
+	put := &RequestPut{
+		KubeGroupVersionResource: KubeGroupVersionResource{
+			Kind: "ConfigMap",
+		},
+		Name:         "cm",
+		KeySpaceSize: 1,
+		ValueSize:    1,
+	}
+	assert.NoError(t, put.Validate())
+
+	put.Version, put.Resource = "v1", "configmaps"
+	assert.NoError(t, put.Validate())
+
+	put.Group, put.Version, put.Resource, put.Kind = "apps", "v1", "deployments", "Deployment"
+	assert.Error(t, put.Validate())
+}
+
+func TestLoadProfileSpecValidateAsTable(t *testing.T) {
+	spec := LoadProfileSpec{
+		Rate:             1,
+		Total:            1,
+		Conns:            1,
+		Client:           1,
+		ContentType:      ContentTypeJSON,
+		ValidateResponse: true,
+		Requests: []*WeightedRequest{
+			{
+				Shares: 1,
+				QuorumList: &RequestList{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					AsTable: true,
+				},
+			},
+		},
+	}
+	assert.Error(t, spec.Validate())
+
+	spec.ValidateResponse = false
+	assert.NoError(t, spec.Validate())
+}
+
+func TestRequestListValidateNodeNameFromNodepool(t *testing.T) {
+	req := RequestList{
+		KubeGroupVersionResource: KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		NodeNameFromNodepool: "pool",
+	}
+	assert.NoError(t, req.Validate(true))
+
+	req.FieldSelector = "spec.nodeName=x"
+	assert.Error(t, req.Validate(true))
+
+	req.FieldSelector = ""
+	req.Resource = "configmaps"
+	assert.Error(t, req.Validate(true))
+}
+
+func TestLoadProfileSpecValidateRetryBackoff(t *testing.T) {
+	spec := LoadProfileSpec{
+		Rate:               1,
+		Total:              1,
+		Conns:              1,
+		Client:             1,
+		ContentType:        ContentTypeJSON,
+		RetryBackoffBaseMs: 100,
+		Requests: []*WeightedRequest{
+			{
+				Shares: 1,
+				StaleGet: &RequestGet{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Name: "x",
+				},
+			},
+		},
+	}
+	assert.NoError(t, spec.Validate())
+
+	spec.RetryBackoffMaxMs = 1000
+	assert.NoError(t, spec.Validate())
+
+	spec.RetryBackoffMaxMs = 10
+	assert.Error(t, spec.Validate())
+
+	spec.RetryBackoffMaxMs = 0
+	spec.RetryBackoffBaseMs = -1
+	assert.Error(t, spec.Validate())
+}
+
+func TestLoadProfileSpecValidatePercentiles(t *testing.T) {
+	spec := LoadProfileSpec{
+		Rate:        1,
+		Total:       1,
+		Conns:       1,
+		Client:      1,
+		ContentType: ContentTypeJSON,
+		Percentiles: []float64{0, 0.5, 0.999, 1},
+		Requests: []*WeightedRequest{
+			{
+				Shares: 1,
+				StaleGet: &RequestGet{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Name: "x",
+				},
+			},
+		},
+	}
+	assert.NoError(t, spec.Validate())
+
+	spec.Percentiles = []float64{-0.1}
+	assert.Error(t, spec.Validate())
+
+	spec.Percentiles = []float64{1.1}
+	assert.Error(t, spec.Validate())
+}
+
+func TestValidateTargets(t *testing.T) {
+	assert.NoError(t, validateTargets(nil))
+	assert.NoError(t, validateTargets([]*Target{
+		{Name: "a", Shares: 1},
+		{Name: "b", Kubeconfig: "/tmp/kubeconfig", Context: "b", Shares: 2},
+	}))
+	assert.Error(t, validateTargets([]*Target{{Shares: 1}}))
+	assert.Error(t, validateTargets([]*Target{{Name: "a", Shares: 1}, {Name: "a", Shares: 1}}))
+	assert.Error(t, validateTargets([]*Target{{Name: "a"}}))
+}
+
+func TestValidateShares(t *testing.T) {
+	zero, one := 0, 1
+	assert.NoError(t, validateShares([]*WeightedRequest{{Shares: 1}, {Shares: 0}}))
+	assert.NoError(t, validateShares([]*WeightedRequest{{Shares: 0, EndShares: &one}, {Shares: 1, EndShares: &zero}}))
+	assert.Error(t, validateShares(nil))
+	assert.Error(t, validateShares([]*WeightedRequest{{Shares: 0}, {Shares: 0}}))
+	assert.Error(t, validateShares([]*WeightedRequest{{Shares: 0, EndShares: &one}}))
+	assert.Error(t, validateShares([]*WeightedRequest{{Shares: 1, EndShares: &zero}}))
+}
+
+func TestLoadProfileSpecValidateTargetsWithCache(t *testing.T) {
+	spec := LoadProfileSpec{
+		Rate:        1,
+		Total:       1,
+		Conns:       1,
+		Client:      1,
+		ContentType: ContentTypeJSON,
+		Targets:     []*Target{{Name: "a", Shares: 1}},
+		Requests: []*WeightedRequest{
+			{
+				Shares: 1,
+				StaleGet: &RequestGet{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Namespace: "default",
+					Name:      "x",
+				},
+			},
+		},
+	}
+	assert.NoError(t, spec.Validate())
+
+	spec.Requests = append(spec.Requests, &WeightedRequest{
+		Shares:          1,
+		CreateNamespace: &RequestCreateNamespace{DeleteRatio: 0.1},
+	})
+	assert.Error(t, spec.Validate())
+
+	spec.Requests = spec.Requests[:1]
+	spec.Requests = append(spec.Requests, &WeightedRequest{
+		Shares: 1,
+		PostDel: &RequestPostDel{
+			KubeGroupVersionResource: KubeGroupVersionResource{
+				Version:  "v1",
+				Resource: "pods",
+			},
+			Namespace: "default",
+		},
+	})
+	assert.Error(t, spec.Validate())
+}
+
+func TestLoadProfileSpecValidateSubresource(t *testing.T) {
+	spec := LoadProfileSpec{
+		Rate:             1,
+		Total:            1,
+		Conns:            1,
+		Client:           1,
+		ContentType:      ContentTypeJSON,
+		ValidateResponse: true,
+		Requests: []*WeightedRequest{
+			{
+				Shares: 1,
+				StaleGet: &RequestGet{
+					KubeGroupVersionResource: KubeGroupVersionResource{
+						Group:    "apps",
+						Version:  "v1",
+						Resource: "deployments",
+					},
+					Namespace:   "default",
+					Name:        "x",
+					Subresource: "status",
+				},
+			},
+		},
+	}
+	assert.NoError(t, spec.Validate())
+
+	spec.Requests[0].StaleGet.Subresource = "scale"
+	assert.Error(t, spec.Validate())
+
+	spec.ValidateResponse = false
+	assert.NoError(t, spec.Validate())
+
+	spec.Requests[0].StaleGet.Subresource = "scale/x"
+	assert.Error(t, spec.Validate())
+
+	spec.Requests[0].StaleGet.Subresource = ".."
+	assert.Error(t, spec.Validate())
+}
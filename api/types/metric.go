@@ -18,6 +18,9 @@ const (
 	// ResponseErrorTypeConnection indicates that error is related to connection.
 	// For instance, connection refused caused by server down.
 	ResponseErrorTypeConnection ResponseErrorType = "connection"
+	// ResponseErrorTypeDecode indicates that the response can't be decoded
+	// or the object doesn't match the request.
+	ResponseErrorTypeDecode ResponseErrorType = "decode"
 )
 
 // ResponseError is the record about that error.
@@ -38,14 +41,99 @@ type ResponseError struct {
 	Message string `json:"message"`
 }
 
+// LatencyHistogram is the distribution of latencies in buckets. Unlike
+// percentiles, histograms with the same buckets can be merged across
+// runners before computing percentiles.
+type LatencyHistogram struct {
+	// Buckets are the upper bounds in seconds in increasing order.
+	Buckets []float64 `json:"buckets"`
+	// Counts is the number of latencies in each bucket, which isn't
+	// cumulative. The last one is for +Inf bucket so that it has one
+	// more item than Buckets.
+	Counts []int64 `json:"counts"`
+	// Sum is the sum of all the latencies in seconds.
+	Sum float64 `json:"sum"`
+}
+
 // ResponseStats is the report about benchmark result.
 type ResponseStats struct {
-	// Errors stores all the observed errors.
+	// Errors stores the observed errors. It might only keep the recent
+	// errors if the number of samples is capped.
 	Errors []ResponseError
+	// ErrorStats means summary of all the observed errors group by type.
+	ErrorStats map[string]int32
+	// ErrorClasses stores the number of failures for each error class,
+	// like timeout or throttled.
+	ErrorClasses map[string]int
 	// LatenciesByURL stores all the observed latencies for each request.
 	LatenciesByURL map[string][]float64
+	// LatencyHistogramsByURL stores the latency histogram for each
+	// request. It's used instead of LatenciesByURL if the metric is backed
+	// by histogram.
+	LatencyHistogramsByURL map[string]LatencyHistogram
+	// LatenciesByMethod stores all the observed latencies for each verb.
+	LatenciesByMethod map[string][]float64
+	// LatencyHistogramsByMethod stores the latency histogram for each verb.
+	LatencyHistogramsByMethod map[string]LatencyHistogram
 	// TotalReceivedBytes is total bytes read from apiserver.
 	TotalReceivedBytes int64
+	// TotalWireBytes is total bytes of response body read from the wire
+	// before decompression. It's only recorded with gzip accept encoding.
+	TotalWireBytes int64
+	// TotalSentBytes is total bytes of request body sent to apiserver.
+	TotalSentBytes int64
+	// ReceivedBytesByMethod is bytes read from apiserver for each verb.
+	ReceivedBytesByMethod map[string]int64
+	// TotalByMethod stores the number of requests for each verb.
+	TotalByMethod map[string]int
+	// FailuresByMethod stores the number of failed requests for each verb.
+	FailuresByMethod map[string]int
+	// FailuresByStatusCode stores the number of failed requests for each
+	// HTTP status code.
+	FailuresByStatusCode map[int]int
+	// ActualQPS is the number of completed requests per second.
+	ActualQPS float64
+	// ErrorRate is the ratio of failed requests to completed requests.
+	ErrorRate float64
+	// WatchStats is the summary of watch streams. It's nil if there is
+	// no watch request.
+	WatchStats *WatchStats
+	// TimesToFirstByte stores the seconds from sending request to
+	// receiving response headers for each successful request. Watch
+	// streams aren't included.
+	TimesToFirstByte []float64
+	// LatenciesByInstance stores the latencies of successful requests
+	// for each kube-apiserver instance identified by response header.
+	LatenciesByInstance map[string][]float64
+}
+
+// WatchStats is the summary of watch streams.
+type WatchStats struct {
+	// EventsByType stores the number of received events for each type,
+	// like ADDED, MODIFIED, DELETED or BOOKMARK.
+	EventsByType map[string]int64
+	// TimesToFirstEvent stores the seconds from sending watch request to
+	// receiving the first event for each stream.
+	TimesToFirstEvent []float64
+	// PartialWatches is the number of streams closed by server before
+	// the initial events end.
+	PartialWatches int
+	// Reconnects is the number of reconnects made by resumed watches.
+	Reconnects int
+	// ReconnectLatencies stores the seconds from stream close to the
+	// reconnected stream for each reconnect.
+	ReconnectLatencies []float64
+}
+
+// FailureThresholdViolation records the verb whose failure rate exceeds
+// the expected threshold.
+type FailureThresholdViolation struct {
+	// Method is the verb of requests, like GET.
+	Method string `json:"method"`
+	// FailureRate is the observed failure rate.
+	FailureRate float64 `json:"failureRate"`
+	// MaxFailureRate is the expected threshold.
+	MaxFailureRate float64 `json:"maxFailureRate"`
 }
 
 type RunnerMetricReport struct {
@@ -53,18 +141,164 @@ type RunnerMetricReport struct {
 	Total int `json:"total"`
 	// Duration means the time of benchmark.
 	Duration string `json:"duration"`
+	// WarmupDuration is the time of warmup before the benchmark, whose
+	// requests aren't recorded.
+	WarmupDuration string `json:"warmupDuration,omitempty"`
+	// ActualQPS is the number of completed requests per second.
+	ActualQPS float64 `json:"actualQPS"`
+	// ErrorRate is the ratio of failed requests to completed requests.
+	ErrorRate float64 `json:"errorRate"`
 	// Errors stores all the observed errors.
 	Errors []ResponseError `json:"errors,omitempty"`
 	// ErrorStats means summary of errors group by type.
 	ErrorStats map[string]int32 `json:"errorStats,omitempty"`
+	// ErrorClasses represents the number of failures for each error
+	// class, like timeout, throttled or conflict.
+	ErrorClasses map[string]int `json:"errorClasses,omitempty"`
 	// TotalReceivedBytes is total bytes read from apiserver.
 	TotalReceivedBytes int64 `json:"totalReceivedBytes"`
+	// TotalWireBytes is total bytes of response body read from the wire
+	// before decompression. It's only reported with gzip accept encoding.
+	TotalWireBytes int64 `json:"totalWireBytes,omitempty"`
+	// TotalSentBytes is total bytes of request body sent to apiserver.
+	TotalSentBytes int64 `json:"totalSentBytes,omitempty"`
 	// LatenciesByURL stores all the observed latencies.
 	LatenciesByURL map[string][]float64 `json:"latenciesByURL,omitempty"`
+	// LatenciesByMethod stores all the observed latencies for each verb.
+	LatenciesByMethod map[string][]float64 `json:"latenciesByMethod,omitempty"`
+	// LatencyHistogramsByURL stores the latency histograms if the runner
+	// uses histogram instead of raw latencies.
+	LatencyHistogramsByURL map[string]LatencyHistogram `json:"latencyHistogramsByURL,omitempty"`
+	// LatencyHistogramsByMethod stores the latency histograms for each
+	// verb if the runner uses histogram instead of raw latencies.
+	LatencyHistogramsByMethod map[string]LatencyHistogram `json:"latencyHistogramsByMethod,omitempty"`
 	// PercentileLatencies represents the latency distribution in seconds.
 	PercentileLatencies [][2]float64 `json:"percentileLatencies,omitempty"`
 	// PercentileLatenciesByURL represents the latency distribution in seconds per request.
 	PercentileLatenciesByURL map[string][][2]float64 `json:"percentileLatenciesByURL,omitempty"`
+	// PercentileLatenciesByMethod represents the latency distribution in
+	// seconds per verb, like LIST or WATCHLIST.
+	PercentileLatenciesByMethod map[string][][2]float64 `json:"percentileLatenciesByMethod,omitempty"`
+	// ReceivedBytesByMethod represents bytes read from apiserver per verb.
+	ReceivedBytesByMethod map[string]int64 `json:"receivedBytesByMethod,omitempty"`
+	// TotalByMethod represents total number of requests for each verb.
+	TotalByMethod map[string]int `json:"totalByMethod,omitempty"`
+	// FailuresByMethod represents total number of failed requests for each verb.
+	FailuresByMethod map[string]int `json:"failuresByMethod,omitempty"`
+	// FailuresByStatusCode represents total number of failed requests for
+	// each HTTP status code, like 429 for throttling.
+	FailuresByStatusCode map[int]int `json:"failuresByStatusCode,omitempty"`
+	// WatchEventsByType represents the number of received watch events
+	// for each type, like ADDED or BOOKMARK.
+	WatchEventsByType map[string]int64 `json:"watchEventsByType,omitempty"`
+	// PercentileTimesToFirstWatchEvent represents the distribution of
+	// time in seconds from sending watch request to the first event.
+	PercentileTimesToFirstWatchEvent [][2]float64 `json:"percentileTimesToFirstWatchEvent,omitempty"`
+	// PercentileTimesToFirstByte represents the distribution of time in
+	// seconds from sending request to receiving response headers. The
+	// rest of latency is spent on streaming response body.
+	PercentileTimesToFirstByte [][2]float64 `json:"percentileTimesToFirstByte,omitempty"`
+	// TimesToFirstByte stores all the observed times to first byte.
+	TimesToFirstByte []float64 `json:"timesToFirstByte,omitempty"`
+	// PercentileLatenciesByInstance represents the latency distribution
+	// in seconds per kube-apiserver instance identified by response
+	// header.
+	PercentileLatenciesByInstance map[string][][2]float64 `json:"percentileLatenciesByInstance,omitempty"`
+	// LatenciesByInstance stores all the observed latencies for each
+	// kube-apiserver instance.
+	LatenciesByInstance map[string][]float64 `json:"latenciesByInstance,omitempty"`
+	// PartialWatches represents the number of watch streams closed by
+	// server before the initial events end.
+	PartialWatches int `json:"partialWatches,omitempty"`
+	// WatchReconnects represents the number of reconnects made by resumed
+	// watch streams.
+	WatchReconnects int `json:"watchReconnects,omitempty"`
+	// PercentileWatchReconnectLatencies represents the distribution of
+	// time in seconds from stream close to the reconnected stream.
+	PercentileWatchReconnectLatencies [][2]float64 `json:"percentileWatchReconnectLatencies,omitempty"`
+	// WatchReconnectLatencies stores all the observed reconnect latencies.
+	WatchReconnectLatencies []float64 `json:"watchReconnectLatencies,omitempty"`
+	// FailureThresholdViolations lists the verbs which exceeded their
+	// expected failure rate.
+	FailureThresholdViolations []FailureThresholdViolation `json:"failureThresholdViolations,omitempty"`
+	// MixByPhase represents the number of requests picked for each request
+	// in each phase if the mix changes over time.
+	MixByPhase []map[string]int `json:"mixByPhase,omitempty"`
+	// HaltReason is why the run was halted by stop condition. It's empty
+	// if the run completed.
+	HaltReason string `json:"haltReason,omitempty"`
+	// ObjectLeaks reports objects created by post-delete requests which
+	// are neither deleted nor tracked at the end of run.
+	ObjectLeaks []ObjectLeak `json:"objectLeaks,omitempty"`
+	// TotalInjectedDelay is the total artificial network delay injected
+	// into requests to simulate high-RTT clients. It's excluded from
+	// latencies.
+	TotalInjectedDelay string `json:"totalInjectedDelay,omitempty"`
+	// TotalAttempts is the total number of HTTP attempts, including
+	// retries. Attempts of requests counted in AuthFailures are excluded.
+	TotalAttempts int64 `json:"totalAttempts,omitempty"`
+	// AmplificationFactor is the ratio of TotalAttempts to logical
+	// requests. The value much greater than 1 means retries generate
+	// more load than the nominal rate.
+	AmplificationFactor float64 `json:"amplificationFactor,omitempty"`
+	// AuthFailures is the number of requests which failed with 401 or 403
+	// and triggered client rebuild instead of being counted as failures.
+	AuthFailures int64 `json:"authFailures,omitempty"`
+	// ClientRebuilds is the number of times the clients were rebuilt
+	// after auth failures.
+	ClientRebuilds int64 `json:"clientRebuilds,omitempty"`
+	// ReportsByTarget represents the summary of requests sent to each
+	// target if the load is distributed across multiple clusters.
+	ReportsByTarget map[string]TargetMetricReport `json:"reportsByTarget,omitempty"`
+}
+
+// TargetMetricReport is the summary of requests sent to one target.
+type TargetMetricReport struct {
+	// Total represents total number of requests.
+	Total int `json:"total"`
+	// FailuresByMethod represents total number of failed requests for each verb.
+	FailuresByMethod map[string]int `json:"failuresByMethod,omitempty"`
+	// PercentileLatencies represents the latency distribution in seconds.
+	PercentileLatencies [][2]float64 `json:"percentileLatencies,omitempty"`
+	// Latencies stores all the observed latencies.
+	Latencies []float64 `json:"latencies,omitempty"`
+	// LatencyHistogram stores the latency histogram if the runner uses
+	// histogram instead of raw latencies.
+	LatencyHistogram *LatencyHistogram `json:"latencyHistogram,omitempty"`
+}
+
+// ObjectLeak is the summary of objects created by post-delete requests for
+// one resource.
+type ObjectLeak struct {
+	// Resource is the URI of resource collection.
+	Resource string `json:"resource"`
+	// Preexisting is the number of objects created by previous runs,
+	// which is listed at the start of run.
+	Preexisting int `json:"preexisting"`
+	// Remaining is the number of objects created by this run, which is
+	// listed at the end of run.
+	Remaining int `json:"remaining"`
+	// Expected is the number of objects which are created by this run
+	// and not deleted by design.
+	Expected int `json:"expected"`
+	// Leaked is the number of remaining objects which are unexpected.
+	Leaked int `json:"leaked"`
+	// CleanedUp is the number of leaked objects deleted after run.
+	CleanedUp int `json:"cleanedUp,omitempty"`
+}
+
+// VersionComparisonReport compares the same load profile run against
+// multiple group/version targets.
+type VersionComparisonReport struct {
+	// GroupVersions lists the targets in the order of running.
+	GroupVersions []string `json:"groupVersions"`
+	// PercentileLatencies represents the latency distribution in seconds
+	// side by side. Each row is the percentile followed by the latency of
+	// each target in the order of GroupVersions.
+	PercentileLatencies [][]float64 `json:"percentileLatencies,omitempty"`
+	// Reports stores the report of each target in the order of
+	// GroupVersions.
+	Reports []RunnerMetricReport `json:"reports"`
 }
 
 // TODO(weifu): build brand new struct for RunnerGroupsReport to include more
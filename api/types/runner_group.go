@@ -24,6 +24,12 @@ type RunnerGroupSpec struct {
 	// NodeAffinity defines how to deploy runners into dedicated nodes
 	// which have specific labels.
 	NodeAffinity map[string][]string `json:"nodeAffinity,omitempty" yaml:"nodeAffinity,omitempty"`
+	// NodeSelector defines the labels which runners' nodes must have.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty" yaml:"nodeSelector,omitempty"`
+	// Tolerations allows runners to be deployed into tainted nodes, like
+	// the nodes dedicated for load generation. The runner group server
+	// uses them as well.
+	Tolerations []Toleration `json:"tolerations,omitempty" yaml:"tolerations,omitempty"`
 	// ServiceAccount is the name of the ServiceAccount to use to run runners.
 	ServiceAccount *string `json:"serviceAccount,omitempty" yaml:"serviceAccount,omitempty"`
 	// OwnerReference is to mark the runner group depending on this object.
@@ -32,6 +38,23 @@ type RunnerGroupSpec struct {
 	OwnerReference *string `json:"ownerReference,omitempty" yaml:"ownerReference,omitempty"`
 }
 
+// Toleration is the same as k8s.io/api/core/v1.Toleration, which allows
+// pods to be scheduled onto nodes with matching taints.
+type Toleration struct {
+	// Key is the taint key. Empty means matching all taint keys.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Operator is Exists or Equal. Defaults to Equal.
+	Operator string `json:"operator,omitempty" yaml:"operator,omitempty"`
+	// Value is the taint value which the toleration matches to.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+	// Effect is the taint effect to match. Empty means matching all
+	// taint effects.
+	Effect string `json:"effect,omitempty" yaml:"effect,omitempty"`
+	// TolerationSeconds is the period of time the toleration tolerates
+	// the taint with NoExecute effect.
+	TolerationSeconds *int64 `json:"tolerationSeconds,omitempty" yaml:"tolerationSeconds,omitempty"`
+}
+
 // RunnerGroupStatus represents current state of RunnerGroup.
 type RunnerGroupStatus struct {
 	// State is the current state of RunnerGroup.
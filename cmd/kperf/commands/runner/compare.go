@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/urfave/cli"
+	"k8s.io/klog/v2"
+)
+
+var compareCommand = cli.Command{
+	Name:  "compare",
+	Usage: "run the same load profile against multiple group/version targets sequentially and compare latencies",
+	Flags: append([]cli.Flag{
+		cli.StringSliceFlag{
+			Name:     "group-version",
+			Usage:    "The group/version target, like apps/v1 or v1 for core group. It overrides group and version of all the requests. Repeat it for each target (at least 2)",
+			Required: true,
+		},
+	}, runCommand.Flags...),
+	Action: func(cliCtx *cli.Context) error {
+		groupVersions := cliCtx.StringSlice("group-version")
+		if len(groupVersions) < 2 {
+			return fmt.Errorf("at least 2 group-version targets are required: %v", groupVersions)
+		}
+
+		rawDataFlagIncluded := cliCtx.Bool("raw-data")
+
+		ctx, stop := interruptibleContext()
+		defer stop()
+
+		output := types.VersionComparisonReport{
+			GroupVersions: groupVersions,
+		}
+		for _, gv := range groupVersions {
+			// NOTE: Report the targets which have run if interrupted.
+			if ctx.Err() != nil {
+				output.GroupVersions = output.GroupVersions[:len(output.Reports)]
+				break
+			}
+
+			// NOTE: Reload config for each target because kinds are
+			// resolved into the load profile in place.
+			profileCfg, err := loadConfig(cliCtx)
+			if err != nil {
+				return err
+			}
+
+			if err := overrideGroupVersion(&profileCfg.Spec, gv); err != nil {
+				return err
+			}
+
+			klog.V(2).InfoS("Running load profile", "groupVersion", gv)
+			stats, err := runProfile(ctx, cliCtx, profileCfg)
+			if err != nil {
+				return fmt.Errorf("failed to run load profile against %s: %w", gv, err)
+			}
+
+			output.Reports = append(output.Reports,
+				buildRunnerMetricReport(rawDataFlagIncluded, stats, &profileCfg.Spec))
+		}
+		output.PercentileLatencies = buildSideBySidePercentileLatencies(output.Reports)
+
+		f, err := createResultFile(cliCtx.String("result"))
+		if err != nil {
+			return err
+		}
+		if f != os.Stdout {
+			defer f.Close()
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(output); err != nil {
+			return fmt.Errorf("failed to encode json: %w", err)
+		}
+		return nil
+	},
+}
+
+// overrideGroupVersion updates group and version of all the requests which
+// target resource. The group is empty if gv doesn't have slash, which is the
+// core group.
+func overrideGroupVersion(spec *types.LoadProfileSpec, gv string) error {
+	group, version, found := strings.Cut(gv, "/")
+	if !found {
+		group, version = "", gv
+	}
+	if version == "" || strings.Contains(version, "/") {
+		return fmt.Errorf("invalid group-version %q", gv)
+	}
+
+	for _, r := range spec.Requests {
+		target := r.GroupVersionResource()
+		if target == nil {
+			continue
+		}
+		target.Group = group
+		target.Version = version
+	}
+	return nil
+}
+
+// buildSideBySidePercentileLatencies puts percentile latencies from reports
+// together. Each row is the percentile followed by the latency of each
+// report. The latency is zero if that report doesn't have that percentile.
+func buildSideBySidePercentileLatencies(reports []types.RunnerMetricReport) [][]float64 {
+	res := [][]float64{}
+	rowIdx := map[float64]int{}
+
+	for i, report := range reports {
+		for _, pl := range report.PercentileLatencies {
+			idx, ok := rowIdx[pl[0]]
+			if !ok {
+				idx = len(res)
+				rowIdx[pl[0]] = idx
+				res = append(res, make([]float64, len(reports)+1))
+				res[idx][0] = pl[0]
+			}
+			res[idx][i+1] = pl[1]
+		}
+	}
+	return res
+}
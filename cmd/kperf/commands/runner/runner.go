@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/Azure/kperf/api/types"
 	"github.com/Azure/kperf/cmd/kperf/commands/utils"
@@ -19,6 +21,7 @@ import (
 
 	"github.com/urfave/cli"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/rest"
 )
 
 // Command represents runner subcommand.
@@ -27,6 +30,8 @@ var Command = cli.Command{
 	Usage: "Setup benchmark to kube-apiserver from one endpoint",
 	Subcommands: []cli.Command{
 		runCommand,
+		compareCommand,
+		validateCommand,
 	},
 }
 
@@ -41,19 +46,23 @@ var runCommand = cli.Command{
 		},
 		cli.IntFlag{
 			Name:  "client",
-			Usage: "Total number of HTTP clients",
+			Usage: "Total number of concurrent HTTP clients sending requests. The i-th client uses the (i % conns)-th connection. It can override corresponding value defined by --config",
 			Value: 1,
 		},
 		cli.StringFlag{
-			Name:     "config",
-			Usage:    "Path to the configuration file",
+			Name:     "config, profile",
+			Usage:    "Path to the load profile file. The runner group spec file is accepted as well so that its load profile can be debugged locally",
 			Required: true,
 		},
 		cli.IntFlag{
 			Name:  "conns",
-			Usage: "Total number of connections. It can override corresponding value defined by --config",
+			Usage: "Total number of connections, which is the number of rest clients with individual transport. With HTTP/2, each one is a single TCP connection shared by multiplexing. It can override corresponding value defined by --config",
 			Value: 1,
 		},
+		cli.BoolFlag{
+			Name:  "conn-per-client",
+			Usage: "Give each client its own connection instead of sharing --conns connections. It can override corresponding value defined by --config",
+		},
 		cli.StringFlag{
 			Name:  "content-type",
 			Usage: fmt.Sprintf("Content type (%v or %v)", types.ContentTypeJSON, types.ContentTypeProtobuffer),
@@ -76,11 +85,40 @@ var runCommand = cli.Command{
 			Name:  "disable-http2",
 			Usage: "Disable HTTP2 protocol",
 		},
+		cli.BoolFlag{
+			Name:  "disable-keep-alives",
+			Usage: "Open new TCP and TLS connection for each request instead of reusing pooled connections. It can override corresponding value defined by --config",
+		},
+		cli.BoolFlag{
+			Name:  "insecure-skip-tls-verify",
+			Usage: "Skip server certificate verification. The connection will be insecure",
+		},
+		cli.StringFlag{
+			Name:  "certificate-authority",
+			Usage: "Path to a CA bundle file which overrides the one in kubeconfig",
+		},
+		cli.BoolFlag{
+			Name:  "validate-response",
+			Usage: "Decode the response of GET and LIST requests and validate the object. It costs CPU. It can override corresponding value defined by --config",
+		},
+		cli.IntFlag{
+			Name:  "network-delay-ms",
+			Usage: "Artificial latency in milliseconds injected into each round trip to simulate high-RTT clients. It can override corresponding value defined by --config",
+		},
 		cli.IntFlag{
 			Name:  "max-retries",
 			Usage: "Retry request after receiving 429 http code (<=0 means no retry)",
 			Value: 0,
 		},
+		cli.IntFlag{
+			Name:  "max-failure-samples",
+			Usage: "Maximum number of recent failures kept in raw data (<=0 means no limit). It can override corresponding value defined by --config",
+			Value: 10000,
+		},
+		cli.StringFlag{
+			Name:  "debug-addr",
+			Usage: "Serve running stats in JSON format on http://ADDR/debug/vars, pprof on http://ADDR/debug/pprof and prometheus metrics on http://ADDR/metrics, like localhost:6060 (Empty means disabled)",
+		},
 		cli.StringFlag{
 			Name:  "result",
 			Usage: "Path to the file which stores results",
@@ -89,6 +127,15 @@ var runCommand = cli.Command{
 			Name:  "raw-data",
 			Usage: "show raw letencies data in result",
 		},
+		cli.StringFlag{
+			Name:  "latency-dump",
+			Usage: "Path to the file which stores latency distribution, bucket upper bound to count. It's in CSV format if the file ends with .csv, otherwise JSON",
+		},
+		cli.IntFlag{
+			Name:  "latency-reservoir-size",
+			Usage: "Maximum number of latencies randomly sampled for --latency-dump (<=0 means all the latencies)",
+			Value: 0,
+		},
 		cli.IntFlag{
 			Name:  "duration",
 			Usage: "Duration of the benchmark in seconds. It will be ignored if --total is set.",
@@ -96,60 +143,191 @@ var runCommand = cli.Command{
 		},
 	},
 	Action: func(cliCtx *cli.Context) error {
-		kubeCfgPath := cliCtx.String("kubeconfig")
-
 		profileCfg, err := loadConfig(cliCtx)
 		if err != nil {
 			return err
 		}
 
-		clientNum := profileCfg.Spec.Conns
-		restClis, err := request.NewClients(kubeCfgPath,
-			clientNum,
-			request.WithClientUserAgentOpt(cliCtx.String("user-agent")),
-			request.WithClientQPSOpt(profileCfg.Spec.Rate),
-			request.WithClientContentTypeOpt(profileCfg.Spec.ContentType),
-			request.WithClientDisableHTTP2Opt(profileCfg.Spec.DisableHTTP2),
-		)
+		ctx, stop := interruptibleContext()
+		defer stop()
+
+		stats, err := runProfile(ctx, cliCtx, profileCfg)
 		if err != nil {
 			return err
 		}
 
-		stats, err := request.Schedule(context.TODO(), &profileCfg.Spec, restClis)
+		f, err := createResultFile(cliCtx.String("result"))
 		if err != nil {
 			return err
 		}
-
-		var f *os.File = os.Stdout
-		outputFilePath := cliCtx.String("result")
-		if outputFilePath != "" {
-			outputFileDir := filepath.Dir(outputFilePath)
-
-			_, err = os.Stat(outputFileDir)
-			if err != nil && os.IsNotExist(err) {
-				err = os.MkdirAll(outputFileDir, 0750)
-			}
-			if err != nil {
-				return fmt.Errorf("failed to ensure output's dir %s: %w", outputFileDir, err)
-			}
-
-			f, err = os.Create(outputFilePath)
-			if err != nil {
-				return err
-			}
+		if f != os.Stdout {
 			defer f.Close()
 		}
 
 		rawDataFlagIncluded := cliCtx.Bool("raw-data")
-		err = printResponseStats(f, rawDataFlagIncluded, stats)
+		err = printResponseStats(f, rawDataFlagIncluded, stats, &profileCfg.Spec)
 		if err != nil {
 			return fmt.Errorf("error while printing response stats: %w", err)
 		}
 
+		if dumpPath := cliCtx.String("latency-dump"); dumpPath != "" {
+			if err := dumpLatencyDistribution(dumpPath, stats); err != nil {
+				return fmt.Errorf("error while dumping latency distribution: %w", err)
+			}
+		}
 		return nil
 	},
 }
 
+// dumpLatencyDistribution writes latency distribution into the file. The
+// format is CSV if the file ends with .csv, otherwise JSON.
+func dumpLatencyDistribution(path string, stats *request.Result) error {
+	format := metrics.DistributionFormatJSON
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		format = metrics.DistributionFormatCSV
+	}
+
+	f, err := createResultFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return stats.ExportLatencyDistribution(f, format)
+}
+
+// runProfile resolves kinds in load profile and schedules requests. If ctx
+// is canceled, like interrupted by signal, it returns partial results of
+// the completed requests.
+func runProfile(ctx context.Context, cliCtx *cli.Context, profileCfg *types.LoadProfile) (*request.Result, error) {
+	kubeCfgPath := cliCtx.String("kubeconfig")
+
+	tlsOpts := []request.ClientCfgOpt{
+		request.WithClientInsecureSkipTLSVerifyOpt(cliCtx.Bool("insecure-skip-tls-verify")),
+		request.WithClientCAFileOpt(cliCtx.String("certificate-authority")),
+	}
+
+	err := request.ResolveKinds(kubeCfgPath, &profileCfg.Spec, tlsOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	clientNum := profileCfg.Spec.Conns
+	if profileCfg.Spec.ConnPerClient {
+		clientNum = profileCfg.Spec.Client
+	}
+	clientOpts := append([]request.ClientCfgOpt{
+		request.WithClientUserAgentOpt(cliCtx.String("user-agent")),
+		request.WithClientQPSOpt(profileCfg.Spec.Rate),
+		request.WithClientContentTypeOpt(profileCfg.Spec.ContentType),
+		request.WithClientDisableHTTP2Opt(profileCfg.Spec.DisableHTTP2),
+		request.WithClientDisableKeepAlivesOpt(profileCfg.Spec.DisableKeepAlives),
+		request.WithClientNetworkDelayOpt(time.Duration(profileCfg.Spec.NetworkDelayMs) * time.Millisecond),
+		request.WithClientAcceptEncodingOpt(profileCfg.Spec.AcceptEncoding),
+		request.WithClientInstanceHeaderOpt(profileCfg.Spec.InstanceHeader),
+	}, tlsOpts...)
+
+	restClis, err := request.NewClients(kubeCfgPath, clientNum, clientOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	newTargetClients := make(map[string]func() ([]rest.Interface, error), len(profileCfg.Spec.Targets))
+	targetClis := make(map[string][]rest.Interface, len(profileCfg.Spec.Targets))
+	for _, t := range profileCfg.Spec.Targets {
+		targetKubeCfgPath := t.Kubeconfig
+		if targetKubeCfgPath == "" {
+			targetKubeCfgPath = kubeCfgPath
+		}
+
+		targetClientOpts := append(append([]request.ClientCfgOpt{}, clientOpts...),
+			request.WithClientContextOpt(t.Context))
+		newTargetClients[t.Name] = func() ([]rest.Interface, error) {
+			return request.NewClients(targetKubeCfgPath, clientNum, targetClientOpts...)
+		}
+
+		clis, err := newTargetClients[t.Name]()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create clients for target %s: %w", t.Name, err)
+		}
+		targetClis[t.Name] = clis
+	}
+
+	stats, err := request.Schedule(ctx, &profileCfg.Spec, restClis,
+		request.WithScheduleDebugAddrOpt(cliCtx.String("debug-addr")),
+		request.WithScheduleTargetClientsOpt(targetClis),
+		request.WithScheduleLatencyReservoirOpt(cliCtx.Int("latency-reservoir-size")),
+		// NOTE: Rebuild clients from kubeconfig so that the rotated
+		// token is used.
+		request.WithScheduleClientRebuilderOpt(func() ([]rest.Interface, error) {
+			return request.NewClients(kubeCfgPath, clientNum, clientOpts...)
+		}),
+		request.WithScheduleTargetClientRebuilderOpt(func(target string) ([]rest.Interface, error) {
+			return newTargetClients[target]()
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx.Err() != nil && stats.HaltReason == "" {
+		stats.HaltReason = interruptedHaltReason
+	}
+	return stats, nil
+}
+
+// buildTargetMetricReport summarizes the requests sent to one target.
+func buildTargetMetricReport(rawDataFlagIncluded bool, stats types.ResponseStats, spec *types.LoadProfileSpec) types.TargetMetricReport {
+	total := 0
+	for _, n := range stats.TotalByMethod {
+		total += n
+	}
+
+	report := types.TargetMetricReport{
+		Total:            total,
+		FailuresByMethod: stats.FailuresByMethod,
+	}
+
+	if len(stats.LatencyHistogramsByMethod) > 0 {
+		all := types.LatencyHistogram{}
+		for _, h := range stats.LatencyHistogramsByMethod {
+			_ = metrics.MergeLatencyHistogram(&all, h)
+		}
+		report.PercentileLatencies = metrics.BuildPercentileLatenciesFromHistogram(all, spec.Percentiles)
+		report.LatencyHistogram = &all
+		return report
+	}
+
+	latencies := []float64{}
+	for _, l := range stats.LatenciesByMethod {
+		latencies = append(latencies, l...)
+	}
+	report.PercentileLatencies = metrics.BuildPercentileLatenciesWithObjectives(latencies, spec.Percentiles)
+	if rawDataFlagIncluded {
+		report.Latencies = latencies
+	}
+	return report
+}
+
+// createResultFile creates the file which stores results. It returns
+// stdout if outputFilePath is empty.
+func createResultFile(outputFilePath string) (*os.File, error) {
+	if outputFilePath == "" {
+		return os.Stdout, nil
+	}
+
+	outputFileDir := filepath.Dir(outputFilePath)
+
+	_, err := os.Stat(outputFileDir)
+	if err != nil && os.IsNotExist(err) {
+		err = os.MkdirAll(outputFileDir, 0750)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to ensure output's dir %s: %w", outputFileDir, err)
+	}
+	return os.Create(outputFilePath)
+}
+
 // loadConfig loads and validates the config.
 func loadConfig(cliCtx *cli.Context) (*types.LoadProfile, error) {
 	var profileCfg types.LoadProfile
@@ -161,8 +339,14 @@ func loadConfig(cliCtx *cli.Context) (*types.LoadProfile, error) {
 		return nil, fmt.Errorf("failed to read file %s: %w", cfgPath, err)
 	}
 
-	if err := yaml.Unmarshal(cfgInRaw, &profileCfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal %s from yaml format: %w", cfgPath, err)
+	if err := yaml.UnmarshalStrict(cfgInRaw, &profileCfg); err != nil {
+		// NOTE: Accept runner group spec as well so that the load
+		// profile can be debugged locally before scaling it out.
+		var rgSpec types.RunnerGroupSpec
+		if rerr := yaml.UnmarshalStrict(cfgInRaw, &rgSpec); rerr != nil || rgSpec.Profile == nil {
+			return nil, fmt.Errorf("failed to unmarshal %s from yaml format: %w", cfgPath, err)
+		}
+		profileCfg = *rgSpec.Profile
 	}
 
 	// override value by flags
@@ -175,6 +359,9 @@ func loadConfig(cliCtx *cli.Context) (*types.LoadProfile, error) {
 	if v := "client"; cliCtx.IsSet(v) || profileCfg.Spec.Client == 0 {
 		profileCfg.Spec.Client = cliCtx.Int(v)
 	}
+	if v := "conn-per-client"; cliCtx.IsSet(v) {
+		profileCfg.Spec.ConnPerClient = cliCtx.Bool(v)
+	}
 	if v := "total"; cliCtx.IsSet(v) {
 		profileCfg.Spec.Total = cliCtx.Int(v)
 	}
@@ -195,9 +382,21 @@ func loadConfig(cliCtx *cli.Context) (*types.LoadProfile, error) {
 	if v := "disable-http2"; cliCtx.IsSet(v) {
 		profileCfg.Spec.DisableHTTP2 = cliCtx.Bool(v)
 	}
+	if v := "disable-keep-alives"; cliCtx.IsSet(v) {
+		profileCfg.Spec.DisableKeepAlives = cliCtx.Bool(v)
+	}
+	if v := "validate-response"; cliCtx.IsSet(v) {
+		profileCfg.Spec.ValidateResponse = cliCtx.Bool(v)
+	}
+	if v := "network-delay-ms"; cliCtx.IsSet(v) {
+		profileCfg.Spec.NetworkDelayMs = cliCtx.Int(v)
+	}
 	if v := "max-retries"; cliCtx.IsSet(v) {
 		profileCfg.Spec.MaxRetries = cliCtx.Int(v)
 	}
+	if v := "max-failure-samples"; cliCtx.IsSet(v) || profileCfg.Spec.MaxFailureSamples == 0 {
+		profileCfg.Spec.MaxFailureSamples = cliCtx.Int(v)
+	}
 
 	if err := profileCfg.Validate(); err != nil {
 		return nil, err
@@ -206,41 +405,134 @@ func loadConfig(cliCtx *cli.Context) (*types.LoadProfile, error) {
 }
 
 // printResponseStats prints types.RunnerMetricReport into underlying file.
-func printResponseStats(f *os.File, rawDataFlagIncluded bool, stats *request.Result) error {
+func printResponseStats(f *os.File, rawDataFlagIncluded bool, stats *request.Result, spec *types.LoadProfileSpec) error {
+	output := buildRunnerMetricReport(rawDataFlagIncluded, stats, spec)
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+
+	err := encoder.Encode(output)
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+// buildRunnerMetricReport converts request.Result into types.RunnerMetricReport.
+func buildRunnerMetricReport(rawDataFlagIncluded bool, stats *request.Result, spec *types.LoadProfileSpec) types.RunnerMetricReport {
 	output := types.RunnerMetricReport{
 		Total:              stats.Total,
-		ErrorStats:         metrics.BuildErrorStatsGroupByType(stats.Errors),
+		ErrorStats:         stats.ErrorStats,
+		ErrorClasses:       stats.ErrorClasses,
 		Duration:           stats.Duration.String(),
+		ActualQPS:          stats.ActualQPS,
+		ErrorRate:          stats.ErrorRate,
 		TotalReceivedBytes: stats.TotalReceivedBytes,
+		TotalWireBytes:     stats.TotalWireBytes,
+		TotalSentBytes:     stats.TotalSentBytes,
+		TotalByMethod:      stats.TotalByMethod,
+		FailuresByMethod:   stats.FailuresByMethod,
 
-		PercentileLatenciesByURL: map[string][][2]float64{},
+		FailuresByStatusCode:  stats.FailuresByStatusCode,
+		ReceivedBytesByMethod: stats.ReceivedBytesByMethod,
+		MixByPhase:            stats.MixByPhase,
+		HaltReason:            stats.HaltReason,
+		ObjectLeaks:           stats.ObjectLeaks,
+		TotalAttempts:         stats.Attempts,
+		AuthFailures:          stats.AuthFailures,
+		ClientRebuilds:        stats.ClientRebuilds,
+		AmplificationFactor: metrics.BuildAmplificationFactor(
+			stats.Attempts, stats.TotalByMethod),
+		FailureThresholdViolations: metrics.BuildFailureThresholdViolations(
+			stats.TotalByMethod, stats.FailuresByMethod, spec.MaxFailureRateByVerb),
+
+		PercentileLatenciesByURL:    map[string][][2]float64{},
+		PercentileLatenciesByMethod: map[string][][2]float64{},
 	}
 
-	total := 0
-	for _, latencies := range stats.LatenciesByURL {
-		total += len(latencies)
+	if stats.WarmupDuration > 0 {
+		output.WarmupDuration = stats.WarmupDuration.String()
 	}
-	latencies := make([]float64, 0, total)
-	for _, l := range stats.LatenciesByURL {
-		latencies = append(latencies, l...)
+
+	if stats.InjectedDelay > 0 {
+		output.TotalInjectedDelay = stats.InjectedDelay.String()
 	}
-	output.PercentileLatencies = metrics.BuildPercentileLatencies(latencies)
 
-	for u, l := range stats.LatenciesByURL {
-		output.PercentileLatenciesByURL[u] = metrics.BuildPercentileLatencies(l)
+	if ws := stats.WatchStats; ws != nil {
+		output.WatchEventsByType = ws.EventsByType
+		output.PartialWatches = ws.PartialWatches
+		output.WatchReconnects = ws.Reconnects
+		output.PercentileWatchReconnectLatencies = metrics.BuildPercentileLatenciesWithObjectives(
+			ws.ReconnectLatencies, spec.Percentiles)
+		output.PercentileTimesToFirstWatchEvent = metrics.BuildPercentileLatenciesWithObjectives(
+			ws.TimesToFirstEvent, spec.Percentiles)
 	}
 
-	if rawDataFlagIncluded {
-		output.LatenciesByURL = stats.LatenciesByURL
-		output.Errors = stats.Errors
+	if len(stats.TargetStats) > 0 {
+		output.ReportsByTarget = make(map[string]types.TargetMetricReport, len(stats.TargetStats))
+		for name, ts := range stats.TargetStats {
+			output.ReportsByTarget[name] = buildTargetMetricReport(rawDataFlagIncluded, ts, spec)
+		}
 	}
 
-	encoder := json.NewEncoder(f)
-	encoder.SetIndent("", "  ")
+	if len(stats.TimesToFirstByte) > 0 {
+		output.PercentileTimesToFirstByte = metrics.BuildPercentileLatenciesWithObjectives(
+			stats.TimesToFirstByte, spec.Percentiles)
+	}
 
-	err := encoder.Encode(output)
-	if err != nil {
-		return fmt.Errorf("failed to encode json: %w", err)
+	if len(stats.LatenciesByInstance) > 0 {
+		output.PercentileLatenciesByInstance = make(map[string][][2]float64, len(stats.LatenciesByInstance))
+		for instance, l := range stats.LatenciesByInstance {
+			output.PercentileLatenciesByInstance[instance] = metrics.BuildPercentileLatenciesWithObjectives(l, spec.Percentiles)
+		}
 	}
-	return nil
+
+	if len(stats.LatencyHistogramsByURL) > 0 {
+		// NOTE: Histograms are always reported so that they can be
+		// merged across runners.
+		output.LatencyHistogramsByURL = stats.LatencyHistogramsByURL
+
+		all := types.LatencyHistogram{}
+		for u, h := range stats.LatencyHistogramsByURL {
+			// All the histograms in one run share the same buckets.
+			_ = metrics.MergeLatencyHistogram(&all, h)
+			output.PercentileLatenciesByURL[u] = metrics.BuildPercentileLatenciesFromHistogram(h, spec.Percentiles)
+		}
+		output.PercentileLatencies = metrics.BuildPercentileLatenciesFromHistogram(all, spec.Percentiles)
+
+		output.LatencyHistogramsByMethod = stats.LatencyHistogramsByMethod
+		for method, h := range stats.LatencyHistogramsByMethod {
+			output.PercentileLatenciesByMethod[method] = metrics.BuildPercentileLatenciesFromHistogram(h, spec.Percentiles)
+		}
+	} else {
+		total := 0
+		for _, latencies := range stats.LatenciesByURL {
+			total += len(latencies)
+		}
+		latencies := make([]float64, 0, total)
+		for _, l := range stats.LatenciesByURL {
+			latencies = append(latencies, l...)
+		}
+		output.PercentileLatencies = metrics.BuildPercentileLatenciesWithObjectives(latencies, spec.Percentiles)
+
+		for u, l := range stats.LatenciesByURL {
+			output.PercentileLatenciesByURL[u] = metrics.BuildPercentileLatenciesWithObjectives(l, spec.Percentiles)
+		}
+
+		for method, l := range stats.LatenciesByMethod {
+			output.PercentileLatenciesByMethod[method] = metrics.BuildPercentileLatenciesWithObjectives(l, spec.Percentiles)
+		}
+	}
+
+	if rawDataFlagIncluded {
+		output.LatenciesByURL = stats.LatenciesByURL
+		output.LatenciesByMethod = stats.LatenciesByMethod
+		output.TimesToFirstByte = stats.TimesToFirstByte
+		output.LatenciesByInstance = stats.LatenciesByInstance
+		if stats.WatchStats != nil {
+			output.WatchReconnectLatencies = stats.WatchStats.ReconnectLatencies
+		}
+		output.Errors = stats.Errors
+	}
+	return output
 }
@@ -0,0 +1,64 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package runner
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	// NOTE: The selector of list request is named seletor.
+	in := `
+version: 1
+spec:
+  rate: 100
+  total: 10000
+  conns: 2
+  client: 1
+  contentType: json
+  requests:
+  - staleList:
+      version: v1
+      resource: pods
+      seletor: app=x1
+    shares: 10
+  - watchList:
+      version: v1
+      resource: pods
+      seletor: app=x2
+    shares: 10
+`
+
+	_, err := loadConfig(newLoadConfigTestContext(t, in))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 18: field seletor not found in type types.RequestWatchList")
+
+	in = strings.Replace(in, "seletor: app=x2", "selector: app=x2", 1)
+	profile, err := loadConfig(newLoadConfigTestContext(t, in))
+	require.NoError(t, err)
+	assert.Equal(t, "app=x1", profile.Spec.Requests[0].StaleList.Selector)
+	assert.Equal(t, "app=x2", profile.Spec.Requests[1].WatchList.Selector)
+}
+
+// newLoadConfigTestContext returns cli.Context of run command whose config
+// flag points to a file with content.
+func newLoadConfigTestContext(t *testing.T, content string) *cli.Context {
+	cfgPath := filepath.Join(t.TempDir(), "profile.yaml")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(content), 0600))
+
+	set := flag.NewFlagSet(runCommand.Name, flag.ContinueOnError)
+	for _, f := range runCommand.Flags {
+		f.Apply(set)
+	}
+	require.NoError(t, set.Parse([]string{"--config", cfgPath}))
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package runner
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+// interruptedHaltReason is reported if the run is interrupted by signal.
+const interruptedHaltReason = "interrupted by signal"
+
+// interruptibleContext returns a context which is canceled on the first
+// SIGINT or SIGTERM, so that the run stops and still reports partial
+// results. The second signal exits immediately. The stop function must be
+// called to release the signal handler.
+func interruptibleContext() (_ context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigCh:
+		case <-done:
+			return
+		}
+		klog.Warning("Interrupted. Stopping the run and reporting partial results. Interrupt again to exit immediately")
+		cancel()
+
+		select {
+		case <-sigCh:
+			klog.Warning("Interrupted again. Exiting")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigCh)
+		close(done)
+		cancel()
+	}
+}
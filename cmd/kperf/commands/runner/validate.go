@@ -0,0 +1,49 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package runner
+
+import (
+	"fmt"
+
+	"github.com/Azure/kperf/request"
+
+	"github.com/urfave/cli"
+)
+
+var validateCommand = cli.Command{
+	Name:  "validate",
+	Usage: "print the requests resolved from the load profile without sending them",
+	Flags: append([]cli.Flag{
+		cli.IntFlag{
+			Name:  "count",
+			Usage: "The number of requests to resolve",
+			Value: 10,
+		},
+	}, runCommand.Flags...),
+	Action: func(cliCtx *cli.Context) error {
+		profileCfg, err := loadConfig(cliCtx)
+		if err != nil {
+			return err
+		}
+
+		// NOTE: It's no-op if there is no request specified by kind,
+		// so that the load profile can be validated offline.
+		err = request.ResolveKinds(cliCtx.String("kubeconfig"), &profileCfg.Spec,
+			request.WithClientInsecureSkipTLSVerifyOpt(cliCtx.Bool("insecure-skip-tls-verify")),
+			request.WithClientCAFileOpt(cliCtx.String("certificate-authority")),
+		)
+		if err != nil {
+			return err
+		}
+
+		urls, err := request.ResolveURLs(&profileCfg.Spec, cliCtx.Int("count"))
+		if err != nil {
+			return err
+		}
+		for _, u := range urls {
+			fmt.Println(u)
+		}
+		return nil
+	},
+}
@@ -46,6 +46,10 @@ var runCommand = cli.Command{
 			Name:  "affinity",
 			Usage: "Deploy server to the node with a specific labels (FORMAT: KEY=VALUE[,VALUE])",
 		},
+		cli.StringSliceFlag{
+			Name:  "toleration",
+			Usage: "Deploy server to the node with a specific taint (FORMAT: KEY[=VALUE]:EFFECT). The runners' tolerations in runner group spec are applied to server as well",
+		},
 		cli.IntFlag{
 			Name:  "runner-verbosity",
 			Usage: "The verbosity level of runners",
@@ -63,6 +67,11 @@ var runCommand = cli.Command{
 			return fmt.Errorf("failed to parse affinity: %w", err)
 		}
 
+		tolerations, err := utils.ParseTolerations(cliCtx.StringSlice("toleration"))
+		if err != nil {
+			return fmt.Errorf("failed to parse toleration: %w", err)
+		}
+
 		priorityLevel, matchingPrecedence, err := parseFlowControl(cliCtx.String("runner-flowcontrol"))
 		if err != nil {
 			return fmt.Errorf("failed to parse runner-flowcontrol: %w", err)
@@ -83,6 +92,7 @@ var runCommand = cli.Command{
 			specs[0],
 			cliCtx.Int("runner-verbosity"),
 			runner.WithRunCmdServerNodeSelectorsOpt(affinityLabels),
+			runner.WithRunCmdServerTolerationsOpt(tolerations),
 			runner.WithRunCmdRunnerGroupFlowControl(priorityLevel, matchingPrecedence),
 		)
 	},
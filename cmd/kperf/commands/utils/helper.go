@@ -10,6 +10,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/Azure/kperf/api/types"
+
+	corev1 "k8s.io/api/core/v1"
 	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,6 +59,71 @@ func KeyValueMap(strs []string) (map[string]string, error) {
 	return res, nil
 }
 
+// ParseTolerations converts KEY[=VALUE]:EFFECT into tolerations, like
+// kubectl taint. The operator is Exists if there is no value. The empty
+// effect means matching all effects.
+func ParseTolerations(strs []string) ([]types.Toleration, error) {
+	res := make([]types.Toleration, 0, len(strs))
+	for _, str := range strs {
+		keyValue, effect, ok := strings.Cut(str, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected KEY[=VALUE]:EFFECT format, but got %s", str)
+		}
+
+		switch corev1.TaintEffect(effect) {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, fmt.Errorf("invalid taint effect %s in %s", effect, str)
+		}
+
+		key, value, hasValue := strings.Cut(keyValue, "=")
+		if key == "" {
+			return nil, fmt.Errorf("expected non-empty key in %s", str)
+		}
+
+		t := types.Toleration{
+			Key:      key,
+			Operator: string(corev1.TolerationOpExists),
+			Effect:   effect,
+		}
+		if hasValue {
+			t.Operator = string(corev1.TolerationOpEqual)
+			t.Value = value
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// ParseTaints converts KEY[=VALUE]:EFFECT into taints, like kubectl taint.
+func ParseTaints(strs []string) ([]corev1.Taint, error) {
+	res := make([]corev1.Taint, 0, len(strs))
+	for _, str := range strs {
+		keyValue, effect, ok := strings.Cut(str, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected KEY[=VALUE]:EFFECT format, but got %s", str)
+		}
+
+		switch corev1.TaintEffect(effect) {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return nil, fmt.Errorf("invalid taint effect %s in %s", effect, str)
+		}
+
+		key, value, _ := strings.Cut(keyValue, "=")
+		if key == "" {
+			return nil, fmt.Errorf("expected non-empty key in %s", str)
+		}
+
+		res = append(res, corev1.Taint{
+			Key:    key,
+			Value:  value,
+			Effect: corev1.TaintEffect(effect),
+		})
+	}
+	return res, nil
+}
+
 // inCluster is to check if current process is in pod.
 func inCluster() bool {
 	f, err := os.Stat("/var/run/secrets/kubernetes.io/serviceaccount/token")
@@ -5,14 +5,15 @@ package virtualcluster
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/Azure/kperf/cmd/kperf/commands/utils"
 	"github.com/Azure/kperf/virtualcluster"
-	"helm.sh/helm/v3/pkg/release"
 
 	"github.com/urfave/cli"
 	"k8s.io/klog/v2"
@@ -27,12 +28,21 @@ var nodepoolCommand = cli.Command{
 			Usage: "Path to the kubeconfig file",
 			Value: utils.DefaultKubeConfigPath,
 		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "Output format (table or json)",
+			Value: "table",
+		},
 	},
 	Subcommands: []cli.Command{
 		nodepoolAddCommand,
 		nodepoolBatchAddCommand,
 		nodepoolDelCommand,
+		nodepoolScaleCommand,
+		nodepoolUpdateCommand,
 		nodepoolListCommand,
+		nodepoolCordonCommand,
+		nodepoolDrainCommand,
 	},
 }
 
@@ -49,15 +59,15 @@ var nodepoolAddCommand = cli.Command{
 			Usage: "The number of virtual nodes",
 			Value: 10,
 		},
-		cli.IntFlag{
+		cli.StringFlag{
 			Name:  "cpu",
-			Usage: "The allocatable CPU resource per node",
-			Value: 8,
+			Usage: "The allocatable CPU resource per node, like 8 or 3500m (The integer means cores)",
+			Value: "8",
 		},
-		cli.IntFlag{
+		cli.StringFlag{
 			Name:  "memory",
-			Usage: "The allocatable Memory resource per node (GiB)",
-			Value: 16,
+			Usage: "The allocatable Memory resource per node, like 16 or 12.5Gi (The number without unit means GiB)",
+			Value: "16",
 		},
 		cli.IntFlag{
 			Name:  "max-pods",
@@ -72,11 +82,37 @@ var nodepoolAddCommand = cli.Command{
 			Name:  "node-labels",
 			Usage: "Additional labels to node (FORMAT: KEY=VALUE)",
 		},
+		cli.StringSliceFlag{
+			Name:  "taint",
+			Usage: "Additional taints to node (FORMAT: KEY[=VALUE]:EFFECT)",
+		},
+		cli.StringSliceFlag{
+			Name:  "extended-resource",
+			Usage: "Extended resource provided by node, like nvidia.com/gpu (FORMAT: NAME=QUANTITY)",
+		},
 		cli.StringFlag{
 			Name:   "shared-provider-id",
 			Usage:  "Force all the virtual nodes using one provider ID",
 			Hidden: true,
 		},
+		cli.DurationFlag{
+			Name:  "deploy-timeout",
+			Usage: "The timeout to wait for each helm release of nodepool to be ready",
+			Value: 30 * time.Minute,
+		},
+		cli.BoolTFlag{
+			Name:  "atomic",
+			Usage: "Clean up the partially failed releases. Use --atomic=false to keep them for inspection",
+		},
+		cli.BoolFlag{
+			Name:  "wait",
+			Usage: "Wait for all the virtual nodes to be ready",
+		},
+		cli.DurationFlag{
+			Name:  "wait-timeout",
+			Usage: "The timeout to wait for virtual nodes to be ready",
+			Value: 10 * time.Minute,
+		},
 	},
 	Action: func(cliCtx *cli.Context) error {
 		if cliCtx.NArg() != 1 {
@@ -104,22 +140,40 @@ var nodepoolAddCommand = cli.Command{
 			return fmt.Errorf("failed to parse node-labels: %w", err)
 		}
 
+		nodeTaints, err := utils.ParseTaints(cliCtx.StringSlice("taint"))
+		if err != nil {
+			return fmt.Errorf("failed to parse taint: %w", err)
+		}
+
+		extendedResources, err := utils.KeyValueMap(cliCtx.StringSlice("extended-resource"))
+		if err != nil {
+			return fmt.Errorf("failed to parse extended-resource: %w", err)
+		}
+
 		nodes := cliCtx.Int("nodes")
 		if nodes > maxNodesPerPool {
 			klog.Warningf("Creating a node pool with a large number of nodes may cause performance issues. Consider using batch-add command for large node pools.")
 		}
 
-		return virtualcluster.CreateNodepool(context.Background(),
+		err = virtualcluster.CreateNodepool(context.Background(),
 			kubeCfgPath,
 			nodepoolName,
-			virtualcluster.WithNodepoolCPUOpt(cliCtx.Int("cpu")),
-			virtualcluster.WithNodepoolMemoryOpt(cliCtx.Int("memory")),
+			virtualcluster.WithNodepoolCPUOpt(cliCtx.String("cpu")),
+			virtualcluster.WithNodepoolMemoryOpt(cliCtx.String("memory")),
 			virtualcluster.WithNodepoolCountOpt(nodes),
 			virtualcluster.WithNodepoolMaxPodsOpt(cliCtx.Int("max-pods")),
 			virtualcluster.WithNodepoolNodeControllerAffinity(affinityLabels),
 			virtualcluster.WithNodepoolLabelsOpt(nodeLabels),
+			virtualcluster.WithNodepoolTaints(nodeTaints),
+			virtualcluster.WithNodepoolExtendedResources(extendedResources),
 			virtualcluster.WithNodepoolSharedProviderID(cliCtx.String("shared-provider-id")),
+			virtualcluster.WithNodepoolDeployTimeout(cliCtx.Duration("deploy-timeout")),
+			virtualcluster.WithNodepoolAtomicOpt(cliCtx.BoolT("atomic")),
 		)
+		if err != nil || !cliCtx.Bool("wait") {
+			return err
+		}
+		return virtualcluster.WaitNodepoolReady(context.Background(), kubeCfgPath, nodepoolName, nodes, cliCtx.Duration("wait-timeout"))
 	},
 }
 
@@ -133,15 +187,15 @@ var nodepoolBatchAddCommand = cli.Command{
 			Usage: "The number of virtual nodes",
 			Value: 10,
 		},
-		cli.IntFlag{
+		cli.StringFlag{
 			Name:  "cpu",
-			Usage: "The allocatable CPU resource per node",
-			Value: 8,
+			Usage: "The allocatable CPU resource per node, like 8 or 3500m (The integer means cores)",
+			Value: "8",
 		},
-		cli.IntFlag{
+		cli.StringFlag{
 			Name:  "memory",
-			Usage: "The allocatable Memory resource per node (GiB)",
-			Value: 16,
+			Usage: "The allocatable Memory resource per node, like 16 or 12.5Gi (The number without unit means GiB)",
+			Value: "16",
 		},
 		cli.IntFlag{
 			Name:  "max-pods",
@@ -156,11 +210,28 @@ var nodepoolBatchAddCommand = cli.Command{
 			Name:  "node-labels",
 			Usage: "Additional labels to node (FORMAT: KEY=VALUE)",
 		},
+		cli.StringSliceFlag{
+			Name:  "taint",
+			Usage: "Additional taints to node (FORMAT: KEY[=VALUE]:EFFECT)",
+		},
+		cli.StringSliceFlag{
+			Name:  "extended-resource",
+			Usage: "Extended resource provided by node, like nvidia.com/gpu (FORMAT: NAME=QUANTITY)",
+		},
 		cli.StringFlag{
 			Name:   "shared-provider-id",
 			Usage:  "Force all the virtual nodes using one provider ID",
 			Hidden: true,
 		},
+		cli.DurationFlag{
+			Name:  "deploy-timeout",
+			Usage: "The timeout to wait for each helm release of nodepool to be ready",
+			Value: 30 * time.Minute,
+		},
+		cli.BoolTFlag{
+			Name:  "atomic",
+			Usage: "Clean up the partially failed releases. Use --atomic=false to keep them for inspection",
+		},
 		cli.IntFlag{
 			Name:  "batch-size",
 			Usage: "Maximum number of nodes to create in one batch, default is 300",
@@ -192,6 +263,16 @@ var nodepoolBatchAddCommand = cli.Command{
 			return fmt.Errorf("failed to parse node labels: %w", err)
 		}
 
+		nodeTaints, err := utils.ParseTaints(cliCtx.StringSlice("taint"))
+		if err != nil {
+			return fmt.Errorf("failed to parse taints: %w", err)
+		}
+
+		extendedResources, err := utils.KeyValueMap(cliCtx.StringSlice("extended-resource"))
+		if err != nil {
+			return fmt.Errorf("failed to parse extended resources: %w", err)
+		}
+
 		totalNodes := cliCtx.Int("nodes")
 		batchSize := cliCtx.Int("batch-size")
 		if batchSize <= 0 {
@@ -208,13 +289,17 @@ var nodepoolBatchAddCommand = cli.Command{
 			if err := virtualcluster.CreateNodepool(context.Background(),
 				kubeCfgPath,
 				batchNodepoolName,
-				virtualcluster.WithNodepoolCPUOpt(cliCtx.Int("cpu")),
-				virtualcluster.WithNodepoolMemoryOpt(cliCtx.Int("memory")),
+				virtualcluster.WithNodepoolCPUOpt(cliCtx.String("cpu")),
+				virtualcluster.WithNodepoolMemoryOpt(cliCtx.String("memory")),
 				virtualcluster.WithNodepoolCountOpt(currentBatchSize),
 				virtualcluster.WithNodepoolMaxPodsOpt(cliCtx.Int("max-pods")),
 				virtualcluster.WithNodepoolNodeControllerAffinity(affinityLabels),
 				virtualcluster.WithNodepoolLabelsOpt(nodeLabels),
+				virtualcluster.WithNodepoolTaints(nodeTaints),
+				virtualcluster.WithNodepoolExtendedResources(extendedResources),
 				virtualcluster.WithNodepoolSharedProviderID(cliCtx.String("shared-provider-id")),
+				virtualcluster.WithNodepoolDeployTimeout(cliCtx.Duration("deploy-timeout")),
+				virtualcluster.WithNodepoolAtomicOpt(cliCtx.BoolT("atomic")),
 			); err != nil {
 				return fmt.Errorf("failed to create nodepool batch %s: %w", batchNodepoolName, err)
 			}
@@ -245,33 +330,199 @@ var nodepoolDelCommand = cli.Command{
 	},
 }
 
+var nodepoolScaleCommand = cli.Command{
+	Name:      "scale",
+	ArgsUsage: "NAME",
+	Usage:     "Change the number of virtual nodes in a node pool in place",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:     "nodes",
+			Usage:    "The number of virtual nodes",
+			Required: true,
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one argument as nodepool name")
+		}
+		nodepoolName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(nodepoolName) == 0 {
+			return fmt.Errorf("required non-empty nodepool name")
+		}
+
+		nodes := cliCtx.Int("nodes")
+		if nodes <= 0 {
+			return fmt.Errorf("nodes requires > 0: %v", nodes)
+		}
+		if nodes > maxNodesPerPool {
+			klog.Warningf("Scaling a node pool to a large number of nodes may cause performance issues. Consider using batch-add command for large node pools.")
+		}
+
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+		return virtualcluster.ScaleNodepool(context.Background(), kubeCfgPath, nodepoolName, nodes)
+	},
+}
+
+var nodepoolUpdateCommand = cli.Command{
+	Name:      "update",
+	ArgsUsage: "NAME",
+	Usage:     "Update labels of virtual nodes in a node pool in place",
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "node-labels",
+			Usage: "Labels to add or change on node (FORMAT: KEY=VALUE)",
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one argument as nodepool name")
+		}
+		nodepoolName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(nodepoolName) == 0 {
+			return fmt.Errorf("required non-empty nodepool name")
+		}
+
+		nodeLabels, err := utils.KeyValueMap(cliCtx.StringSlice("node-labels"))
+		if err != nil {
+			return fmt.Errorf("failed to parse node-labels: %w", err)
+		}
+
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+		return virtualcluster.UpdateNodepoolLabels(context.Background(), kubeCfgPath, nodepoolName, nodeLabels)
+	},
+}
+
+var nodepoolCordonCommand = cli.Command{
+	Name:      "cordon",
+	ArgsUsage: "NAME",
+	Usage:     "Mark all the virtual nodes in a node pool unschedulable",
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one argument as nodepool name")
+		}
+		nodepoolName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(nodepoolName) == 0 {
+			return fmt.Errorf("required non-empty nodepool name")
+		}
+
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+		nodes, cordoned, err := virtualcluster.CordonNodepool(context.Background(), kubeCfgPath, nodepoolName)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Cordoned %d nodes (%d already unschedulable) in nodepool %s\n", cordoned, nodes-cordoned, nodepoolName)
+		return nil
+	},
+}
+
+var nodepoolDrainCommand = cli.Command{
+	Name:      "drain",
+	ArgsUsage: "NAME",
+	Usage:     "Cordon all the virtual nodes in a node pool and evict their pods",
+	Flags: []cli.Flag{
+		cli.Int64Flag{
+			Name:  "grace-period",
+			Usage: "Period of time in seconds given to each pod to terminate gracefully. If negative, the default value specified in the pod will be used",
+			Value: -1,
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one argument as nodepool name")
+		}
+		nodepoolName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(nodepoolName) == 0 {
+			return fmt.Errorf("required non-empty nodepool name")
+		}
+
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+		res, err := virtualcluster.DrainNodepool(context.Background(), kubeCfgPath, nodepoolName, cliCtx.Int64("grace-period"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Drained %d nodes (%d newly cordoned) in nodepool %s: evicted %d pods, skipped %d pods\n",
+			res.Nodes, res.CordonedNodes, nodepoolName, res.EvictedPods, res.SkippedPods)
+		return nil
+	},
+}
+
 var nodepoolListCommand = cli.Command{
 	Name:  "list",
 	Usage: "List virtual node pools",
 	Action: func(cliCtx *cli.Context) error {
+		output := cliCtx.GlobalString("output")
+		if output != "table" && output != "json" {
+			return fmt.Errorf("unsupported output format %s", output)
+		}
+
 		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
 		nodepools, err := virtualcluster.ListNodepools(context.Background(), kubeCfgPath)
 		if err != nil {
 			return err
 		}
-		return renderNodepoolList(nodepools)
 
+		items := newNodepoolListItems(nodepools)
+		if output == "json" {
+			return renderNodepoolListJSON(items)
+		}
+		return renderNodepoolList(items)
 	},
 }
 
-func renderNodepoolList(nodepools []*release.Release) error {
-	tw := tabwriter.NewWriter(os.Stdout, 1, 12, 3, ' ', 0)
+// nodepoolListItem is the summary of nodepool in nodepool list.
+type nodepoolListItem struct {
+	Name    string `json:"name"`
+	Nodes   int    `json:"nodes"`
+	Ready   int    `json:"ready"`
+	CPU     string `json:"cpu"`
+	Memory  string `json:"memory"`
+	MaxPods int    `json:"maxPods"`
+	Status  string `json:"status"`
+}
 
-	fmt.Fprintln(tw, "NAME\tNODES\tCPU\tMEMORY (GiB)\tMAX PODS\tSTATUS\t")
+func newNodepoolListItems(nodepools []*virtualcluster.Nodepool) []nodepoolListItem {
+	items := make([]nodepoolListItem, 0, len(nodepools))
 	for _, nodepool := range nodepools {
+		// NOTE: The unexpected value is listed as zero instead of
+		// failing the whole list.
+		nodes, _ := virtualcluster.NodepoolConfigInt(nodepool.Config, "replicas")
+		maxPods, _ := virtualcluster.NodepoolConfigInt(nodepool.Config, "maxPods")
+
+		items = append(items, nodepoolListItem{
+			Name:    nodepool.Name,
+			Nodes:   nodes,
+			Ready:   nodepool.ReadyNodes,
+			CPU:     fmt.Sprint(nodepool.Config["cpu"]),
+			Memory:  fmt.Sprint(nodepool.Config["memory"]),
+			MaxPods: maxPods,
+			Status:  nodepool.Info.Status.String(),
+		})
+	}
+	return items
+}
+
+func renderNodepoolListJSON(items []nodepoolListItem) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(items)
+}
+
+func renderNodepoolList(items []nodepoolListItem) error {
+	tw := tabwriter.NewWriter(os.Stdout, 1, 12, 3, ' ', 0)
+
+	fmt.Fprintln(tw, "NAME\tNODES\tCPU\tMEMORY\tMAX PODS\tSTATUS\t")
+	for _, item := range items {
 		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%s\t\n",
-			nodepool.Name,
-			// TODO(weifu): show the number of read nodes
-			fmt.Sprintf("? / %v", nodepool.Config["replicas"]),
-			nodepool.Config["cpu"],
-			nodepool.Config["memory"],
-			nodepool.Config["maxPods"],
-			nodepool.Info.Status,
+			item.Name,
+			fmt.Sprintf("%d / %d", item.Ready, item.Nodes),
+			item.CPU,
+			item.Memory,
+			item.MaxPods,
+			item.Status,
 		)
 	}
 	return tw.Flush()
@@ -56,6 +56,7 @@ var benchCiliumCustomResourceListCase = cli.Command{
 		},
 		commonFlags...,
 	),
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(ciliumCustomResourceListRun)(cliCtx)
 		return err
@@ -90,7 +91,7 @@ func ciliumCustomResourceListRun(cliCtx *cli.Context) (*internaltypes.BenchmarkR
 		return nil, fmt.Errorf("failed to load Cilium data: %w", err)
 	}
 
-	rgResult, err := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, err := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -105,10 +106,10 @@ func ciliumCustomResourceListRun(cliCtx *cli.Context) (*internaltypes.BenchmarkR
 		Description: fmt.Sprintf(`Deploy %d CiliumIdentities and %d CiliumEndpoints, then run stale list requests against them`, numCID, numCEP),
 		LoadSpec:    *rgSpec,
 		Result:      *rgResult,
-		Info: map[string]interface{}{
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
 			"numCiliumIdentities": numCID,
 			"numCiliumEndpoints":  numCEP,
-		},
+		}, rgReadyTime),
 	}, nil
 }
 
@@ -0,0 +1,411 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/contrib/utils"
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+var benchCompactionImpactCase = cli.Command{
+	Name: "compaction_impact",
+	Usage: `
+
+The test suite is to drive steady write load for a long time and detect etcd
+compaction and defrag events. It annotates the latency timeline with these
+events and reports the latency before, during and after them.
+
+NOTE: kube-apiserver compacts etcd every 5 minutes by default. The duration
+should cover several compaction intervals.
+	`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "workers",
+			Usage: "The number of workers updating configmaps",
+			Value: 10,
+		},
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Maximum updates per second for all workers (Zero means no limitation)",
+			Value: 100,
+		},
+		cli.DurationFlag{
+			Name:  "duration",
+			Usage: "Duration of the benchmark",
+			Value: 20 * time.Minute,
+		},
+		cli.DurationFlag{
+			Name:  "sample-interval",
+			Usage: "The interval to detect compaction and defrag events. It's also the step of latency timeline",
+			Value: 10 * time.Second,
+		},
+		cli.DurationFlag{
+			Name:  "window",
+			Usage: "The window before and after each event used to compare latency",
+			Value: 30 * time.Second,
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchCompactionImpactRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+var benchCompactionNamespace = "kperf-compaction-bench"
+
+const (
+	// etcdEventCompaction means that etcd compacted old revisions.
+	etcdEventCompaction = "compaction"
+	// etcdEventDefrag means that etcd database size shrank, which is
+	// usually caused by defragmentation.
+	etcdEventDefrag = "defrag"
+)
+
+// etcdEvent is an etcd maintenance event detected during the run.
+type etcdEvent struct {
+	Type string `json:"type"`
+	// NotBefore is the time of last sample which didn't see the event.
+	NotBefore time.Time `json:"notBefore"`
+	// DetectedAt is the time of sample which saw the event.
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// latencySample is the latency of one request.
+type latencySample struct {
+	at      time.Time
+	seconds float64
+}
+
+// timelineStep is the summary of latency in one sample interval.
+type timelineStep struct {
+	Start               time.Time    `json:"start"`
+	Total               int          `json:"total"`
+	PercentileLatencies [][2]float64 `json:"percentileLatencies,omitempty"`
+	Events              []string     `json:"events,omitempty"`
+}
+
+// benchCompactionImpactRun is for subcommand benchCompactionImpactCase.
+func benchCompactionImpactRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	workers := cliCtx.Int("workers")
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers requires > 0: %v", workers)
+	}
+	qps := cliCtx.Float64("rate")
+	if qps < 0 {
+		return nil, fmt.Errorf("rate requires >= 0: %v", qps)
+	}
+	duration := cliCtx.Duration("duration")
+	sampleInterval := cliCtx.Duration("sample-interval")
+	if sampleInterval <= 0 {
+		return nil, fmt.Errorf("sample-interval requires > 0: %v", sampleInterval)
+	}
+	window := cliCtx.Duration("window")
+	if window <= 0 {
+		return nil, fmt.Errorf("window requires > 0: %v", window)
+	}
+
+	clientset, err := newLocalDriverClientset(kubeCfgPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = prepareCompactionConfigmaps(ctx, clientset, workers)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := clientset.CoreV1().Namespaces().Delete(ctx, benchCompactionNamespace, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete namespace: %v", err))
+		}
+	}()
+
+	respMetric := metrics.NewResponseMetric()
+
+	var samplesMu sync.Mutex
+	samples := []latencySample{}
+	seqs := make([]int, workers)
+
+	start := time.Now()
+
+	// NOTE: The events are detected during the run and it stops when the
+	// duration elapses, just like workers.
+	detectCtx, detectCancel := context.WithTimeout(ctx, duration)
+	defer detectCancel()
+
+	eventsCh := make(chan []etcdEvent, 1)
+	go func() {
+		eventsCh <- detectEtcdEvents(detectCtx, clientset, kubeCfgPath, sampleInterval)
+	}()
+
+	totalDuration := runLocalDriverWorkers(ctx, workers, qps, duration, func(runCtx context.Context, idx int) {
+		name := compactionConfigmapName(idx)
+		url := fmt.Sprintf("/api/v1/namespaces/%s/configmaps/%s", benchCompactionNamespace, name)
+
+		// NOTE: Each worker only updates its own sequence.
+		seqs[idx]++
+		patch := fmt.Sprintf(`{"data":{"seq":"%d"}}`, seqs[idx])
+
+		reqStart := time.Now()
+		_, err := clientset.CoreV1().ConfigMaps(benchCompactionNamespace).
+			Patch(runCtx, name, apitypes.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		end := time.Now()
+		if err != nil {
+			if runCtx.Err() == nil {
+				respMetric.ObserveFailure(http.MethodPatch, url, end, end.Sub(reqStart).Seconds(), err)
+			}
+			return
+		}
+		respMetric.ObserveLatency(http.MethodPatch, url, end.Sub(reqStart).Seconds())
+
+		samplesMu.Lock()
+		samples = append(samples, latencySample{at: reqStart, seconds: end.Sub(reqStart).Seconds()})
+		samplesMu.Unlock()
+	})
+	detectCancel()
+	events := <-eventsCh
+
+	spec := types.LoadProfileSpec{
+		Rate:     qps,
+		Duration: int(duration.Seconds()),
+		Conns:    1,
+		Client:   workers,
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: %v configmaps in a namespace.
+Workload: %v workers PATCH their own configmap for %v and detect etcd compaction and defrag every %v.`,
+			workers, workers, duration, sampleInterval),
+
+		LoadSpec: newLocalDriverLoadSpec("compaction impact", spec),
+		Result:   buildLocalDriverReport(respMetric.Gather(), totalDuration, &spec),
+		Info: map[string]interface{}{
+			"etcdEvents":            events,
+			"latenciesByEventPhase": buildLatenciesByEventPhase(samples, events, window),
+			"latencyTimeline":       buildLatencyTimeline(start, sampleInterval, samples, events),
+		},
+	}, nil
+}
+
+// compactionConfigmapName returns the name of configmap for idx-th worker.
+func compactionConfigmapName(idx int) string {
+	return fmt.Sprintf("kperf-compaction-%d", idx)
+}
+
+// prepareCompactionConfigmaps creates the namespace and one configmap for
+// each worker.
+func prepareCompactionConfigmaps(ctx context.Context, clientset kubernetes.Interface, workers int) error {
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: benchCompactionNamespace},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", benchCompactionNamespace, err)
+	}
+
+	for i := 0; i < workers; i++ {
+		name := compactionConfigmapName(i)
+		_, err = clientset.CoreV1().ConfigMaps(benchCompactionNamespace).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+		}, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create configmap %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// detectEtcdEvents samples until ctx is done and returns the compaction
+// and defrag events.
+//
+// NOTE: kube-apiserver doesn't expose compaction in metrics. The resource
+// version is recorded in every sample. Since kube-apiserver compacts etcd to
+// the revision seen in its previous compaction interval, the recorded ones
+// aren't compacted at once. Each sample lists with the oldest recorded
+// resource version which hasn't been compacted. It fails with 410 Gone only
+// if there was a compaction since last sample. The defrag is detected by the
+// drop of etcd database size in kube-apiserver metrics.
+func detectEtcdEvents(ctx context.Context, clientset kubernetes.Interface, kubeCfgPath string, interval time.Duration) []etcdEvent {
+	warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
+	cli := clientset.CoreV1().ConfigMaps(benchCompactionNamespace)
+
+	currentRV := func() string {
+		list, err := cli.List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			if ctx.Err() == nil {
+				warnLogger.LogKV("msg", "failed to get current resource version", "error", err)
+			}
+			return ""
+		}
+		return list.ResourceVersion
+	}
+
+	compacted := func(rv string) bool {
+		_, err := cli.List(ctx, metav1.ListOptions{
+			Limit:                1,
+			ResourceVersion:      rv,
+			ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+		})
+		switch {
+		case err == nil:
+			return false
+		case apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+			return true
+		default:
+			if ctx.Err() == nil {
+				warnLogger.LogKV("msg", "failed to probe compaction", "error", err)
+			}
+			return false
+		}
+	}
+
+	// rvs are the resource versions recorded by samples which haven't
+	// been compacted, from oldest to newest.
+	rvs := []string{}
+	if rv := currentRV(); rv != "" {
+		rvs = append(rvs, rv)
+	}
+	lastDBSize, err := utils.FetchAPIServerStorageDBSize(ctx, kubeCfgPath)
+	if err != nil {
+		warnLogger.LogKV("msg", "failed to fetch etcd database size", "error", err)
+	}
+	lastSampleAt := time.Now()
+
+	events := []etcdEvent{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return events
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		if len(rvs) > 0 && compacted(rvs[0]) {
+			events = append(events, etcdEvent{Type: etcdEventCompaction, NotBefore: lastSampleAt, DetectedAt: now})
+
+			// NOTE: The compacted ones are always the oldest.
+			idx := sort.Search(len(rvs), func(i int) bool {
+				return i > 0 && !compacted(rvs[i])
+			})
+			rvs = rvs[idx:]
+		}
+		if rv := currentRV(); rv != "" {
+			rvs = append(rvs, rv)
+		}
+
+		dbSize, err := utils.FetchAPIServerStorageDBSize(ctx, kubeCfgPath)
+		switch {
+		case err != nil:
+			if ctx.Err() == nil {
+				warnLogger.LogKV("msg", "failed to fetch etcd database size", "error", err)
+			}
+		default:
+			if lastDBSize > 0 && dbSize < lastDBSize {
+				events = append(events, etcdEvent{Type: etcdEventDefrag, NotBefore: lastSampleAt, DetectedAt: now})
+			}
+			lastDBSize = dbSize
+		}
+		lastSampleAt = now
+	}
+}
+
+// buildLatenciesByEventPhase returns percentile latencies in three phases
+// for all the events. The during phase is from the last sample which didn't
+// see the event to window after it's detected. The before and after phases
+// are the windows around the during phase.
+func buildLatenciesByEventPhase(samples []latencySample, events []etcdEvent, window time.Duration) map[string]map[string][][2]float64 {
+	res := map[string]map[string][][2]float64{}
+
+	byType := map[string][]etcdEvent{}
+	for _, e := range events {
+		byType[e.Type] = append(byType[e.Type], e)
+	}
+
+	for typ, evs := range byType {
+		before, during, after := []float64{}, []float64{}, []float64{}
+		for _, e := range evs {
+			duringEnd := e.DetectedAt.Add(window)
+			for _, s := range samples {
+				switch {
+				case !s.at.Before(e.NotBefore.Add(-window)) && s.at.Before(e.NotBefore):
+					before = append(before, s.seconds)
+				case !s.at.Before(e.NotBefore) && s.at.Before(duringEnd):
+					during = append(during, s.seconds)
+				case !s.at.Before(duringEnd) && s.at.Before(duringEnd.Add(window)):
+					after = append(after, s.seconds)
+				}
+			}
+		}
+
+		res[typ] = map[string][][2]float64{
+			"before": metrics.BuildPercentileLatencies(before),
+			"during": metrics.BuildPercentileLatencies(during),
+			"after":  metrics.BuildPercentileLatencies(after),
+		}
+	}
+	return res
+}
+
+// buildLatencyTimeline groups latencies by step and annotates each step with
+// the events detected in it.
+func buildLatencyTimeline(start time.Time, step time.Duration, samples []latencySample, events []etcdEvent) []timelineStep {
+	latenciesByStep := map[int][]float64{}
+	lastStep := 0
+	for _, s := range samples {
+		idx := int(s.at.Sub(start) / step)
+		latenciesByStep[idx] = append(latenciesByStep[idx], s.seconds)
+		lastStep = max(lastStep, idx)
+	}
+
+	eventsByStep := map[int][]string{}
+	for _, e := range events {
+		idx := int(e.DetectedAt.Sub(start) / step)
+		eventsByStep[idx] = append(eventsByStep[idx], e.Type)
+		lastStep = max(lastStep, idx)
+	}
+
+	res := make([]timelineStep, 0, lastStep+1)
+	for idx := 0; idx <= lastStep; idx++ {
+		l := latenciesByStep[idx]
+		res = append(res, timelineStep{
+			Start:               start.Add(time.Duration(idx) * step),
+			Total:               len(l),
+			PercentileLatencies: metrics.BuildPercentileLatencies(l),
+			Events:              eventsByStep[idx],
+		})
+	}
+	return res
+}
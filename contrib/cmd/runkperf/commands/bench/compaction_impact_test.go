@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLatenciesByEventPhase(t *testing.T) {
+	start := time.Now()
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+
+	events := []etcdEvent{
+		{Type: etcdEventCompaction, NotBefore: at(60), DetectedAt: at(70)},
+		{Type: etcdEventCompaction, NotBefore: at(360), DetectedAt: at(370)},
+		{Type: etcdEventDefrag, NotBefore: at(200), DetectedAt: at(210)},
+	}
+	samples := []latencySample{
+		{at: at(10), seconds: 10},  // out of any window
+		{at: at(30), seconds: 1},   // before first compaction
+		{at: at(59), seconds: 1.5}, // before first compaction
+		{at: at(60), seconds: 2},   // during first compaction
+		{at: at(99), seconds: 2.5}, // during first compaction
+		{at: at(100), seconds: 3},  // after first compaction
+		{at: at(180), seconds: 4},  // before defrag
+		{at: at(230), seconds: 5},  // during defrag
+		{at: at(245), seconds: 6},  // after defrag
+		{at: at(340), seconds: 7},  // before second compaction
+		{at: at(380), seconds: 8},  // during second compaction
+		{at: at(420), seconds: 9},  // after second compaction
+		{at: at(430), seconds: 10}, // out of any window
+	}
+
+	res := buildLatenciesByEventPhase(samples, events, 30*time.Second)
+	assert.Equal(t, map[string]map[string][][2]float64{
+		etcdEventCompaction: {
+			"before": metrics.BuildPercentileLatencies([]float64{1, 1.5, 7}),
+			"during": metrics.BuildPercentileLatencies([]float64{2, 2.5, 8}),
+			"after":  metrics.BuildPercentileLatencies([]float64{3, 9}),
+		},
+		etcdEventDefrag: {
+			"before": metrics.BuildPercentileLatencies([]float64{4}),
+			"during": metrics.BuildPercentileLatencies([]float64{5}),
+			"after":  metrics.BuildPercentileLatencies([]float64{6}),
+		},
+	}, res)
+
+	assert.Empty(t, buildLatenciesByEventPhase(samples, nil, 30*time.Second))
+}
@@ -0,0 +1,229 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+
+	"github.com/urfave/cli"
+)
+
+var benchCompareCommand = cli.Command{
+	Name:      "compare",
+	Usage:     "Compare candidate benchmark report with baseline and flag regressions",
+	ArgsUsage: "BASELINE CANDIDATE",
+	Flags: []cli.Flag{
+		cli.Float64Flag{
+			Name:  "threshold",
+			Usage: "The allowed degradation in percent before it's flagged as regression",
+			Value: 10,
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 2 {
+			return fmt.Errorf("required baseline and candidate reports")
+		}
+		baselinePath, candidatePath := cliCtx.Args().Get(0), cliCtx.Args().Get(1)
+
+		threshold := cliCtx.Float64("threshold")
+		if threshold < 0 {
+			return fmt.Errorf("threshold requires >= 0: %v", threshold)
+		}
+
+		baseline, err := readBenchmarkReport(baselinePath)
+		if err != nil {
+			return err
+		}
+		candidate, err := readBenchmarkReport(candidatePath)
+		if err != nil {
+			return err
+		}
+
+		res, err := compareBenchmarkReports(baseline, candidate, threshold)
+		if err != nil {
+			return err
+		}
+		res.Baseline, res.Candidate = baselinePath, candidatePath
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(res); err != nil {
+			return fmt.Errorf("failed to encode json: %w", err)
+		}
+
+		// NOTE: Render table into stderr so that stdout is still valid JSON.
+		if err := renderBenchmarkComparisonTable(os.Stderr, res); err != nil {
+			return fmt.Errorf("failed to render comparison: %w", err)
+		}
+
+		if len(res.Regressions) > 0 {
+			return fmt.Errorf("found regressions beyond %v%%: %v", threshold, res.Regressions)
+		}
+		return nil
+	},
+}
+
+// readBenchmarkReport reads benchmark report in JSON format from file.
+func readBenchmarkReport(path string) (*internaltypes.BenchmarkReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %w", path, err)
+	}
+
+	report := &internaltypes.BenchmarkReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("failed to decode report %s: %w", path, err)
+	}
+	return report, nil
+}
+
+// compareBenchmarkReports builds deltas from baseline to candidate. Higher
+// latency, lower QPS or higher error rate beyond threshold percent is
+// flagged as regression.
+func compareBenchmarkReports(baseline, candidate *internaltypes.BenchmarkReport, threshold float64) (*internaltypes.BenchmarkComparison, error) {
+	res := &internaltypes.BenchmarkComparison{
+		ThresholdPercent: threshold,
+	}
+
+	candidateLatencies := make(map[float64]float64, len(candidate.Result.PercentileLatencies))
+	for _, pl := range candidate.Result.PercentileLatencies {
+		candidateLatencies[pl[0]] = pl[1]
+	}
+	for _, pl := range baseline.Result.PercentileLatencies {
+		v, ok := candidateLatencies[pl[0]]
+		if !ok {
+			continue
+		}
+
+		delta := internaltypes.PercentileLatencyDelta{
+			Percentile:  pl[0],
+			MetricDelta: newMetricDelta(pl[1], v),
+		}
+		delta.Regressed = delta.DeltaPercent > threshold
+		if delta.Regressed {
+			res.Regressions = append(res.Regressions, percentileName(pl[0]))
+		}
+		res.PercentileLatencies = append(res.PercentileLatencies, delta)
+	}
+	sort.Slice(res.PercentileLatencies, func(i, j int) bool {
+		return res.PercentileLatencies[i].Percentile < res.PercentileLatencies[j].Percentile
+	})
+
+	baselineQPS, err := reportQPS(&baseline.Result)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	candidateQPS, err := reportQPS(&candidate.Result)
+	if err != nil {
+		return nil, fmt.Errorf("candidate: %w", err)
+	}
+	res.QPS = newMetricDelta(baselineQPS, candidateQPS)
+	res.QPS.Regressed = -res.QPS.DeltaPercent > threshold
+	if res.QPS.Regressed {
+		res.Regressions = append(res.Regressions, "QPS")
+	}
+
+	// NOTE: Error rate is ratio already. Use the difference in percentage
+	// points so that new errors on top of zero baseline can be flagged.
+	baselineErrRate, candidateErrRate := reportErrorRate(&baseline.Result), reportErrorRate(&candidate.Result)
+	res.ErrorRate = internaltypes.MetricDelta{
+		Baseline:     baselineErrRate,
+		Candidate:    candidateErrRate,
+		DeltaPercent: (candidateErrRate - baselineErrRate) * 100,
+	}
+	res.ErrorRate.Regressed = res.ErrorRate.DeltaPercent > threshold
+	if res.ErrorRate.Regressed {
+		res.Regressions = append(res.Regressions, "ErrorRate")
+	}
+	return res, nil
+}
+
+// newMetricDelta returns delta with change in percent. The change is zero
+// if baseline is zero.
+func newMetricDelta(baseline, candidate float64) internaltypes.MetricDelta {
+	delta := internaltypes.MetricDelta{
+		Baseline:  baseline,
+		Candidate: candidate,
+	}
+	if baseline != 0 {
+		delta.DeltaPercent = (candidate - baseline) / baseline * 100
+	}
+	return delta
+}
+
+// reportQPS returns the number of completed requests per second. It falls
+// back to total divided by duration if the report doesn't have it.
+func reportQPS(result *types.RunnerGroupsReport) (float64, error) {
+	if result.ActualQPS > 0 || result.Total == 0 {
+		return result.ActualQPS, nil
+	}
+
+	duration, err := time.ParseDuration(result.Duration)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration %q: %w", result.Duration, err)
+	}
+	if duration <= 0 {
+		return 0, nil
+	}
+	return float64(result.Total) / duration.Seconds(), nil
+}
+
+// reportErrorRate returns the ratio of failed requests to completed
+// requests. It falls back to failures by method if the report doesn't
+// have it.
+func reportErrorRate(result *types.RunnerGroupsReport) float64 {
+	if result.ErrorRate > 0 || result.Total == 0 {
+		return result.ErrorRate
+	}
+
+	failures := 0
+	for _, n := range result.FailuresByMethod {
+		failures += n
+	}
+	return float64(failures) / float64(result.Total)
+}
+
+// percentileName returns name of percentile, like P99 for 0.99.
+func percentileName(p float64) string {
+	return "P" + strconv.FormatFloat(p*100, 'f', -1, 64)
+}
+
+// renderBenchmarkComparisonTable renders comparison as table for human, like
+//
+//	METRIC      BASELINE   CANDIDATE   DELTA     REGRESSED
+//	P99 (ms)    9.10       12.00       +31.87%   yes
+//	QPS         100.00     98.00       -2.00%    no
+func renderBenchmarkComparisonTable(w io.Writer, res *internaltypes.BenchmarkComparison) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+
+	fmt.Fprintln(tw, "METRIC\tBASELINE\tCANDIDATE\tDELTA\tREGRESSED")
+
+	writeRow := func(name string, scale float64, delta internaltypes.MetricDelta) {
+		regressed := "no"
+		if delta.Regressed {
+			regressed = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%+.2f%%\t%s\n",
+			name, delta.Baseline*scale, delta.Candidate*scale, delta.DeltaPercent, regressed)
+	}
+
+	for _, pl := range res.PercentileLatencies {
+		writeRow(percentileName(pl.Percentile)+" (ms)", 1000, pl.MetricDelta)
+	}
+	writeRow("QPS", 1, res.QPS)
+	writeRow("ErrorRate (%)", 100, res.ErrorRate)
+
+	fmt.Fprintf(tw, "\n(Threshold: %v%%)\n", res.ThresholdPercent)
+	return tw.Flush()
+}
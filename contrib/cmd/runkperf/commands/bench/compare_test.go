@@ -0,0 +1,55 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareBenchmarkReports(t *testing.T) {
+	baseline := &internaltypes.BenchmarkReport{
+		Result: types.RunnerGroupsReport{
+			Total:               1000,
+			Duration:            "10s",
+			ActualQPS:           100,
+			PercentileLatencies: [][2]float64{{0.5, 0.01}, {0.99, 0.1}},
+		},
+	}
+	// NOTE: In-process cases don't report QPS and error rate.
+	candidate := &internaltypes.BenchmarkReport{
+		Result: types.RunnerGroupsReport{
+			Total:               800,
+			Duration:            "10s",
+			FailuresByMethod:    map[string]int{"GET": 8},
+			PercentileLatencies: [][2]float64{{0.5, 0.0105}, {0.9, 0.05}, {0.99, 0.15}},
+		},
+	}
+
+	res, err := compareBenchmarkReports(baseline, candidate, 10)
+	require.NoError(t, err)
+
+	require.Len(t, res.PercentileLatencies, 2)
+	assert.Equal(t, 0.5, res.PercentileLatencies[0].Percentile)
+	assert.InDelta(t, 5, res.PercentileLatencies[0].DeltaPercent, 1e-6)
+	assert.False(t, res.PercentileLatencies[0].Regressed)
+	assert.Equal(t, 0.99, res.PercentileLatencies[1].Percentile)
+	assert.InDelta(t, 50, res.PercentileLatencies[1].DeltaPercent, 1e-6)
+	assert.True(t, res.PercentileLatencies[1].Regressed)
+
+	assert.InDelta(t, 80, res.QPS.Candidate, 1e-6)
+	assert.InDelta(t, -20, res.QPS.DeltaPercent, 1e-6)
+	assert.True(t, res.QPS.Regressed)
+
+	assert.InDelta(t, 0.01, res.ErrorRate.Candidate, 1e-6)
+	assert.InDelta(t, 1, res.ErrorRate.DeltaPercent, 1e-6)
+	assert.False(t, res.ErrorRate.Regressed)
+
+	assert.Equal(t, []string{"P99", "QPS"}, res.Regressions)
+}
@@ -0,0 +1,202 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/contrib/utils"
+
+	"github.com/urfave/cli"
+)
+
+var benchGetConfigmapsBySizeCase = cli.Command{
+	Name: "get_configmaps_by_size",
+	Usage: `
+
+The test suite is to generate configmaps with different sizes in a namespace
+and get them. It reports the GET latency for each size class. The load profile
+is fixed.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "sizes",
+			Usage: "Comma-separated size classes of configmap (Unit: KiB). The configmap must be less than 1MiB",
+			Value: "1,10,100,1000",
+		},
+		cli.IntFlag{
+			Name:  "total",
+			Usage: "Total requests per runner (There are 10 runners totally and runner's rate is 10)",
+			Value: 1000,
+		},
+		cli.IntFlag{
+			Name:  "duration",
+			Usage: "Duration of the benchmark in seconds. It will be ignored if --total is set.",
+			Value: 0,
+		},
+		cli.StringFlag{
+			Name:  "content-type",
+			Usage: "Content type (json or protobuf)",
+			Value: "json",
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchGetConfigmapsBySizeRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+var benchConfigmapsBySizeNamespace = "kperf-configmaps-size-bench"
+
+// benchGetConfigmapsBySizeRun is for subcommand benchGetConfigmapsBySizeCase.
+func benchGetConfigmapsBySizeRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	sizes, err := parseConfigmapSizes(cliCtx.String("sizes"))
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: The object name should be aligned with configmap generator,
+	// which is runkperf-cm-${namePattern}-${index}.
+	namePatternFn := func(size int) string {
+		return fmt.Sprintf("size-%dkib", size)
+	}
+	objectNameFn := func(size int) string {
+		return fmt.Sprintf("runkperf-cm-%s-0", namePatternFn(size))
+	}
+
+	rgCfgFile, rgSpec, rgCfgFileDone, err := newLoadProfileFromEmbed(cliCtx,
+		"loadprofile/get_configmaps_by_size.yaml",
+		func(spec *types.RunnerGroupSpec) error {
+			// NOTE: The custom load profile has its own requests.
+			if cliCtx.GlobalString("load-profile") != "" {
+				return nil
+			}
+
+			reqs := make([]*types.WeightedRequest, 0, len(sizes))
+			for _, size := range sizes {
+				reqs = append(reqs, &types.WeightedRequest{
+					Shares: 100,
+					QuorumGet: &types.RequestGet{
+						KubeGroupVersionResource: types.KubeGroupVersionResource{
+							Version:  "v1",
+							Resource: "configmaps",
+						},
+						Namespace: benchConfigmapsBySizeNamespace,
+						Name:      objectNameFn(size),
+					},
+				})
+			}
+			spec.Profile.Spec.Requests = reqs
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rgCfgFileDone() }()
+
+	defer func() {
+		for _, size := range sizes {
+			err := utils.DeleteConfigmaps(ctx, kubeCfgPath, benchConfigmapsBySizeNamespace, namePatternFn(size), 0)
+			if err != nil {
+				log.GetLogger(ctx).WithKeyValues("level", "error").
+					LogKV("msg", fmt.Sprintf("Failed to delete configmaps: %v", err))
+			}
+		}
+
+		kr := utils.NewKubectlRunner(kubeCfgPath, benchConfigmapsBySizeNamespace)
+		err := kr.DeleteNamespace(ctx, 0, benchConfigmapsBySizeNamespace)
+		if err != nil {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete namespace: %v", err))
+		}
+	}()
+
+	for _, size := range sizes {
+		err = utils.CreateConfigmaps(ctx, kubeCfgPath, benchConfigmapsBySizeNamespace, namePatternFn(size), 1, size, 1, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create configmap with %d KiB: %w", size, err)
+		}
+	}
+
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
+		cliCtx.GlobalString("kubeconfig"),
+		cliCtx.GlobalString("runner-image"),
+		rgCfgFile,
+		cliCtx.GlobalString("runner-flowcontrol"),
+		cliCtx.GlobalString("rg-affinity"),
+	)
+	if derr != nil {
+		return nil, derr
+	}
+
+	latenciesBySize := map[string][][2]float64{}
+	for _, size := range sizes {
+		suffix := fmt.Sprintf("/configmaps/%s", objectNameFn(size))
+		for u, l := range rgResult.PercentileLatenciesByURL {
+			if strings.HasSuffix(strings.SplitN(u, "?", 2)[0], suffix) {
+				latenciesBySize[fmt.Sprintf("%dKiB", size)] = l
+				break
+			}
+		}
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: Generate one configmap for each size class %v (KiB) in a namespace.
+Workload: Get configmaps and get the percentile latency for each size class.`,
+			sizes),
+
+		LoadSpec: *rgSpec,
+		Result:   *rgResult,
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
+			"percentileLatenciesBySize": latenciesBySize,
+		}, rgReadyTime),
+	}, nil
+}
+
+// maxConfigmapSizeInKiB is the largest size class. The whole configmap,
+// including metadata, can't exceed 1MiB.
+const maxConfigmapSizeInKiB = 1023
+
+// parseConfigmapSizes parses comma-separated size classes in KiB.
+func parseConfigmapSizes(str string) ([]int, error) {
+	res := []int{}
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		size, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid size %s: %w", s, err)
+		}
+		if size <= 0 || size > maxConfigmapSizeInKiB {
+			return nil, fmt.Errorf("size %d must be in (0, %d] KiB", size, maxConfigmapSizeInKiB)
+		}
+		res = append(res, size)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("required at least one size class")
+	}
+	return res, nil
+}
@@ -0,0 +1,22 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigmapSizes(t *testing.T) {
+	sizes, err := parseConfigmapSizes(" 1, 10,,1023 ")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 10, 1023}, sizes)
+
+	for _, str := range []string{"", "0", "-1", "1024", "1,x"} {
+		_, err := parseConfigmapSizes(str)
+		assert.Error(t, err, str)
+	}
+}
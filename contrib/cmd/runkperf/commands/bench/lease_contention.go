@@ -0,0 +1,203 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/urfave/cli"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var benchLeaseContentionCase = cli.Command{
+	Name: "lease_contention",
+	Usage: `
+
+The test suite is to simulate many controllers contending on a single Lease
+object, like leader-election storms. Each worker GETs the lease and UPDATEs
+it with its own holder identity. It reports the update conflict rate (409)
+separately from other failures.
+	`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "workers",
+			Usage: "The number of workers contending on the lease",
+			Value: 100,
+		},
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Maximum GET+UPDATE rounds per second for all workers (Zero means no limitation)",
+			Value: 0,
+		},
+		cli.DurationFlag{
+			Name:  "duration",
+			Usage: "Duration of the benchmark",
+			Value: time.Minute,
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchLeaseContentionRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+var (
+	benchLeaseNamespace = "kperf-lease-bench"
+	benchLeaseName      = "kperf-lease-contention"
+)
+
+// benchLeaseContentionRun is for subcommand benchLeaseContentionCase.
+func benchLeaseContentionRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	workers := cliCtx.Int("workers")
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers requires > 0: %v", workers)
+	}
+	qps := cliCtx.Float64("rate")
+	if qps < 0 {
+		return nil, fmt.Errorf("rate requires >= 0: %v", qps)
+	}
+	duration := cliCtx.Duration("duration")
+
+	// NOTE: The client-side rate limiter is disabled so that the workers
+	// can contend as fast as possible.
+	clientset, err := newLocalDriverClientset(kubeCfgPath, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = prepareContentionLease(ctx, clientset)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := clientset.CoreV1().Namespaces().Delete(ctx, benchLeaseNamespace, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete namespace: %v", err))
+		}
+	}()
+
+	respMetric := metrics.NewResponseMetric()
+	url := fmt.Sprintf("/apis/coordination.k8s.io/v1/namespaces/%s/leases/%s", benchLeaseNamespace, benchLeaseName)
+
+	totalDuration := runLocalDriverWorkers(ctx, workers, qps, duration, func(runCtx context.Context, idx int) {
+		contendLease(runCtx, clientset, respMetric, url, fmt.Sprintf("worker-%d", idx))
+	})
+	stats := respMetric.Gather()
+
+	conflicts := stats.ErrorStats[fmt.Sprintf("%s/%d", types.ResponseErrorTypeHTTP, http.StatusConflict)]
+	updates := stats.TotalByMethod[http.MethodPut]
+	conflictRate := float64(0)
+	if updates > 0 {
+		conflictRate = float64(conflicts) / float64(updates)
+	}
+
+	otherFailures := -int(conflicts)
+	for _, n := range stats.FailuresByMethod {
+		otherFailures += n
+	}
+
+	spec := types.LoadProfileSpec{
+		Rate:     qps,
+		Duration: int(duration.Seconds()),
+		Conns:    1,
+		Client:   workers,
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: One Lease object in a namespace.
+Workload: %v workers GET and UPDATE the lease with their own holder identity for %v.`,
+			workers, duration),
+
+		LoadSpec: newLocalDriverLoadSpec("lease contention", spec),
+		Result:   buildLocalDriverReport(stats, totalDuration, &spec),
+		Info: map[string]interface{}{
+			"updates":       updates,
+			"conflicts":     conflicts,
+			"conflictRate":  conflictRate,
+			"otherFailures": otherFailures,
+		},
+	}, nil
+}
+
+// prepareContentionLease creates the namespace and lease for contention.
+func prepareContentionLease(ctx context.Context, clientset kubernetes.Interface) error {
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: benchLeaseNamespace},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", benchLeaseNamespace, err)
+	}
+
+	_, err = clientset.CoordinationV1().Leases(benchLeaseNamespace).Create(ctx, &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: benchLeaseName},
+	}, metav1.CreateOptions{})
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create lease %s: %w", benchLeaseName, err)
+	}
+	return nil
+}
+
+// contendLease GETs the lease and UPDATEs it with identity as holder, like
+// leader-election does.
+func contendLease(ctx context.Context, clientset kubernetes.Interface, respMetric metrics.ResponseMetric, url string, identity string) {
+	cli := clientset.CoordinationV1().Leases(benchLeaseNamespace)
+
+	start := time.Now()
+	lease, err := cli.Get(ctx, benchLeaseName, metav1.GetOptions{})
+	end := time.Now()
+	if err != nil {
+		if ctx.Err() == nil {
+			respMetric.ObserveFailure(http.MethodGet, url, end, end.Sub(start).Seconds(), err)
+		}
+		return
+	}
+	respMetric.ObserveLatency(http.MethodGet, url, end.Sub(start).Seconds())
+
+	now := metav1.NewMicroTime(time.Now())
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+		transitions := int32(0)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.HolderIdentity = &identity
+		lease.Spec.AcquireTime = &now
+		lease.Spec.LeaseTransitions = &transitions
+	}
+	lease.Spec.RenewTime = &now
+
+	start = time.Now()
+	_, err = cli.Update(ctx, lease, metav1.UpdateOptions{})
+	end = time.Now()
+	if err != nil {
+		if ctx.Err() == nil {
+			respMetric.ObserveFailure(http.MethodPut, url, end, end.Sub(start).Seconds(), err)
+		}
+		return
+	}
+	respMetric.ObserveLatency(http.MethodPut, url, end.Sub(start).Seconds())
+}
@@ -0,0 +1,289 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/contrib/utils"
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/urfave/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var benchListChunkSizeCase = cli.Command{
+	Name: "list_chunk_size",
+	Usage: `
+
+The test suite is to generate configmaps in a namespace and drain them by
+chunked LIST with different page sizes, just like controllers' list-watch.
+It reports per-page latency and total time to drain for each chunk size.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "chunk-sizes",
+			Usage: "Comma-separated list of LIST page sizes (0 means no chunking)",
+			Value: "0,100,500,1000",
+		},
+		cli.IntFlag{
+			Name:  "configmap-amount",
+			Usage: "Total amount of configmaps",
+			Value: 10000,
+		},
+		cli.IntFlag{
+			Name:  "size",
+			Usage: "The size of each configmap (Unit: KiB)",
+			Value: 10,
+		},
+		cli.IntFlag{
+			Name:  "group-size",
+			Usage: "The size of each configmap group",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "rounds",
+			Usage: "The number of times to drain the collection for each chunk size",
+			Value: 10,
+		},
+		cli.BoolFlag{
+			Name:  "from-cache",
+			Usage: "Serve the first page from watch cache with resourceVersion=0",
+		},
+		cli.StringFlag{
+			Name:  "content-type",
+			Usage: "Content type (json or protobuf)",
+			Value: "json",
+		},
+		cli.StringFlag{
+			Name:  "percentiles",
+			Usage: "Comma-separated latency percentiles in [0, 1] to report (Empty means the default ones)",
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchListChunkSizeRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+var benchListChunkSizeNamespace = "kperf-list-chunk-size-bench"
+
+// chunkSizeResult is the summary of draining collection with one chunk size.
+type chunkSizeResult struct {
+	// ChunkSize is the LIST page size.
+	ChunkSize int64 `json:"chunkSize"`
+	// PagesPerDrain is the number of LIST requests to drain collection.
+	PagesPerDrain int `json:"pagesPerDrain"`
+	// PercentilePageLatencies is the latency distribution of each page.
+	PercentilePageLatencies [][2]float64 `json:"percentilePageLatencies"`
+	// PercentileDrainDurations is the distribution of total time to drain
+	// collection.
+	PercentileDrainDurations [][2]float64 `json:"percentileDrainDurations"`
+	// Failures is the number of drains which failed.
+	Failures int `json:"failures,omitempty"`
+}
+
+// benchListChunkSizeRun is for subcommand benchListChunkSizeCase.
+func benchListChunkSizeRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	chunkSizes, err := parseChunkSizes(cliCtx.String("chunk-sizes"))
+	if err != nil {
+		return nil, err
+	}
+	rounds := cliCtx.Int("rounds")
+	if rounds <= 0 {
+		return nil, fmt.Errorf("rounds requires > 0: %v", rounds)
+	}
+	contentType := types.ContentType(cliCtx.String("content-type"))
+	if err := contentType.Validate(); err != nil {
+		return nil, err
+	}
+	fromCache := cliCtx.Bool("from-cache")
+	percentiles, err := parsePercentiles(cliCtx.String("percentiles"))
+	if err != nil {
+		return nil, err
+	}
+
+	spec := types.LoadProfileSpec{
+		Conns:       1,
+		Client:      1,
+		ContentType: contentType,
+		Percentiles: percentiles,
+	}
+
+	cmAmount := cliCtx.Int("configmap-amount")
+	cmSize := cliCtx.Int("size")
+	cmGroupSize := cliCtx.Int("group-size")
+
+	clientset, err := newLocalDriverClientset(kubeCfgPath, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := utils.DeleteConfigmaps(ctx, kubeCfgPath, benchListChunkSizeNamespace, "runkperf-bench", 0)
+		if err != nil {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete configmaps: %v", err))
+		}
+
+		kr := utils.NewKubectlRunner(kubeCfgPath, benchListChunkSizeNamespace)
+		err = kr.DeleteNamespace(ctx, 0, benchListChunkSizeNamespace)
+		if err != nil {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete namespace: %v", err))
+		}
+	}()
+
+	err = utils.CreateConfigmaps(ctx, kubeCfgPath, benchListChunkSizeNamespace, "runkperf-bench",
+		cmAmount, cmSize, min(cmGroupSize, cmAmount), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %d configmaps: %w", cmAmount, err)
+	}
+
+	respMetric := metrics.NewResponseMetric()
+	results := make([]chunkSizeResult, 0, len(chunkSizes))
+
+	start := time.Now()
+	for _, chunkSize := range chunkSizes {
+		log.GetLogger(ctx).WithKeyValues("level", "info").
+			LogKV("msg", "draining configmaps", "chunkSize", chunkSize, "rounds", rounds)
+
+		// NOTE: Use chunk size in URL so that the latency table shows
+		// chunk size vs latency.
+		url := fmt.Sprintf("/api/v1/namespaces/%s/configmaps?limit=%d", benchListChunkSizeNamespace, chunkSize)
+
+		res := chunkSizeResult{ChunkSize: chunkSize}
+		pageLatencies := []float64{}
+		drainDurations := []float64{}
+		for i := 0; i < rounds; i++ {
+			pages, latencies, err := drainConfigmapsByChunk(ctx, clientset, respMetric, url, chunkSize, fromCache)
+			pageLatencies = append(pageLatencies, latencies...)
+			if err != nil {
+				res.Failures++
+				log.GetLogger(ctx).WithKeyValues("level", "warn").
+					LogKV("msg", "failed to drain configmaps", "chunkSize", chunkSize, "error", err)
+				continue
+			}
+
+			res.PagesPerDrain = pages
+			drainDuration := 0.0
+			for _, l := range latencies {
+				drainDuration += l
+			}
+			drainDurations = append(drainDurations, drainDuration)
+		}
+		res.PercentilePageLatencies = metrics.BuildPercentileLatenciesWithObjectives(pageLatencies, percentiles)
+		res.PercentileDrainDurations = metrics.BuildPercentileLatenciesWithObjectives(drainDurations, percentiles)
+		results = append(results, res)
+	}
+	totalDuration := time.Since(start)
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: Generate %v configmaps with %v KiB in a namespace.
+Workload: Drain configmaps by chunked LIST %v times for each chunk size %v (from watch cache: %v).`,
+			cmAmount, cmSize, rounds, chunkSizes, fromCache),
+
+		LoadSpec: newLocalDriverLoadSpec("list chunk size", spec),
+		Result:   buildLocalDriverReport(respMetric.Gather(), totalDuration, &spec),
+		Info: map[string]interface{}{
+			"chunkSizes": results,
+		},
+	}, nil
+}
+
+// drainConfigmapsByChunk lists all the configmaps page by page. It returns
+// the number of pages and the latency of each page in seconds.
+func drainConfigmapsByChunk(ctx context.Context, clientset kubernetes.Interface, respMetric metrics.ResponseMetric,
+	url string, chunkSize int64, fromCache bool) (int, []float64, error) {
+
+	opts := metav1.ListOptions{Limit: chunkSize}
+	if fromCache {
+		opts.ResourceVersion = "0"
+	}
+
+	latencies := []float64{}
+	for {
+		start := time.Now()
+		cms, err := clientset.CoreV1().ConfigMaps(benchListChunkSizeNamespace).List(ctx, opts)
+		end := time.Now()
+		latency := end.Sub(start).Seconds()
+		if err != nil {
+			respMetric.ObserveFailure("LIST", url, end, latency, err)
+			return len(latencies), latencies, err
+		}
+		respMetric.ObserveLatency("LIST", url, latency)
+		latencies = append(latencies, latency)
+
+		if cms.Continue == "" {
+			return len(latencies), latencies, nil
+		}
+		// NOTE: The continue token already has resource version.
+		opts = metav1.ListOptions{Limit: chunkSize, Continue: cms.Continue}
+	}
+}
+
+// parseChunkSizes parses comma-separated LIST page sizes.
+func parseChunkSizes(str string) ([]int64, error) {
+	res := []int64{}
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		size, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chunk size %s: %w", s, err)
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("chunk size %d requires >= 0", size)
+		}
+		res = append(res, size)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("required at least one chunk size")
+	}
+	return res, nil
+}
+
+// parsePercentiles parses comma-separated latency percentiles.
+func parsePercentiles(str string) ([]float64, error) {
+	res := []float64{}
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		p, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid percentile %s: %w", s, err)
+		}
+		if p < 0 || p > 1 {
+			return nil, fmt.Errorf("percentile %v requires in [0, 1]", p)
+		}
+		res = append(res, p)
+	}
+	return res, nil
+}
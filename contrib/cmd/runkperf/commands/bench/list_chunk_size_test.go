@@ -0,0 +1,37 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChunkSizes(t *testing.T) {
+	sizes, err := parseChunkSizes(" 0, 100,,500 ")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 100, 500}, sizes)
+
+	for _, str := range []string{"", "-1", "x"} {
+		_, err := parseChunkSizes(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestParsePercentiles(t *testing.T) {
+	percentiles, err := parsePercentiles("")
+	require.NoError(t, err)
+	assert.Empty(t, percentiles)
+
+	percentiles, err = parsePercentiles("0, 0.5,0.999,1")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 0.5, 0.999, 1}, percentiles)
+
+	for _, str := range []string{"-0.1", "1.5", "p99"} {
+		_, err := parsePercentiles(str)
+		assert.Error(t, err, str)
+	}
+}
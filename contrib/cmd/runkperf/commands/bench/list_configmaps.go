@@ -47,9 +47,14 @@ The test suite is to generate configmaps in a namespace and list them. The load
 			Value: 0,
 		},
 	},
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(
-			addAPIServerCoresInfoInterceptor(benchListConfigmapsRun),
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchListConfigmapsRun),
+				),
+			),
 		)(cliCtx)
 		return err
 	},
@@ -106,7 +111,7 @@ func benchListConfigmapsRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport
 			LogKV("msg", fmt.Sprintf("Running for %v seconds", duration.Seconds()))
 	}
 
-	rgResult, derr := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -126,9 +131,9 @@ Workload: List all configmaps in the namespace and get the percentile latency.`,
 
 		LoadSpec: *rgSpec,
 		Result:   *rgResult,
-		Info: map[string]interface{}{
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
 			"configmapSizeInBytes": cmSize,
 			"runningTime":          duration.String(),
-		},
+		}, rgReadyTime),
 	}, nil
 }
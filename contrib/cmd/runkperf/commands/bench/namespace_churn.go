@@ -0,0 +1,284 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/urfave/cli"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+var benchNamespaceChurnCase = cli.Command{
+	Name: "namespace_churn",
+	Usage: `
+
+The test suite is to repeatedly create and delete namespaces, optionally with
+configmaps inside to exercise namespace finalization. It reports create and
+delete latency, and the finalization duration from DELETE request to the
+namespace being gone.
+	`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "workers",
+			Usage: "The number of workers creating and deleting namespaces",
+			Value: 10,
+		},
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Maximum namespace create-delete cycles per second for all workers (Zero means no limitation)",
+			Value: 10,
+		},
+		cli.DurationFlag{
+			Name:  "duration",
+			Usage: "Duration of the benchmark",
+			Value: 5 * time.Minute,
+		},
+		cli.IntFlag{
+			Name:  "objects",
+			Usage: "The number of configmaps created in each namespace before deletion",
+			Value: 0,
+		},
+		cli.DurationFlag{
+			Name:  "finalization-timeout",
+			Usage: "Maximum time to wait for namespace to be gone after DELETE",
+			Value: 5 * time.Minute,
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNamespaceChurnRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+const (
+	// benchNamespaceChurnLabel marks namespaces created by namespace_churn.
+	benchNamespaceChurnLabel = "bench.kperf.io/namespace-churn"
+
+	// URLs used in report. The namespace name is omitted so that latency
+	// isn't split by each namespace.
+	benchNamespaceChurnNamespacesURL = "/api/v1/namespaces"
+	benchNamespaceChurnNamespaceURL  = "/api/v1/namespaces/{name}"
+	benchNamespaceChurnConfigmapsURL = "/api/v1/namespaces/{name}/configmaps"
+)
+
+// benchNamespaceChurnRun is for subcommand benchNamespaceChurnCase.
+func benchNamespaceChurnRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	workers := cliCtx.Int("workers")
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers requires > 0: %v", workers)
+	}
+	qps := cliCtx.Float64("rate")
+	if qps < 0 {
+		return nil, fmt.Errorf("rate requires >= 0: %v", qps)
+	}
+	objects := cliCtx.Int("objects")
+	if objects < 0 {
+		return nil, fmt.Errorf("objects requires >= 0: %v", objects)
+	}
+	duration := cliCtx.Duration("duration")
+	finalizationTimeout := cliCtx.Duration("finalization-timeout")
+
+	clientset, err := newLocalDriverClientset(kubeCfgPath, "")
+	if err != nil {
+		return nil, err
+	}
+	defer cleanupChurnNamespaces(ctx, clientset)
+
+	respMetric := metrics.NewResponseMetric()
+
+	var finalizationMu sync.Mutex
+	finalizations := []float64{}
+	var cycles, finalizationTimeouts int64
+
+	runID := time.Now().Unix()
+	var counter int64
+
+	totalDuration := runLocalDriverWorkers(ctx, workers, qps, duration, func(context.Context, int) {
+		name := fmt.Sprintf("kperf-ns-churn-%d-%d", runID, atomic.AddInt64(&counter, 1))
+		// NOTE: Use ctx instead of the one passed by driver so that the
+		// cycle can be finished and the namespace won't leak.
+		seconds, err := churnNamespace(ctx, clientset, respMetric, name, objects, finalizationTimeout)
+		if err != nil {
+			log.GetLogger(ctx).WithKeyValues("level", "warn").
+				LogKV("msg", "namespace churn cycle failed", "namespace", name, "error", err)
+			if errors.Is(err, errNamespaceFinalizationTimeout) {
+				atomic.AddInt64(&finalizationTimeouts, 1)
+			}
+			return
+		}
+
+		atomic.AddInt64(&cycles, 1)
+		finalizationMu.Lock()
+		finalizations = append(finalizations, seconds)
+		finalizationMu.Unlock()
+	})
+
+	spec := types.LoadProfileSpec{
+		Rate:     qps,
+		Duration: int(duration.Seconds()),
+		Conns:    1,
+		Client:   workers,
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: Namespaces with %v configmaps inside.
+Workload: %v workers create and delete namespaces for %v and wait for namespace finalization.`,
+			objects, workers, duration),
+
+		LoadSpec: newLocalDriverLoadSpec("namespace churn", spec),
+		Result:   buildLocalDriverReport(respMetric.Gather(), totalDuration, &spec),
+		Info: map[string]interface{}{
+			"cycles":                         cycles,
+			"objectsPerNamespace":            objects,
+			"finalizationTimeouts":           finalizationTimeouts,
+			"finalizationPercentileDuration": metrics.BuildPercentileLatenciesWithObjectives(finalizations, spec.Percentiles),
+		},
+	}, nil
+}
+
+// churnNamespace creates namespace with objects inside and deletes it. It
+// returns the finalization duration in seconds, which is from DELETE
+// request to the namespace being gone.
+func churnNamespace(ctx context.Context, clientset kubernetes.Interface, respMetric metrics.ResponseMetric,
+	name string, objects int, timeout time.Duration) (float64, error) {
+
+	observe := func(method, url string, start time.Time, err error) error {
+		end := time.Now()
+		if err != nil {
+			respMetric.ObserveFailure(method, url, end, end.Sub(start).Seconds(), err)
+			return err
+		}
+		respMetric.ObserveLatency(method, url, end.Sub(start).Seconds())
+		return nil
+	}
+
+	start := time.Now()
+	_, err := clientset.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{benchNamespaceChurnLabel: "true"},
+		},
+	}, metav1.CreateOptions{})
+	if err := observe(http.MethodPost, benchNamespaceChurnNamespacesURL, start, err); err != nil {
+		return 0, fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	for i := 0; i < objects; i++ {
+		start = time.Now()
+		_, err := clientset.CoreV1().ConfigMaps(name).Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("kperf-cm-%d", i)},
+			Data:       map[string]string{"key": "value"},
+		}, metav1.CreateOptions{})
+		// NOTE: Still delete namespace even if it fails to create object.
+		_ = observe(http.MethodPost, benchNamespaceChurnConfigmapsURL, start, err)
+	}
+
+	deleteStart := time.Now()
+	err = clientset.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
+	if err := observe(http.MethodDelete, benchNamespaceChurnNamespaceURL, deleteStart, err); err != nil {
+		return 0, fmt.Errorf("failed to delete namespace: %w", err)
+	}
+
+	if err := waitForNamespaceGone(ctx, clientset, name, timeout); err != nil {
+		return 0, err
+	}
+	return time.Since(deleteStart).Seconds(), nil
+}
+
+// errNamespaceFinalizationTimeout means that namespace isn't gone in time
+// after DELETE.
+var errNamespaceFinalizationTimeout = errors.New("namespace finalization timeout")
+
+// waitForNamespaceGone waits for namespace to be removed after finalization.
+// It returns errNamespaceFinalizationTimeout if it isn't gone in timeout.
+func waitForNamespaceGone(ctx context.Context, clientset kubernetes.Interface, name string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		ns, err := clientset.CoreV1().Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: namespace %s isn't gone in %v", errNamespaceFinalizationTimeout, name, timeout)
+			}
+			return fmt.Errorf("failed to get namespace: %w", err)
+		}
+
+		w, err := clientset.CoreV1().Namespaces().Watch(ctx, metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+			ResourceVersion: ns.ResourceVersion,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w: namespace %s isn't gone in %v", errNamespaceFinalizationTimeout, name, timeout)
+			}
+			return fmt.Errorf("failed to watch namespace: %w", err)
+		}
+
+		for event := range w.ResultChan() {
+			if event.Type == watch.Deleted {
+				w.Stop()
+				return nil
+			}
+		}
+		w.Stop()
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("%w: namespace %s isn't gone in %v", errNamespaceFinalizationTimeout, name, timeout)
+		}
+		// NOTE: Watch might be closed by server. Retry with new Get.
+	}
+}
+
+// cleanupChurnNamespaces deletes all the namespaces created by
+// namespace_churn.
+func cleanupChurnNamespaces(ctx context.Context, clientset kubernetes.Interface) {
+	nsList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: benchNamespaceChurnLabel + "=true",
+	})
+	if err != nil {
+		log.GetLogger(ctx).WithKeyValues("level", "error").
+			LogKV("msg", fmt.Sprintf("Failed to list namespaces: %v", err))
+		return
+	}
+
+	for _, ns := range nsList.Items {
+		err := clientset.CoreV1().Namespaces().Delete(ctx, ns.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.GetLogger(ctx).WithKeyValues("level", "error").
+				LogKV("msg", fmt.Sprintf("Failed to delete namespace %s: %v", ns.Name, err))
+		}
+	}
+}
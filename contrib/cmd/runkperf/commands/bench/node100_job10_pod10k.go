@@ -8,13 +8,17 @@ package bench
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	kperfcmdutils "github.com/Azure/kperf/cmd/kperf/commands/utils"
 	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
 	"github.com/Azure/kperf/contrib/utils"
 
 	"github.com/urfave/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var benchNode100Job10Pod10kCase = cli.Command{
@@ -40,12 +44,41 @@ var benchNode100Job10Pod10kCase = cli.Command{
 				Usage: "TTL seconds after finished for each job",
 				Value: 0,
 			},
+			cli.StringFlag{
+				Name:  "pod-image",
+				Usage: "Override the container image of job's pod",
+			},
+			cli.StringSliceFlag{
+				Name:  "pod-command",
+				Usage: "Override the command of job's pod. Repeat it for each argument",
+			},
+			cli.StringSliceFlag{
+				Name:  "pod-requests",
+				Usage: "Resource requests of job's pod, like cpu=100m (FORMAT: NAME=QUANTITY)",
+			},
+			cli.IntFlag{
+				Name:  "pod-annotations",
+				Usage: "The number of annotations which --annotation-bytes is split across (default: 1)",
+			},
+			cli.IntFlag{
+				Name:  "annotation-bytes",
+				Usage: "Pad each job's pod with synthetic annotations of that many bytes to inflate its object size",
+			},
+			cli.IntFlag{
+				Name:  "label-count",
+				Usage: "Pad each job's pod with that many synthetic labels",
+			},
 		},
 		commonFlags...,
 	),
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(
-			addAPIServerCoresInfoInterceptor(benchNode100Job10Pod10kCaseRun),
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNode100Job10Pod10kCaseRun),
+				),
+			),
 		)(cliCtx)
 		return err
 	},
@@ -63,6 +96,16 @@ func benchNode100Job10Pod10kCaseRun(cliCtx *cli.Context) (*internaltypes.Benchma
 		totalPods  = jobCount * podsPerJob // 10,000 pods
 	)
 
+	// NOTE: Validate pod spec override before deploying anything.
+	podSpec, err := jobPodSpecOverrideFromFlags(cliCtx)
+	if err != nil {
+		return nil, err
+	}
+	jobsOpts := []utils.DeployJobsOpt{}
+	if podSpec != nil {
+		jobsOpts = append(jobsOpts, utils.WithDeployJobsPodSpecOpt(*podSpec))
+	}
+
 	rgCfgFile, rgSpec, rgCfgFileDone, err := newLoadProfileFromEmbed(cliCtx,
 		"loadprofile/node100_job10_pod10k.yaml")
 	if err != nil {
@@ -94,14 +137,24 @@ func benchNode100Job10Pod10kCaseRun(cliCtx *cli.Context) (*internaltypes.Benchma
 		parallelism,
 		"job10pod10k",
 		10*time.Minute, // deployTimeout
+		jobsOpts...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to deploy jobs: %w", err)
 	}
 	defer jobsCleanup()
 
+	// NOTE: The pod size is for reference only. It shouldn't fail the run.
+	// It's omitted in the report if sampling fails.
+	avgPodSize, avgPodSizeErr := getAveragePodSize(ctx, kubeCfgPath, "job10pod10k", "job-group=benchmark-jobs")
+	if avgPodSizeErr != nil {
+		log.GetLogger(ctx).
+			WithKeyValues("level", "warn").
+			LogKV("msg", "failed to get the average size of pods", "error", avgPodSizeErr)
+	}
+
 	// Deploy runner group to measure read-only performance
-	rgResult, err := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, err := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -112,6 +165,14 @@ func benchNode100Job10Pod10kCaseRun(cliCtx *cli.Context) (*internaltypes.Benchma
 		return nil, err
 	}
 
+	info := withRunnerGroupReadyTime(map[string]interface{}{}, rgReadyTime)
+	if avgPodSizeErr == nil {
+		info["avgPodSizeInBytes"] = avgPodSize
+	}
+	if podSpec != nil {
+		info["podSpecOverride"] = podSpec
+	}
+
 	return &internaltypes.BenchmarkReport{
 		Description: fmt.Sprintf(`
 		Environment: %d virtual nodes managed by kwok-controller,
@@ -120,6 +181,72 @@ func benchNode100Job10Pod10kCaseRun(cliCtx *cli.Context) (*internaltypes.Benchma
 			nodeCount, jobCount, podsPerJob, totalPods, parallelism),
 		LoadSpec: *rgSpec,
 		Result:   *rgResult,
-		Info:     make(map[string]interface{}),
+		Info:     info,
 	}, nil
 }
+
+// jobPodSpecOverrideFromFlags returns pod spec override from flags. It
+// returns nil if there is no override.
+func jobPodSpecOverrideFromFlags(cliCtx *cli.Context) (*utils.JobPodSpecOverride, error) {
+	if !cliCtx.IsSet("pod-image") && !cliCtx.IsSet("pod-command") && !cliCtx.IsSet("pod-requests") &&
+		!cliCtx.IsSet("pod-annotations") && !cliCtx.IsSet("annotation-bytes") && !cliCtx.IsSet("label-count") {
+		return nil, nil
+	}
+
+	requests, err := kperfcmdutils.KeyValueMap(cliCtx.StringSlice("pod-requests"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse pod-requests: %w", err)
+	}
+
+	podSpec := &utils.JobPodSpecOverride{
+		Image:           cliCtx.String("pod-image"),
+		Command:         cliCtx.StringSlice("pod-command"),
+		Requests:        requests,
+		Annotations:     cliCtx.Int("pod-annotations"),
+		AnnotationBytes: cliCtx.Int("annotation-bytes"),
+		Labels:          cliCtx.Int("label-count"),
+	}
+	if err := podSpec.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid pod spec override: %w", err)
+	}
+	return podSpec, nil
+}
+
+// avgPodSizeSampleLimit is the number of pods sampled to get average pod size.
+const avgPodSizeSampleLimit = 100
+
+// getAveragePodSize returns the average size of pods in JSON format, sampled
+// from the first page of pods with labelSelector in namespace.
+func getAveragePodSize(ctx context.Context, kubeCfgPath string, namespace, labelSelector string) (int, error) {
+	log.GetLogger(ctx).
+		WithKeyValues("level", "info").
+		LogKV("msg", "get the average size of pods", "labelSelector", labelSelector, "namespace", namespace)
+
+	cli, err := utils.BuildClientset(kubeCfgPath)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := cli.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+		Limit:         avgPodSizeSampleLimit,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pods with labelSelector %s: %w",
+			labelSelector, err)
+	}
+	if len(resp.Items) == 0 {
+		return 0, fmt.Errorf("no pod with labelSelector %s in namespace %s",
+			labelSelector, namespace)
+	}
+
+	total := 0
+	for _, pod := range resp.Items {
+		data, err := json.Marshal(pod)
+		if err != nil {
+			return 0, fmt.Errorf("failed to json.Marshal pod: %w", err)
+		}
+		total += len(data)
+	}
+	return total / len(resp.Items), nil
+}
@@ -32,9 +32,14 @@ that nodes. It repeats to create and delete job. The load profile is fixed.
 		},
 		commonFlags...,
 	),
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(
-			addAPIServerCoresInfoInterceptor(benchNode100Job1Pod3KCaseRun),
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNode100Job1Pod3KCaseRun),
+				),
+			),
 		)(cliCtx)
 		return err
 	},
@@ -75,7 +80,7 @@ func benchNode100Job1Pod3KCaseRun(cliCtx *cli.Context) (*internaltypes.Benchmark
 			utils.WithJobIntervalOpt(jobInterval))
 	}()
 
-	rgResult, derr := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -95,6 +100,6 @@ Environment: 100 virtual nodes managed by kwok-controller,
 Workload: Deploy 1 job with 3,000 pods repeatedly. The parallelism is 100. The interval is %v`, jobInterval),
 		LoadSpec: *rgSpec,
 		Result:   *rgResult,
-		Info:     make(map[string]interface{}),
+		Info:     withRunnerGroupReadyTime(map[string]interface{}{}, rgReadyTime),
 	}, nil
 }
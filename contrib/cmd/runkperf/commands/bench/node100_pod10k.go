@@ -51,9 +51,14 @@ benchmark.
 		},
 		commonFlags...,
 	),
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(
-			addAPIServerCoresInfoInterceptor(benchNode100DeploymentNPod10KRun),
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNode100DeploymentNPod10KRun),
+				),
+			),
 		)(cliCtx)
 		return err
 	},
@@ -132,7 +137,7 @@ func benchNode100DeploymentNPod10KRun(cliCtx *cli.Context) (*internaltypes.Bench
 		utils.RollingUpdateDeployments(dpCtx, total, deploymentNamePattern, kubeCfgPath, utils.WithRollingUpdateIntervalTimeoutOpt(restartInterval))
 	}()
 
-	rgResult, derr := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -154,10 +159,10 @@ Workload: Deploy %d deployments with %d pods. Rolling-update deployments one by
 
 		LoadSpec: *rgSpec,
 		Result:   *rgResult,
-		Info: map[string]interface{}{
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
 			"podSizeInBytes": podSize,
 			"interval":       restartInterval.String(),
-		},
+		}, rgReadyTime),
 	}, nil
 }
 
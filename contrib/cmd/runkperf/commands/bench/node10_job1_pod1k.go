@@ -34,9 +34,14 @@ var benchNode10Job1Pod1kCase = cli.Command{
 		},
 		commonFlags...,
 	),
+	Before: checkBenchCasePrerequisites,
 	Action: func(cliCtx *cli.Context) error {
 		_, err := renderBenchmarkReportInterceptor(
-			addAPIServerCoresInfoInterceptor(benchNode10Job1Pod1kCaseRun),
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNode10Job1Pod1kCaseRun),
+				),
+			),
 		)(cliCtx)
 		return err
 	},
@@ -77,7 +82,7 @@ func benchNode10Job1Pod1kCaseRun(cliCtx *cli.Context) (*internaltypes.BenchmarkR
 
 	}()
 
-	rgResult, derr := utils.DeployRunnerGroup(ctx,
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
 		cliCtx.GlobalString("kubeconfig"),
 		cliCtx.GlobalString("runner-image"),
 		rgCfgFile,
@@ -97,6 +102,6 @@ func benchNode10Job1Pod1kCaseRun(cliCtx *cli.Context) (*internaltypes.BenchmarkR
 		Workload: Deploy 1 job with 1,000 pods repeatedly. The parallelism is 100. The interval is %v`, jobInterval),
 		LoadSpec: *rgSpec,
 		Result:   *rgResult,
-		Info:     make(map[string]interface{}),
+		Info:     withRunnerGroupReadyTime(map[string]interface{}{}, rgReadyTime),
 	}, nil
 }
@@ -0,0 +1,178 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/contrib/utils"
+
+	"github.com/urfave/cli"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var benchNodeDaemonsetCase = cli.Command{
+	Name: "node_daemonset",
+	Usage: `
+
+The test suite is to setup N virtual nodes and deploy daemonsets on that nodes,
+so that there are N pods per daemonset. It measures the LIST load from daemonset
+pods on every node, like kubelet, together with node status updates. Run it
+with different --nodes and --daemonsets to see how pods per node affect read
+latency.
+	`,
+	Flags: append(
+		[]cli.Flag{
+			cli.IntFlag{
+				Name:  "nodes",
+				Usage: "The number of virtual nodes",
+				Value: 100,
+			},
+			cli.IntFlag{
+				Name:  "daemonsets",
+				Usage: "The number of daemonsets, which is the number of daemonset pods per node",
+				Value: 1,
+			},
+			cli.IntFlag{
+				Name:  "total",
+				Usage: "Total requests per runner (There are 10 runners totally and runner's rate is 10)",
+				Value: 3000,
+			},
+			cli.IntFlag{
+				Name:  "padding-bytes",
+				Usage: "Add <key=data, value=randomStringByLen(padding-bytes)> in pod's annotation to increase pod size",
+				Value: 0,
+			},
+		},
+		commonFlags...,
+	),
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchNodeDaemonsetCaseRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+// benchNodeDaemonsetCaseRun is for benchNodeDaemonsetCase subcommand.
+func benchNodeDaemonsetCaseRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	nodes := cliCtx.Int("nodes")
+	if nodes <= 0 {
+		return nil, fmt.Errorf("nodes requires > 0: %v", nodes)
+	}
+	dsCount := cliCtx.Int("daemonsets")
+	if dsCount <= 0 {
+		return nil, fmt.Errorf("daemonsets requires > 0: %v", dsCount)
+	}
+
+	rgCfgFile, rgSpec, rgCfgFileDone, err := newLoadProfileFromEmbed(cliCtx,
+		"loadprofile/node_daemonset.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rgCfgFileDone() }()
+
+	// NOTE: The nodepool name should be aligned with ../../../../internal/manifests/loadprofile/node_daemonset.yaml.
+	nodepoolName := "nodedaemonset"
+
+	vcDone, err := deployVirtualNodepool(ctx, cliCtx, nodepoolName,
+		nodes,
+		cliCtx.Int("cpu"),
+		cliCtx.Int("memory"),
+		cliCtx.Int("max-pods"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy virtual node: %w", err)
+	}
+	defer func() { _ = vcDone() }()
+
+	// NOTE: The name and namespace should be aligned with ../../../../internal/manifests/loadprofile/node_daemonset.yaml.
+	dsNamePattern, dsNamespace := "daemonset", "daemonset"
+
+	dsCleanup, err := utils.DeployDaemonsets(ctx,
+		kubeCfgPath,
+		dsNamePattern,
+		dsCount,
+		nodepoolName,
+		cliCtx.Int("padding-bytes"),
+		dsNamespace,
+		10*time.Minute,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy daemonsets: %w", err)
+	}
+	defer dsCleanup()
+
+	readyPods, err := countDaemonsetReadyPods(ctx, kubeCfgPath, dsNamespace, dsNamePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	rgResult, rgReadyTime, err := utils.DeployRunnerGroup(ctx,
+		cliCtx.GlobalString("kubeconfig"),
+		cliCtx.GlobalString("runner-image"),
+		rgCfgFile,
+		cliCtx.GlobalString("runner-flowcontrol"),
+		cliCtx.GlobalString("rg-affinity"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: %d virtual nodes managed by kwok-controller,
+Workload: Deploy %d daemonsets with %d pods per node. Measures LIST load from daemonset pods on every node.`,
+			nodes, dsCount, dsCount),
+		LoadSpec: *rgSpec,
+		Result:   *rgResult,
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
+			"nodes":       nodes,
+			"daemonsets":  dsCount,
+			"podsPerNode": dsCount,
+			"readyPods":   readyPods,
+		}, rgReadyTime),
+	}, nil
+}
+
+// countDaemonsetReadyPods returns the number of ready pods of daemonsets
+// with app=<namePattern> label.
+func countDaemonsetReadyPods(ctx context.Context, kubeCfgPath string, namespace, namePattern string) (int, error) {
+	cli, err := utils.BuildClientset(kubeCfgPath)
+	if err != nil {
+		return 0, err
+	}
+
+	labelSelector := fmt.Sprintf("app=%s", namePattern)
+	dsList, err := cli.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list daemonsets with labelSelector %s: %w",
+			labelSelector, err)
+	}
+
+	infoLogger := log.GetLogger(ctx).WithKeyValues("level", "info")
+
+	total := 0
+	for _, ds := range dsList.Items {
+		infoLogger.LogKV("msg", "dump DaemonSet status",
+			"name", ds.Name, "ns", ds.Namespace,
+			"desired", ds.Status.DesiredNumberScheduled, "ready", ds.Status.NumberReady)
+		total += int(ds.Status.NumberReady)
+	}
+	return total, nil
+}
@@ -4,6 +4,8 @@
 package bench
 
 import (
+	"time"
+
 	kperfcmdutils "github.com/Azure/kperf/cmd/kperf/commands/utils"
 
 	"github.com/urfave/cli"
@@ -27,7 +29,8 @@ var Command = cli.Command{
 			// We should build release pipeline so that we can
 			// build with fixed public release image as default value.
 			// Right now, we need to set image manually.
-			Required: true,
+			//
+			// NOTE: It's checked by each test case.
 		},
 		cli.StringFlag{
 			Name:  "runner-flowcontrol",
@@ -44,15 +47,36 @@ var Command = cli.Command{
 			Usage: "Deploy runner group with a specific labels (FORMAT: KEY=VALUE[,VALUE])",
 			Value: "node.kubernetes.io/instance-type=Standard_D16s_v3,m4.4xlarge,n1-standard-16",
 		},
+		cli.StringFlag{
+			Name:  "rg-tolerations",
+			Usage: "Deploy runner group to the nodes with specific taints (FORMAT: KEY[=VALUE]:EFFECT[,KEY[=VALUE]:EFFECT])",
+		},
+		cli.StringFlag{
+			Name:  "rg-node-selector",
+			Usage: "Deploy runner group to the nodes with specific labels (FORMAT: KEY=VALUE[,KEY=VALUE])",
+		},
 		cli.BoolFlag{
 			Name:   "eks",
 			Usage:  "Indicates the target kubernetes cluster is EKS",
 			Hidden: true,
 		},
+		cli.DurationFlag{
+			Name:  "apiserver-sample-interval",
+			Usage: "The interval to sample apiserver's requests, CPU and memory metrics during benchmark",
+			Value: 30 * time.Second,
+		},
+		cli.StringFlag{
+			Name:  "load-profile",
+			Usage: "Path to the runner group spec file which overrides the embedded load profile of test case",
+		},
 		cli.StringFlag{
 			Name:  "result",
 			Usage: "Path to the file which stores results",
 		},
+		cli.StringFlag{
+			Name:  "result-configmap",
+			Usage: "Store results into configmap as well, which is useful for in-cluster runs (FORMAT: NAMESPACE/NAME)",
+		},
 	},
 	Subcommands: []cli.Command{
 		benchNode10Job1Pod100Case,
@@ -62,6 +86,14 @@ var Command = cli.Command{
 		benchListConfigmapsCase,
 		benchNode10Job1Pod1kCase,
 		benchNode100Job10Pod10kCase,
+		benchGetConfigmapsBySizeCase,
+		benchLeaseContentionCase,
+		benchCompactionImpactCase,
+		benchWatchListInitCase,
+		benchNamespaceChurnCase,
+		benchListChunkSizeCase,
+		benchNodeDaemonsetCase,
+		benchCompareCommand,
 	},
 }
 
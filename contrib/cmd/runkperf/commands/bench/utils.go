@@ -7,19 +7,34 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
 
 	"github.com/Azure/kperf/api/types"
 	kperfcmdutils "github.com/Azure/kperf/cmd/kperf/commands/utils"
 	internaltypes "github.com/Azure/kperf/contrib/internal/types"
 	"github.com/Azure/kperf/contrib/log"
 	"github.com/Azure/kperf/contrib/utils"
+	"github.com/Azure/kperf/metrics"
 
 	"github.com/urfave/cli"
+	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
 )
 
+// flowcontrolSampleInterval is the interval to sample apiserver's APF metrics.
+const flowcontrolSampleInterval = 30 * time.Second
+
 // subcmdActionFunc is to unify each subcommand's interface. They should return
 // benchmark report as result.
 type subcmdActionFunc func(*cli.Context) (*internaltypes.BenchmarkReport, error)
@@ -59,7 +74,205 @@ func addAPIServerCoresInfoInterceptor(handler subcmdActionFunc) subcmdActionFunc
 	}
 }
 
+// addAPIServerFlowControlInfoInterceptor adds summary about which APF
+// priority levels the load landed in and how long it waited in APF queues.
+func addAPIServerFlowControlInfoInterceptor(handler subcmdActionFunc) subcmdActionFunc {
+	return func(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+		ctx := context.Background()
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
+
+		before, ferr := utils.FetchAPIServerFlowControlStats(ctx, kubeCfgPath)
+		if ferr != nil {
+			warnLogger.LogKV("msg", "failed to fetch apiserver flowcontrol metrics", "error", ferr)
+		}
+
+		// NOTE: The current_executing_requests is gauge. Sample it
+		// during the run and keep the peak value for each priority level.
+		peakExecuting := map[string]float64{}
+		peakExecutingByFlowSchema := map[string]float64{}
+
+		sampleCtx, sampleCancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(flowcontrolSampleInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-sampleCtx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				stats, err := utils.FetchAPIServerFlowControlStats(sampleCtx, kubeCfgPath)
+				if err != nil {
+					warnLogger.LogKV("msg", "failed to sample apiserver flowcontrol metrics", "error", err)
+					continue
+				}
+				for pl, s := range stats {
+					peakExecuting[pl] = max(peakExecuting[pl], s.ExecutingRequests)
+					for fs, v := range s.ExecutingRequestsByFlowSchema {
+						peakExecutingByFlowSchema[fs] = max(peakExecutingByFlowSchema[fs], v)
+					}
+				}
+			}
+		}()
+
+		report, err := handler(cliCtx)
+		sampleCancel()
+		wg.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		after, ferr := utils.FetchAPIServerFlowControlStats(ctx, kubeCfgPath)
+		if ferr != nil {
+			warnLogger.LogKV("msg", "failed to fetch apiserver flowcontrol metrics", "error", ferr)
+			return report, nil
+		}
+
+		priorityLevels := map[string]interface{}{}
+		for pl, a := range after {
+			waitCount, waitSeconds := a.WaitCount, a.WaitSeconds
+			if b, ok := before[pl]; ok {
+				waitCount -= b.WaitCount
+				waitSeconds -= b.WaitSeconds
+			}
+
+			avgWaitSeconds := float64(0)
+			if waitCount > 0 {
+				avgWaitSeconds = waitSeconds / waitCount
+			}
+
+			priorityLevels[pl] = map[string]interface{}{
+				"peakExecutingRequests": max(peakExecuting[pl], a.ExecutingRequests),
+				"dispatchedRequests":    waitCount,
+				"avgWaitSeconds":        avgWaitSeconds,
+			}
+		}
+
+		runnerPriorityLevel, _, _ := strings.Cut(cliCtx.GlobalString("runner-flowcontrol"), ":")
+		report.Info["flowcontrol"] = map[string]interface{}{
+			"runnerPriorityLevel":               runnerPriorityLevel,
+			"priorityLevels":                    priorityLevels,
+			"peakExecutingRequestsByFlowSchema": peakExecutingByFlowSchema,
+		}
+		return report, nil
+	}
+}
+
+// addAPIServerLoadInfoInterceptor samples apiserver's requests, CPU and
+// memory metrics before, during and after the run, and adds deltas into
+// benchmark report. It ties client-side latency to server-side load.
+func addAPIServerLoadInfoInterceptor(handler subcmdActionFunc) subcmdActionFunc {
+	return func(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+		ctx := context.Background()
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
+
+		interval := cliCtx.GlobalDuration("apiserver-sample-interval")
+		if interval <= 0 {
+			return nil, fmt.Errorf("apiserver-sample-interval requires > 0: %v", interval)
+		}
+
+		before, ferr := utils.FetchAPIServerLoadStats(ctx, kubeCfgPath)
+		if ferr != nil {
+			warnLogger.LogKV("msg", "failed to fetch apiserver load metrics", "error", ferr)
+		}
+		start := time.Now()
+
+		// NOTE: The go_memstats_alloc_bytes is gauge. Sample it during
+		// the run and keep the peak value for each apiserver. So does
+		// CPU usage between two samples.
+		peakAllocBytes := map[string]float64{}
+		peakCPUCores := map[string]float64{}
+
+		sampleCtx, sampleCancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+
+			last, lastAt := before, start
+			for {
+				select {
+				case <-sampleCtx.Done():
+					return
+				case <-ticker.C:
+				}
+
+				stats, err := utils.FetchAPIServerLoadStats(sampleCtx, kubeCfgPath)
+				if err != nil {
+					warnLogger.LogKV("msg", "failed to sample apiserver load metrics", "error", err)
+					continue
+				}
+				now := time.Now()
+				for ip, s := range stats {
+					peakAllocBytes[ip] = max(peakAllocBytes[ip], s.AllocBytes)
+					if l, ok := last[ip]; ok && s.CPUSeconds >= l.CPUSeconds {
+						peakCPUCores[ip] = max(peakCPUCores[ip], (s.CPUSeconds-l.CPUSeconds)/now.Sub(lastAt).Seconds())
+					}
+				}
+				last, lastAt = stats, now
+			}
+		}()
+
+		report, err := handler(cliCtx)
+		sampleCancel()
+		wg.Wait()
+		if err != nil {
+			return nil, err
+		}
+
+		after, ferr := utils.FetchAPIServerLoadStats(ctx, kubeCfgPath)
+		if ferr != nil {
+			warnLogger.LogKV("msg", "failed to fetch apiserver load metrics", "error", ferr)
+			return report, nil
+		}
+		elapsed := time.Since(start)
+
+		instances := map[string]interface{}{}
+		for ip, a := range after {
+			b, ok := before[ip]
+			// NOTE: The counters are reset if apiserver restarted.
+			if !ok || a.Requests < b.Requests || a.CPUSeconds < b.CPUSeconds {
+				b = &utils.APIServerLoadStats{}
+			}
+
+			cpuSeconds := a.CPUSeconds - b.CPUSeconds
+			instances[ip] = map[string]interface{}{
+				"requests":        a.Requests - b.Requests,
+				"cpuSeconds":      cpuSeconds,
+				"avgCPUCores":     cpuSeconds / elapsed.Seconds(),
+				"peakCPUCores":    peakCPUCores[ip],
+				"allocBytesDelta": a.AllocBytes - b.AllocBytes,
+				"peakAllocBytes":  max(peakAllocBytes[ip], a.AllocBytes, b.AllocBytes),
+			}
+		}
+
+		report.Info["apiserverLoad"] = map[string]interface{}{
+			"sampleInterval": interval.String(),
+			"instances":      instances,
+		}
+		return report, nil
+	}
+}
+
 // renderBenchmarkReportInterceptor renders benchmark report into file or stdout.
+//
+// Before rendering, it adds the effective rate, client, total and the number
+// of runners into report's info so that every case reports them the same way.
+//
+// It also renders percentile latencies as table for human. The table is
+// written into stdout if report is stored in file. Otherwise, it's written
+// into stderr so that stdout is still valid JSON.
 func renderBenchmarkReportInterceptor(handler subcmdActionFunc) subcmdActionFunc {
 	return func(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
 		report, err := handler(cliCtx)
@@ -67,7 +280,12 @@ func renderBenchmarkReportInterceptor(handler subcmdActionFunc) subcmdActionFunc
 			return nil, err
 		}
 
-		outF := os.Stdout
+		if report.Info == nil {
+			report.Info = map[string]interface{}{}
+		}
+		report.Info["load"] = internaltypes.LoadInfo(report.LoadSpec)
+
+		outF, tableF := os.Stdout, os.Stderr
 		if targetFile := cliCtx.GlobalString("result"); targetFile != "" {
 			targetFileDir := filepath.Dir(targetFile)
 
@@ -84,6 +302,7 @@ func renderBenchmarkReportInterceptor(handler subcmdActionFunc) subcmdActionFunc
 				return nil, err
 			}
 			defer outF.Close()
+			tableF = os.Stdout
 		}
 
 		encoder := json.NewEncoder(outF)
@@ -92,10 +311,115 @@ func renderBenchmarkReportInterceptor(handler subcmdActionFunc) subcmdActionFunc
 		if err := encoder.Encode(report); err != nil {
 			return nil, fmt.Errorf("failed to encode json: %w", err)
 		}
+
+		if err := renderPercentileLatenciesTable(tableF, &report.Result); err != nil {
+			return nil, fmt.Errorf("failed to render percentile latencies: %w", err)
+		}
+
+		if ref := cliCtx.GlobalString("result-configmap"); ref != "" {
+			if err := saveBenchmarkReportIntoConfigmap(cliCtx, ref, report); err != nil {
+				return nil, err
+			}
+		}
 		return report, nil
 	}
 }
 
+const (
+	// benchReportConfigmapAppLabel is the app label value of configmaps
+	// storing benchmark reports, which can be used to list all the reports.
+	benchReportConfigmapAppLabel = "runkperf-report"
+	// benchReportConfigmapKey is the key of report in configmap's data.
+	benchReportConfigmapKey = "report.json"
+)
+
+// saveBenchmarkReportIntoConfigmap stores report into configmap referred by
+// ref in NAMESPACE/NAME format.
+func saveBenchmarkReportIntoConfigmap(cliCtx *cli.Context, ref string, report *internaltypes.BenchmarkReport) error {
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok || namespace == "" || name == "" {
+		return fmt.Errorf("invalid result-configmap %q, expected NAMESPACE/NAME", ref)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+
+	labels := map[string]string{
+		"app":       benchReportConfigmapAppLabel,
+		"benchCase": cliCtx.Command.Name,
+	}
+	return utils.SaveReportIntoConfigmap(context.Background(), cliCtx.GlobalString("kubeconfig"),
+		namespace, name, labels, benchReportConfigmapKey, data)
+}
+
+// renderPercentileLatenciesTable renders overall and per-URL percentile
+// latencies as table in milliseconds, like
+//
+//	URL     P0     P50    P90    P95    P99    P100
+//	(all)   1.20   3.40   5.60   7.80   9.10   20.00
+func renderPercentileLatenciesTable(w io.Writer, result *types.RunnerGroupsReport) error {
+	percentiles := map[float64]struct{}{}
+	for _, pl := range result.PercentileLatencies {
+		percentiles[pl[0]] = struct{}{}
+	}
+	for _, l := range result.PercentileLatenciesByURL {
+		for _, pl := range l {
+			percentiles[pl[0]] = struct{}{}
+		}
+	}
+	if len(percentiles) == 0 {
+		return nil
+	}
+
+	columns := make([]float64, 0, len(percentiles))
+	for p := range percentiles {
+		columns = append(columns, p)
+	}
+	sort.Float64s(columns)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 3, ' ', 0)
+
+	fmt.Fprint(tw, "URL")
+	for _, p := range columns {
+		fmt.Fprintf(tw, "\tP%s", strconv.FormatFloat(p*100, 'f', -1, 64))
+	}
+	fmt.Fprintln(tw)
+
+	writeRow := func(name string, latencies [][2]float64) {
+		byPercentile := make(map[float64]float64, len(latencies))
+		for _, pl := range latencies {
+			byPercentile[pl[0]] = pl[1]
+		}
+
+		fmt.Fprint(tw, name)
+		for _, p := range columns {
+			v, ok := byPercentile[p]
+			if !ok {
+				fmt.Fprint(tw, "\t-")
+				continue
+			}
+			fmt.Fprintf(tw, "\t%.2f", v*1000)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	writeRow("(all)", result.PercentileLatencies)
+
+	urls := make([]string, 0, len(result.PercentileLatenciesByURL))
+	for u := range result.PercentileLatenciesByURL {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	for _, u := range urls {
+		writeRow(u, result.PercentileLatenciesByURL[u])
+	}
+
+	fmt.Fprintln(tw, "\n(Unit: ms)")
+	return tw.Flush()
+}
+
 // deployVirtualNodepool deploys virtual nodepool.
 func deployVirtualNodepool(ctx context.Context, cliCtx *cli.Context, target string, nodes, cpu, memory, maxPods int) (func() error, error) {
 	log.GetLogger(ctx).
@@ -140,17 +464,45 @@ func deployVirtualNodepool(ctx context.Context, cliCtx *cli.Context, target stri
 func NewRunnerGroupSpecFromYamlFile() {}
 
 // newLoadProfileFromEmbed loads load profile from embed and tweaks that load
-// profile.
-func newLoadProfileFromEmbed(cliCtx *cli.Context, name string) (_name string, _spec *types.RunnerGroupSpec, _cleanup func() error, _err error) {
+// profile. The embedded one is overridden by --load-profile if it's set.
+//
+// The tweakFns are applied after common flags so that the subcommand can
+// customize the load profile, like requests. The load profile is validated
+// after tweak so that the invalid one fails before deploying runners.
+func newLoadProfileFromEmbed(cliCtx *cli.Context, name string, tweakFns ...func(*types.RunnerGroupSpec) error) (_name string, _spec *types.RunnerGroupSpec, _cleanup func() error, _err error) {
+	newSpecFile := func(tweakFn func(*types.RunnerGroupSpec) error) (string, func() error, error) {
+		return utils.NewRunnerGroupSpecFileFromEmbed(name, tweakFn)
+	}
+
+	loadProfilePath := cliCtx.GlobalString("load-profile")
+	if loadProfilePath != "" {
+		newSpecFile = func(tweakFn func(*types.RunnerGroupSpec) error) (string, func() error, error) {
+			return utils.NewRunnerGroupSpecFileFromFile(loadProfilePath, tweakFn)
+		}
+	}
+
 	var rgSpec types.RunnerGroupSpec
-	rgCfgFile, rgCfgFileDone, err := utils.NewRunnerGroupSpecFileFromEmbed(
-		name,
+	rgCfgFile, rgCfgFileDone, err := newSpecFile(
 		func(spec *types.RunnerGroupSpec) error {
+			if spec.Profile == nil {
+				return fmt.Errorf("loadProfile is required")
+			}
+
 			reqs := cliCtx.Int("total")
 			if reqs < 0 {
 				return fmt.Errorf("invalid total-requests value: %v", reqs)
 			}
 			reqsTime := cliCtx.Int("duration")
+			// NOTE: The custom load profile has its own total and
+			// duration. Only override them if the flags are set.
+			if loadProfilePath != "" {
+				if !cliCtx.IsSet("total") {
+					reqs = 0
+				}
+				if !cliCtx.IsSet("duration") {
+					reqsTime = 0
+				}
+			}
 			if !cliCtx.IsSet("total") && reqsTime > 0 {
 				reqs = 0
 				spec.Profile.Spec.Duration = reqsTime
@@ -162,11 +514,38 @@ func newLoadProfileFromEmbed(cliCtx *cli.Context, name string) (_name string, _s
 				return fmt.Errorf("failed to parse %s affinity: %w", rgAffinity, err)
 			}
 
+			if v := cliCtx.GlobalString("rg-tolerations"); v != "" {
+				tolerations, err := kperfcmdutils.ParseTolerations(strings.Split(v, ","))
+				if err != nil {
+					return fmt.Errorf("failed to parse %s tolerations: %w", v, err)
+				}
+				spec.Tolerations = tolerations
+			}
+
+			if v := cliCtx.GlobalString("rg-node-selector"); v != "" {
+				nodeSelector, err := kperfcmdutils.KeyValueMap(strings.Split(v, ","))
+				if err != nil {
+					return fmt.Errorf("failed to parse %s node selector: %w", v, err)
+				}
+				spec.NodeSelector = nodeSelector
+			}
+
 			if reqs != 0 {
 				spec.Profile.Spec.Total = reqs
 			}
 			spec.NodeAffinity = affinityLabels
 			spec.Profile.Spec.ContentType = types.ContentType(cliCtx.String("content-type"))
+
+			for _, tweakFn := range tweakFns {
+				if err := tweakFn(spec); err != nil {
+					return err
+				}
+			}
+
+			if err := spec.Profile.Validate(); err != nil {
+				return fmt.Errorf("invalid load profile: %w", err)
+			}
+
 			data, _ := yaml.Marshal(spec)
 
 			log.GetLogger(context.TODO()).
@@ -182,3 +561,121 @@ func newLoadProfileFromEmbed(cliCtx *cli.Context, name string) (_name string, _s
 	}
 	return rgCfgFile, &rgSpec, rgCfgFileDone, nil
 }
+
+// checkBenchCasePrerequisites fails fast if runner image isn't set or
+// kube-apiserver is unreachable.
+//
+// NOTE: The runner-image isn't required by bench command because some
+// subcommands, like compare, don't touch cluster.
+func checkBenchCasePrerequisites(cliCtx *cli.Context) error {
+	if cliCtx.GlobalString("runner-image") == "" {
+		return fmt.Errorf("required flag \"runner-image\" not set")
+	}
+	return utils.CheckAPIServerConnectivity(cliCtx.GlobalString("kubeconfig"))
+}
+
+// withRunnerGroupReadyTime adds the time it took until all the runners were
+// started into info. It's omitted if it's unknown.
+func withRunnerGroupReadyTime(info map[string]interface{}, readyTime time.Duration) map[string]interface{} {
+	if readyTime > 0 {
+		info["runnerGroupReadyTime"] = readyTime.String()
+	}
+	return info
+}
+
+// newLocalDriverClientset returns clientset for the cases which send
+// requests from local process instead of runner group. The client-side rate
+// limiter is disabled so that the load is only limited by the case.
+func newLocalDriverClientset(kubeCfgPath string, contentType types.ContentType) (*kubernetes.Clientset, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-go config: %w", err)
+	}
+	config.RateLimiter = flowcontrol.NewFakeAlwaysRateLimiter()
+	if contentType != "" {
+		config.ContentType = contentType.MediaType()
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-go rest client: %w", err)
+	}
+	return clientset, nil
+}
+
+// runLocalDriverWorkers runs workers which call fn repeatedly until duration
+// elapses. The calls from all the workers are limited by qps and zero qps
+// means no limitation. The ctx passed to fn is done after duration. It
+// returns the time it took until all the workers stopped.
+func runLocalDriverWorkers(ctx context.Context, workers int, qps float64, duration time.Duration,
+	fn func(ctx context.Context, idx int)) time.Duration {
+
+	limit := rate.Inf
+	if qps > 0 {
+		limit = rate.Limit(qps)
+	}
+	limiter := rate.NewLimiter(limit, 1)
+
+	runCtx, runCancel := context.WithTimeout(ctx, duration)
+	defer runCancel()
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+
+			for {
+				if err := limiter.Wait(runCtx); err != nil {
+					return
+				}
+				fn(runCtx, idx)
+			}
+		}(i)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// buildLocalDriverReport builds report from the stats of requests sent from
+// local process. The latencies are reported in spec's percentiles.
+func buildLocalDriverReport(stats types.ResponseStats, duration time.Duration, spec *types.LoadProfileSpec) types.RunnerGroupsReport {
+	total := 0
+	for _, n := range stats.TotalByMethod {
+		total += n
+	}
+
+	latencies := []float64{}
+	percentileLatenciesByURL := map[string][][2]float64{}
+	for u, l := range stats.LatenciesByURL {
+		latencies = append(latencies, l...)
+		percentileLatenciesByURL[u] = metrics.BuildPercentileLatenciesWithObjectives(l, spec.Percentiles)
+	}
+
+	return types.RunnerGroupsReport{
+		Total:                    total,
+		Duration:                 duration.String(),
+		ErrorStats:               stats.ErrorStats,
+		ErrorClasses:             stats.ErrorClasses,
+		TotalReceivedBytes:       stats.TotalReceivedBytes,
+		PercentileLatencies:      metrics.BuildPercentileLatenciesWithObjectives(latencies, spec.Percentiles),
+		PercentileLatenciesByURL: percentileLatenciesByURL,
+		TotalByMethod:            stats.TotalByMethod,
+		FailuresByMethod:         stats.FailuresByMethod,
+		FailuresByStatusCode:     stats.FailuresByStatusCode,
+	}
+}
+
+// newLocalDriverLoadSpec returns the runner group spec in report for the
+// load sent from local process.
+func newLocalDriverLoadSpec(description string, spec types.LoadProfileSpec) types.RunnerGroupSpec {
+	return types.RunnerGroupSpec{
+		Profile: &types.LoadProfile{
+			Version:     1,
+			Description: description,
+			Spec:        spec,
+		},
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLocalDriverReport(t *testing.T) {
+	stats := types.ResponseStats{
+		ErrorStats: map[string]int32{"http/409": 1},
+		LatenciesByURL: map[string][]float64{
+			"/a": {1, 2},
+			"/b": {3},
+		},
+		TotalByMethod:    map[string]int{"GET": 2, "PUT": 2},
+		FailuresByMethod: map[string]int{"PUT": 1},
+	}
+	spec := &types.LoadProfileSpec{Percentiles: []float64{0, 1}}
+
+	report := buildLocalDriverReport(stats, 2*time.Second, spec)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, "2s", report.Duration)
+	assert.Equal(t, [][2]float64{{0, 1}, {1, 3}}, report.PercentileLatencies)
+	assert.Equal(t, map[string][][2]float64{
+		"/a": {{0, 1}, {1, 2}},
+		"/b": {{0, 3}, {1, 3}},
+	}, report.PercentileLatenciesByURL)
+	assert.Equal(t, stats.ErrorStats, report.ErrorStats)
+	assert.Equal(t, stats.FailuresByMethod, report.FailuresByMethod)
+}
+
+func TestRunLocalDriverWorkers(t *testing.T) {
+	var calls int64
+	seen := make([]int64, 3)
+
+	runLocalDriverWorkers(context.Background(), 3, 0, 100*time.Millisecond, func(ctx context.Context, idx int) {
+		atomic.AddInt64(&calls, 1)
+		atomic.AddInt64(&seen[idx], 1)
+		time.Sleep(time.Millisecond)
+	})
+	assert.Greater(t, atomic.LoadInt64(&calls), int64(3))
+	for idx := range seen {
+		assert.Greater(t, seen[idx], int64(0), "worker %d", idx)
+	}
+
+	calls = 0
+	runLocalDriverWorkers(context.Background(), 2, 10, 250*time.Millisecond, func(context.Context, int) {
+		atomic.AddInt64(&calls, 1)
+	})
+	assert.LessOrEqual(t, calls, int64(4))
+}
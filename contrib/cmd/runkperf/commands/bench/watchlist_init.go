@@ -0,0 +1,208 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package bench
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/kperf/api/types"
+	internaltypes "github.com/Azure/kperf/contrib/internal/types"
+	"github.com/Azure/kperf/contrib/log"
+	"github.com/Azure/kperf/contrib/utils"
+
+	"github.com/urfave/cli"
+)
+
+var benchWatchListInitCase = cli.Command{
+	Name: "watchlist_init",
+	Usage: `
+
+The test suite is to generate configmaps with different object counts, one
+namespace for each count. It repeatedly establishes fresh WATCHLIST requests
+with sendInitialEvents=true and reports the time to receive the bookmark of
+initial events done for each object count. The load profile is fixed.
+
+NOTE: The kube-apiserver should enable WatchList feature.
+	`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "counts",
+			Usage: "Comma-separated object counts. Each count has its own namespace",
+			Value: "1000,5000,10000",
+		},
+		cli.IntFlag{
+			Name:  "size",
+			Usage: "The size of each configmap (Unit: KiB)",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  "group-size",
+			Usage: "The size of each configmap group",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "total",
+			Usage: "Total requests per runner (There are 10 runners totally and runner's rate is 1)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "duration",
+			Usage: "Duration of the benchmark in seconds. It will be ignored if --total is set.",
+			Value: 0,
+		},
+		cli.StringFlag{
+			Name:  "content-type",
+			Usage: "Content type (json or protobuf)",
+			Value: "json",
+		},
+	},
+	Before: checkBenchCasePrerequisites,
+	Action: func(cliCtx *cli.Context) error {
+		_, err := renderBenchmarkReportInterceptor(
+			addAPIServerCoresInfoInterceptor(
+				addAPIServerFlowControlInfoInterceptor(
+					addAPIServerLoadInfoInterceptor(benchWatchListInitRun),
+				),
+			),
+		)(cliCtx)
+		return err
+	},
+}
+
+// benchWatchListInitNamespace returns namespace for configmaps with count.
+func benchWatchListInitNamespace(count int) string {
+	return fmt.Sprintf("kperf-watchlist-bench-%d", count)
+}
+
+// benchWatchListInitRun is for subcommand benchWatchListInitCase.
+func benchWatchListInitRun(cliCtx *cli.Context) (*internaltypes.BenchmarkReport, error) {
+	ctx := context.Background()
+	kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+
+	counts, err := parseObjectCounts(cliCtx.String("counts"))
+	if err != nil {
+		return nil, err
+	}
+	cmSize := cliCtx.Int("size")
+	cmGroupSize := cliCtx.Int("group-size")
+
+	rgCfgFile, rgSpec, rgCfgFileDone, err := newLoadProfileFromEmbed(cliCtx,
+		"loadprofile/watchlist_init.yaml",
+		func(spec *types.RunnerGroupSpec) error {
+			// NOTE: The custom load profile has its own requests.
+			if cliCtx.GlobalString("load-profile") != "" {
+				return nil
+			}
+
+			reqs := make([]*types.WeightedRequest, 0, len(counts))
+			for _, count := range counts {
+				reqs = append(reqs, &types.WeightedRequest{
+					Shares: 100,
+					WatchList: &types.RequestWatchList{
+						KubeGroupVersionResource: types.KubeGroupVersionResource{
+							Version:  "v1",
+							Resource: "configmaps",
+						},
+						Namespace: benchWatchListInitNamespace(count),
+					},
+				})
+			}
+			spec.Profile.Spec.Requests = reqs
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rgCfgFileDone() }()
+
+	defer func() {
+		for _, count := range counts {
+			ns := benchWatchListInitNamespace(count)
+
+			err := utils.DeleteConfigmaps(ctx, kubeCfgPath, ns, "runkperf-bench", 0)
+			if err != nil {
+				log.GetLogger(ctx).WithKeyValues("level", "error").
+					LogKV("msg", fmt.Sprintf("Failed to delete configmaps: %v", err))
+			}
+
+			kr := utils.NewKubectlRunner(kubeCfgPath, ns)
+			err = kr.DeleteNamespace(ctx, 0, ns)
+			if err != nil {
+				log.GetLogger(ctx).WithKeyValues("level", "error").
+					LogKV("msg", fmt.Sprintf("Failed to delete namespace: %v", err))
+			}
+		}
+	}()
+
+	for _, count := range counts {
+		err = utils.CreateConfigmaps(ctx, kubeCfgPath, benchWatchListInitNamespace(count), "runkperf-bench", count, cmSize, min(cmGroupSize, count), 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %d configmaps: %w", count, err)
+		}
+	}
+
+	rgResult, rgReadyTime, derr := utils.DeployRunnerGroup(ctx,
+		cliCtx.GlobalString("kubeconfig"),
+		cliCtx.GlobalString("runner-image"),
+		rgCfgFile,
+		cliCtx.GlobalString("runner-flowcontrol"),
+		cliCtx.GlobalString("rg-affinity"),
+	)
+	if derr != nil {
+		return nil, derr
+	}
+
+	latenciesByCount := map[string][][2]float64{}
+	for _, count := range counts {
+		nsComp := fmt.Sprintf("/namespaces/%s/", benchWatchListInitNamespace(count))
+		for u, l := range rgResult.PercentileLatenciesByURL {
+			if strings.Contains(u, nsComp) {
+				latenciesByCount[strconv.Itoa(count)] = l
+				break
+			}
+		}
+	}
+
+	return &internaltypes.BenchmarkReport{
+		Description: fmt.Sprintf(`
+Environment: Generate configmaps with %v KiB each for each object count %v, one namespace for each count.
+Workload: WATCHLIST configmaps with sendInitialEvents=true and get the percentile latency of initial events done for each object count.`,
+			cmSize, counts),
+
+		LoadSpec: *rgSpec,
+		Result:   *rgResult,
+		Info: withRunnerGroupReadyTime(map[string]interface{}{
+			"percentileInitialEventsDoneByObjects": latenciesByCount,
+		}, rgReadyTime),
+	}, nil
+}
+
+// parseObjectCounts parses comma-separated object counts.
+func parseObjectCounts(str string) ([]int, error) {
+	res := []int{}
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		count, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid count %s: %w", s, err)
+		}
+		if count <= 0 {
+			return nil, fmt.Errorf("count %d requires > 0", count)
+		}
+		res = append(res, count)
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("required at least one object count")
+	}
+	return res, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+// Package common contains the helpers shared by data subcommands.
+package common
+
+import (
+	"context"
+	"fmt"
+
+	contributils "github.com/Azure/kperf/contrib/utils"
+
+	"github.com/urfave/cli"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// PrepareNamespace creates namespace if it doesn't exist.
+func PrepareNamespace(kubeCfgPath string, namespace string) error {
+	if namespace == "" {
+		return fmt.Errorf("namespace cannot be empty")
+	}
+
+	if namespace == "default" {
+		return nil
+	}
+
+	clientset, err := NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+	if err != nil {
+		return err
+	}
+
+	_, err = clientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: namespace,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		// If the namespace already exists, ignore the error
+		if errors.IsAlreadyExists(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to create namespace %s: %v", namespace, err)
+	}
+	return nil
+}
+
+// NewClientsetWithRateLimiter returns clientset whose requests are limited
+// by qps and burst.
+func NewClientsetWithRateLimiter(kubeCfgPath string, qps float32, burst int) (*kubernetes.Clientset, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config.QPS = qps
+	config.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return clientset, nil
+}
+
+// CheckSetParams verifies the size, group size and total of objects set
+// created by add subcommand.
+func CheckSetParams(size int, groupSize int, total int) error {
+	if size <= 0 {
+		return fmt.Errorf("size must be greater than 0")
+	}
+	if groupSize <= 0 {
+		return fmt.Errorf("group-size must be greater than 0")
+	}
+	if total <= 0 {
+		return fmt.Errorf("total amount must be greater than 0")
+	}
+	if groupSize > total {
+		return fmt.Errorf("group-size must be less than or equal to total")
+	}
+	return nil
+}
+
+// CheckAPIServerConnectivity fails fast if kube-apiserver is unreachable.
+// It's the Before of command which has kubeconfig flag and subcommands. It's
+// skipped if there is no subcommand or help is required, which doesn't need
+// kube-apiserver.
+func CheckAPIServerConnectivity(cliCtx *cli.Context) error {
+	args := cliCtx.Args()
+	if !args.Present() || args.First() == "help" || args.First() == "h" {
+		return nil
+	}
+	for _, arg := range args.Tail() {
+		if arg == "-h" || arg == "--help" {
+			return nil
+		}
+	}
+	return contributils.CheckAPIServerConnectivity(cliCtx.String("kubeconfig"))
+}
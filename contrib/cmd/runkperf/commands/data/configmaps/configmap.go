@@ -6,24 +6,29 @@ package configmaps
 import (
 	"context"
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/Azure/kperf/cmd/kperf/commands/utils"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/flowcontrol"
+	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/common"
 
 	"github.com/urfave/cli"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -49,6 +54,7 @@ var Command = cli.Command{
 		configmapAddCommand,
 		configmapDelCommand,
 		configmapListCommand,
+		configmapChurnCommand,
 	},
 }
 
@@ -72,6 +78,15 @@ var configmapAddCommand = cli.Command{
 			Usage: "Total amount of configmaps",
 			Value: 10,
 		},
+		cli.BoolFlag{
+			Name:  "binary",
+			Usage: "Put random bytes into binaryData instead of data",
+		},
+		cli.Float64Flag{
+			Name:  "entropy",
+			Usage: "The ratio (0.0-1.0) of random content in data. The rest repeats a small dictionary, so data compresses to roughly size * entropy",
+			Value: 1.0,
+		},
 	},
 	Action: func(cliCtx *cli.Context) error {
 		if cliCtx.NArg() != 1 {
@@ -86,25 +101,29 @@ var configmapAddCommand = cli.Command{
 		size := cliCtx.Int("size")
 		groupSize := cliCtx.Int("group-size")
 		total := cliCtx.Int("total")
+		entropy := cliCtx.Float64("entropy")
 
 		// Check if the flags are set correctly
-		err := checkConfigmapParams(size, groupSize, total)
+		err := common.CheckSetParams(size, groupSize, total)
 		if err != nil {
 			return err
 		}
+		if entropy < 0 || entropy > 1 {
+			return fmt.Errorf("entropy must be between 0.0 and 1.0")
+		}
 
 		namespace := cliCtx.GlobalString("namespace")
-		err = prepareNamespace(kubeCfgPath, namespace)
+		err = common.PrepareNamespace(kubeCfgPath, namespace)
 		if err != nil {
 			return err
 		}
 
-		clientset, err := newClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
 		if err != nil {
 			return err
 		}
 
-		err = createConfigmaps(clientset, namespace, cmName, size, groupSize, total)
+		err = createConfigmaps(clientset, namespace, cmName, size, groupSize, total, cliCtx.Bool("binary"), entropy)
 		if err != nil {
 			return err
 		}
@@ -118,6 +137,18 @@ var configmapDelCommand = cli.Command{
 	ShortName: "del",
 	ArgsUsage: "NAME",
 	Usage:     "Delete a configmaps set",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "The number of configmaps deleted in parallel",
+			Value: 10,
+		},
+		cli.Float64Flag{
+			Name:  "qps",
+			Usage: "The maximum number of delete requests per second",
+			Value: 30,
+		},
+	},
 	Action: func(cliCtx *cli.Context) error {
 		if cliCtx.NArg() != 1 {
 			return fmt.Errorf("required only one configmaps set name")
@@ -131,18 +162,27 @@ var configmapDelCommand = cli.Command{
 		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
 		labelSelector := fmt.Sprintf("app=%s,cmName=%s", appLebel, cmName)
 
-		clientset, err := newClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		concurrency := cliCtx.Int("concurrency")
+		if concurrency <= 0 {
+			return fmt.Errorf("concurrency must be greater than 0")
+		}
+		qps := cliCtx.Float64("qps")
+		if qps <= 0 {
+			return fmt.Errorf("qps must be greater than 0")
+		}
+
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, float32(qps), concurrency)
 		if err != nil {
 			return err
 		}
 
 		// Delete each configmap
-		err = deleteConfigmaps(clientset, labelSelector, namespace)
+		deleted, skipped, err := deleteConfigmaps(clientset, labelSelector, namespace, concurrency)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("Deleted configmap %s in %s namespace\n", cmName, namespace)
+		fmt.Printf("Deleted configmap %s in %s namespace: %d deleted, %d already gone\n", cmName, namespace, deleted, skipped)
 		return nil
 
 	},
@@ -155,7 +195,7 @@ var configmapListCommand = cli.Command{
 	Action: func(cliCtx *cli.Context) error {
 		namespace := cliCtx.GlobalString("namespace")
 		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
-		clientset, err := newClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
 		if err != nil {
 			return err
 		}
@@ -201,64 +241,138 @@ var configmapListCommand = cli.Command{
 	},
 }
 
-func prepareNamespace(kubeCfgPath string, namespace string) error {
-	if namespace == "" {
-		return fmt.Errorf("namespace cannot be empty")
-	}
+var configmapChurnCommand = cli.Command{
+	Name:      "churn",
+	Usage:     "Update data of random configmaps in a set repeatedly until canceled",
+	ArgsUsage: "NAME",
+	Flags: []cli.Flag{
+		cli.Float64Flag{
+			Name:  "rate",
+			Usage: "The number of updates per second",
+			Value: 10,
+		},
+		cli.DurationFlag{
+			Name:  "interval",
+			Usage: "The interval to report the number of updates",
+			Value: 10 * time.Second,
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one configmaps set name")
+		}
+		cmName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(cmName) == 0 {
+			return fmt.Errorf("required non-empty configmaps set name")
+		}
 
-	if namespace == "default" {
-		return nil
-	}
+		rate := cliCtx.Float64("rate")
+		if rate <= 0 {
+			return fmt.Errorf("rate must be greater than 0")
+		}
+		interval := cliCtx.Duration("interval")
+		if interval <= 0 {
+			return fmt.Errorf("interval must be greater than 0")
+		}
 
-	clientset, err := newClientsetWithRateLimiter(kubeCfgPath, 30, 10)
-	if err != nil {
-		return err
-	}
+		namespace := cliCtx.GlobalString("namespace")
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		labelSelector := fmt.Sprintf("app=%s,cmName=%s", appLebel, cmName)
 
-	_, err = clientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: namespace,
-		},
-	}, metav1.CreateOptions{})
-	if err != nil {
-		// If the namespace already exists, ignore the error
-		if errors.IsAlreadyExists(err) {
-			return nil
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, float32(rate), 1)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to create namespace %s: %v", namespace, err)
-	}
-	return nil
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		updates, failures, err := churnConfigmaps(ctx, clientset, labelSelector, namespace, interval)
+		fmt.Printf("Issued %d updates (%d failed) to configmap %s in %s namespace\n", updates, failures, cmName, namespace)
+		return err
+	},
 }
 
-func checkConfigmapParams(size int, groupSize int, total int) error {
-	if size <= 0 {
-		return fmt.Errorf("size must be greater than 0")
+// churnWorkers is the number of workers to update configmaps in parallel.
+const churnWorkers = 10
+
+// churnConfigmaps rewrites data of random configmaps until ctx is canceled.
+// The rate is limited by clientset. It returns the number of updates and
+// failed ones.
+func churnConfigmaps(ctx context.Context, clientset *kubernetes.Clientset, labelSelector string, namespace string, interval time.Duration) (int64, int64, error) {
+	configMaps, err := listConfigmaps(clientset, labelSelector, namespace)
+	if err != nil {
+		return 0, 0, err
 	}
-	if groupSize <= 0 {
-		return fmt.Errorf("group-size must be greater than 0")
+
+	if len(configMaps.Items) == 0 {
+		return 0, 0, fmt.Errorf("no configmaps set found in namespace: %s", namespace)
 	}
-	if total <= 0 {
-		return fmt.Errorf("total amount must be greater than 0")
+
+	var updates, failures int64
+
+	g, ctx := errgroup.WithContext(ctx)
+	for i := 0; i < churnWorkers; i++ {
+		g.Go(func() error {
+			for ctx.Err() == nil {
+				idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(configMaps.Items))))
+				if err != nil {
+					return fmt.Errorf("error generating random number: %w", err)
+				}
+
+				patch, err := newConfigmapChurnPatch(&configMaps.Items[idx.Int64()])
+				if err != nil {
+					return err
+				}
+
+				_, err = clientset.CoreV1().ConfigMaps(namespace).Patch(ctx, configMaps.Items[idx.Int64()].Name,
+					apitypes.MergePatchType, patch, metav1.PatchOptions{})
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					atomic.AddInt64(&failures, 1)
+				}
+				atomic.AddInt64(&updates, 1)
+			}
+			return nil
+		})
 	}
-	if groupSize > total {
-		return fmt.Errorf("group-size must be less than or equal to total")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err := g.Wait()
+			return atomic.LoadInt64(&updates), atomic.LoadInt64(&failures), err
+		case <-ticker.C:
+			fmt.Printf("Issued %d updates (%d failed)\n", atomic.LoadInt64(&updates), atomic.LoadInt64(&failures))
+		}
 	}
-	return nil
 }
 
-func newClientsetWithRateLimiter(kubeCfgPath string, qps float32, burst int) (*kubernetes.Clientset, error) {
-	config, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
-	if err != nil {
-		return nil, err
+// newConfigmapChurnPatch returns merge patch to rewrite data of configmap
+// with random content in the same size.
+func newConfigmapChurnPatch(cm *corev1.ConfigMap) ([]byte, error) {
+	if data, ok := cm.BinaryData["data"]; ok {
+		newData, err := randBytes(len(data))
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(map[string]interface{}{
+			"binaryData": map[string][]byte{"data": newData},
+		})
 	}
 
-	config.QPS = qps
-	config.RateLimiter = flowcontrol.NewTokenBucketRateLimiter(qps, burst)
-	clientset, err := kubernetes.NewForConfig(config)
+	newData, err := randString(max(len(cm.Data["data"]), 1))
 	if err != nil {
 		return nil, err
 	}
-	return clientset, nil
+	return json.Marshal(map[string]interface{}{
+		"data": map[string]string{"data": newData},
+	})
 }
 
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -279,7 +393,56 @@ func randString(n int) (string, error) {
 	return string(b), nil
 }
 
-func createConfigmaps(clientset *kubernetes.Clientset, namespace string, cmName string, size int, groupSize int, total int) error {
+func randBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating random bytes: %w", err)
+	}
+	return b, nil
+}
+
+// entropyBlockSize is the size of block in which the entropy ratio of
+// content is random. It's much smaller than the window of compressors, like
+// snappy and gzip, so that data compresses to roughly the entropy ratio.
+const entropyBlockSize = 1024
+
+// entropyDictSize is the size of dictionary repeated in low-entropy data.
+const entropyDictSize = 64
+
+// randData returns n bytes data. In each block, the entropy ratio of content
+// is generated by gen and the rest repeats a small dictionary generated by
+// gen as well.
+func randData(n int, entropy float64, gen func(int) ([]byte, error)) ([]byte, error) {
+	if entropy >= 1 {
+		return gen(n)
+	}
+
+	dict, err := gen(entropyDictSize)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]byte, 0, n)
+	for len(res) < n {
+		blockSize := min(entropyBlockSize, n-len(res))
+
+		randomSize := int(float64(blockSize) * entropy)
+		if randomSize > 0 {
+			random, err := gen(randomSize)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, random...)
+		}
+
+		for i := randomSize; i < blockSize; i++ {
+			res = append(res, dict[i%entropyDictSize])
+		}
+	}
+	return res, nil
+}
+
+func createConfigmaps(clientset kubernetes.Interface, namespace string, cmName string, size int, groupSize int, total int, binary bool, entropy float64) error {
 	// Generate configmaps in parallel with fixed group size
 	// and random data
 	for i := 0; i < total; i = i + groupSize {
@@ -295,19 +458,33 @@ func createConfigmaps(clientset *kubernetes.Clientset, namespace string, cmName
 				cm.Name = name
 				// Set the labels for the configmap to easily identify in delete or list commands
 				cm.Labels = map[string]string{
-					"ownerID": strconv.Itoa(ownerID),
-					"app":     appLebel,
-					"cmName":  cmName,
-				}
-				data, err := randString(size * 1024)
-				if err != nil {
-					return fmt.Errorf("failed to generate random string for configmap %s: %v", name, err)
+					"ownerID":   strconv.Itoa(ownerID),
+					"groupSize": strconv.Itoa(groupSize),
+					"app":       appLebel,
+					"cmName":    cmName,
 				}
-				cm.Data = map[string]string{
-					"data": data,
+				if binary {
+					data, err := randData(size*1024, entropy, randBytes)
+					if err != nil {
+						return fmt.Errorf("failed to generate random bytes for configmap %s: %v", name, err)
+					}
+					cm.BinaryData = map[string][]byte{
+						"data": data,
+					}
+				} else {
+					data, err := randData(size*1024, entropy, func(n int) ([]byte, error) {
+						str, err := randString(n)
+						return []byte(str), err
+					})
+					if err != nil {
+						return fmt.Errorf("failed to generate random string for configmap %s: %v", name, err)
+					}
+					cm.Data = map[string]string{
+						"data": string(data),
+					}
 				}
 
-				_, err = cli.Create(context.TODO(), cm, metav1.CreateOptions{})
+				_, err := cli.Create(context.TODO(), cm, metav1.CreateOptions{})
 				if err != nil {
 					return fmt.Errorf("failed to create configmap %s: %v", name, err)
 				}
@@ -321,25 +498,39 @@ func createConfigmaps(clientset *kubernetes.Clientset, namespace string, cmName
 	return nil
 }
 
-func deleteConfigmaps(clientset *kubernetes.Clientset, labelSelector string, namespace string) error {
+// deleteProgressInterval is the interval to report progress of deletion.
+const deleteProgressInterval = 5 * time.Second
+
+// deleteConfigmaps deletes configmaps matching labelSelector with concurrency
+// parallel requests. It returns the number of deleted configmaps and the
+// number of skipped ones which are already deleted.
+func deleteConfigmaps(clientset *kubernetes.Clientset, labelSelector string, namespace string, concurrency int) (deleted int64, skipped int64, _ error) {
 	// List all configmaps with the label selector
 	configMaps, err := listConfigmaps(clientset, labelSelector, namespace)
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 
 	if len(configMaps.Items) == 0 {
-		return fmt.Errorf("no configmaps set found in namespace: %s", namespace)
+		return 0, 0, fmt.Errorf("no configmaps set found in namespace: %s", namespace)
 	}
-	// Delete each configmap in parallel with fixed group size
-	n, batch := len(configMaps.Items), 10
-	for i := 0; i < n; i = i + batch {
+
+	lastReport := time.Now()
+	// Delete each configmap in parallel with fixed concurrency
+	n := len(configMaps.Items)
+	for i := 0; i < n; i = i + concurrency {
 		g := new(errgroup.Group)
-		for j := i; j < i+batch && j < n; j++ {
+		for j := i; j < i+concurrency && j < n; j++ {
 			g.Go(func() error {
 				err := clientset.CoreV1().ConfigMaps(namespace).Delete(context.TODO(), configMaps.Items[j].Name, metav1.DeleteOptions{})
-				if err != nil && !errors.IsNotFound(err) {
-					// Ignore not found errors
+				switch {
+				case err == nil:
+					atomic.AddInt64(&deleted, 1)
+				case errors.IsNotFound(err):
+					// NOTE: It might be deleted by others, like
+					// namespace deletion.
+					atomic.AddInt64(&skipped, 1)
+				default:
 					return fmt.Errorf("failed to delete configmap %s: %v", configMaps.Items[j].Name, err)
 				}
 				return nil
@@ -347,13 +538,18 @@ func deleteConfigmaps(clientset *kubernetes.Clientset, labelSelector string, nam
 		}
 
 		if err := g.Wait(); err != nil {
-			return err
+			return deleted, skipped, err
+		}
+
+		if time.Since(lastReport) >= deleteProgressInterval {
+			fmt.Printf("Deleted %d of %d configmaps\n", deleted+skipped, n)
+			lastReport = time.Now()
 		}
 	}
-	return nil
+	return deleted, skipped, nil
 }
 
-func listConfigmaps(clientset *kubernetes.Clientset, labelSelector string, namespace string) (*corev1.ConfigMapList, error) {
+func listConfigmaps(clientset kubernetes.Interface, labelSelector string, namespace string) (*corev1.ConfigMapList, error) {
 	configMaps, err := clientset.CoreV1().ConfigMaps(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list configmaps: %v", err)
@@ -362,14 +558,18 @@ func listConfigmaps(clientset *kubernetes.Clientset, labelSelector string, names
 	return configMaps, nil
 }
 
-// Get info of configmaps by name
-func listConfigmapsByName(clientset *kubernetes.Clientset, labelSelector string, namespace string, cmMap map[string][]int) error {
+// Get info of configmaps by name. The value of cmMap is size, group-size
+// and total in int list.
+func listConfigmapsByName(clientset kubernetes.Interface, labelSelector string, namespace string, cmMap map[string][]int) error {
 	configMaps, err := listConfigmaps(clientset, labelSelector, namespace)
 
 	if err != nil {
 		return err
 	}
 
+	// minOwnerIDs is the smallest positive ownerID of each set, which is
+	// the group size of the set created without groupSize label.
+	minOwnerIDs := make(map[string]int)
 	for _, cm := range configMaps.Items {
 		name, ok := cm.Labels["cmName"]
 		if !ok {
@@ -383,16 +583,22 @@ func listConfigmapsByName(clientset *kubernetes.Clientset, labelSelector string,
 			cmMap[name] = []int{0, 0, 0}
 
 			// Get the size of the configmap
-			_, ok = cm.Data["data"]
-			if ok {
-				cmMap[name][0] = len(cm.Data["data"])
+			if data, ok := cm.Data["data"]; ok {
+				cmMap[name][0] = len(data)
+			} else if data, ok := cm.BinaryData["data"]; ok {
+				cmMap[name][0] = len(data)
 			}
 		}
 
 		// Increment the total count of configmaps
 		cmMap[name][2]++
 
-		if cmMap[name][1] != 0 {
+		if groupSize, ok := cm.Labels["groupSize"]; ok {
+			groupSizeInt, err := strconv.Atoi(groupSize)
+			if err != nil {
+				return fmt.Errorf("failed to convert groupSize %s to int: %v", groupSize, err)
+			}
+			cmMap[name][1] = groupSizeInt
 			continue
 		}
 
@@ -401,15 +607,27 @@ func listConfigmapsByName(clientset *kubernetes.Clientset, labelSelector string,
 			return fmt.Errorf("failed to find the ownerID of configmap %s", name)
 		}
 
-		if ownerIDInt, err := strconv.Atoi(ownerID); err == nil {
-			// Use the ownerID to get the group size
-			if ownerIDInt > cmMap[name][1] {
-				cmMap[name][1] = ownerIDInt
-			}
-		} else {
+		// NOTE: The ownerID is the index of the first configmap in the
+		// group, so the smallest positive one is the group size.
+		ownerIDInt, err := strconv.Atoi(ownerID)
+		if err != nil {
 			return fmt.Errorf("failed to convert ownerID %s to int: %v", ownerID, err)
 		}
+		if ownerIDInt > 0 && (minOwnerIDs[name] == 0 || ownerIDInt < minOwnerIDs[name]) {
+			minOwnerIDs[name] = ownerIDInt
+		}
+	}
+
+	for name, value := range cmMap {
+		if value[1] != 0 {
+			continue
+		}
 
+		// NOTE: There is only one group if there is no positive ownerID.
+		value[1] = value[2]
+		if minOwnerID, ok := minOwnerIDs[name]; ok {
+			value[1] = minOwnerID
+		}
 	}
 	return nil
 }
@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package configmaps
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListConfigmapsByName(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	require.NoError(t, createConfigmaps(clientset, "default", "large", 1, 100, 1000, false, 1))
+	require.NoError(t, createConfigmaps(clientset, "default", "small", 2, 3, 10, true, 0.5))
+	require.NoError(t, createConfigmaps(clientset, "default", "single", 1, 5, 5, false, 1))
+
+	cmMap := make(map[string][]int)
+	require.NoError(t, listConfigmapsByName(clientset, "app=runkperf", "default", cmMap))
+	assert.Equal(t, map[string][]int{
+		"large":  {1024, 100, 1000},
+		"small":  {2048, 3, 10},
+		"single": {1024, 5, 5},
+	}, cmMap)
+
+	cmMap = make(map[string][]int)
+	require.NoError(t, listConfigmapsByName(clientset, "app=runkperf, cmName in (small)", "default", cmMap))
+	assert.Equal(t, map[string][]int{"small": {2048, 3, 10}}, cmMap)
+}
+
+func TestListConfigmapsByNameWithoutGroupSizeLabel(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	// NOTE: The configmaps created before groupSize label only have
+	// ownerID label, which is the index of the first one in the group.
+	for i := 0; i < 1000; i++ {
+		_, err := clientset.CoreV1().ConfigMaps("default").Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("runkperf-cm-legacy-%d", i),
+				Labels: map[string]string{
+					"ownerID": fmt.Sprintf("%d", i/100*100),
+					"app":     appLebel,
+					"cmName":  "legacy",
+				},
+			},
+			Data: map[string]string{"data": string(make([]byte, 2048))},
+		}, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	cmMap := make(map[string][]int)
+	require.NoError(t, listConfigmapsByName(clientset, "app=runkperf", "default", cmMap))
+	assert.Equal(t, map[string][]int{"legacy": {2048, 100, 1000}}, cmMap)
+}
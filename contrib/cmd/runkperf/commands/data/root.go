@@ -4,8 +4,10 @@
 package data
 
 import (
+	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/common"
 	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/configmaps"
 	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/daemonsets"
+	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/secrets"
 
 	"github.com/urfave/cli"
 )
@@ -13,8 +15,22 @@ import (
 var Command = cli.Command{
 	Name:  "data",
 	Usage: "Create data for runkperf",
-	Subcommands: []cli.Command{
+	Subcommands: withAPIServerConnectivityCheck(
 		configmaps.Command,
 		daemonsets.Command,
-	},
+		secrets.Command,
+	),
+}
+
+// withAPIServerConnectivityCheck sets common.CheckAPIServerConnectivity as
+// Before of each resource command so that all the data subcommands fail fast
+// if kube-apiserver is unreachable.
+//
+// NOTE: It can't be Before of data command because the kubeconfig flag
+// belongs to resource command, which isn't parsed yet at that time.
+func withAPIServerConnectivityCheck(cmds ...cli.Command) []cli.Command {
+	for i := range cmds {
+		cmds[i].Before = common.CheckAPIServerConnectivity
+	}
+	return cmds
 }
@@ -0,0 +1,325 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Azure/kperf/cmd/kperf/commands/utils"
+	"github.com/Azure/kperf/contrib/cmd/runkperf/commands/data/common"
+
+	"github.com/urfave/cli"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var appLabel = "runkperf"
+
+var Command = cli.Command{
+	Name:  "secret",
+	Usage: "Manage secrets",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "Path to the kubeconfig file",
+			Value: utils.DefaultKubeConfigPath,
+		},
+		cli.StringFlag{
+			Name:  "namespace",
+			Usage: "Namespace to use with commands. If the namespace does not exist, it will be created.",
+			Value: "default",
+		},
+	},
+	Subcommands: []cli.Command{
+		secretAddCommand,
+		secretDelCommand,
+		secretListCommand,
+	},
+}
+
+var secretAddCommand = cli.Command{
+	Name:      "add",
+	Usage:     "Add secret set",
+	ArgsUsage: "NAME of the secrets set",
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "size",
+			Usage: "The size of each secret's base64-encoded data (Unit: KiB)",
+			Value: 100,
+		},
+		cli.IntFlag{
+			Name:  "group-size",
+			Usage: "The size of each secret group",
+			Value: 10,
+		},
+		cli.IntFlag{
+			Name:  "total",
+			Usage: "Total amount of secrets",
+			Value: 10,
+		},
+	},
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one argument as secrets set name: %v", cliCtx.Args())
+		}
+		secretName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(secretName) == 0 {
+			return fmt.Errorf("required non-empty secret set name")
+		}
+
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		size := cliCtx.Int("size")
+		groupSize := cliCtx.Int("group-size")
+		total := cliCtx.Int("total")
+
+		err := common.CheckSetParams(size, groupSize, total)
+		if err != nil {
+			return err
+		}
+
+		namespace := cliCtx.GlobalString("namespace")
+		err = common.PrepareNamespace(kubeCfgPath, namespace)
+		if err != nil {
+			return err
+		}
+
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		if err != nil {
+			return err
+		}
+
+		err = createSecrets(clientset, namespace, secretName, size, groupSize, total)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created secret %s with size %d KiB, group-size %d, total %d\n", secretName, size, groupSize, total)
+		return nil
+	},
+}
+
+var secretDelCommand = cli.Command{
+	Name:      "delete",
+	ShortName: "del",
+	ArgsUsage: "NAME",
+	Usage:     "Delete a secrets set",
+	Action: func(cliCtx *cli.Context) error {
+		if cliCtx.NArg() != 1 {
+			return fmt.Errorf("required only one secrets set name")
+		}
+		secretName := strings.TrimSpace(cliCtx.Args().Get(0))
+		if len(secretName) == 0 {
+			return fmt.Errorf("required non-empty secrets set name")
+		}
+
+		namespace := cliCtx.GlobalString("namespace")
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		labelSelector := fmt.Sprintf("app=%s,secretName=%s", appLabel, secretName)
+
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		if err != nil {
+			return err
+		}
+
+		err = deleteSecrets(clientset, labelSelector, namespace)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Deleted secret %s in %s namespace\n", secretName, namespace)
+		return nil
+	},
+}
+
+var secretListCommand = cli.Command{
+	Name:      "list",
+	Usage:     "List generated secrets",
+	ArgsUsage: "NAME",
+	Action: func(cliCtx *cli.Context) error {
+		namespace := cliCtx.GlobalString("namespace")
+		kubeCfgPath := cliCtx.GlobalString("kubeconfig")
+		clientset, err := common.NewClientsetWithRateLimiter(kubeCfgPath, 30, 10)
+		if err != nil {
+			return err
+		}
+
+		const (
+			minWidth = 1
+			tabWidth = 12
+			padding  = 3
+			padChar  = ' '
+			flags    = 0
+		)
+		tw := tabwriter.NewWriter(os.Stdout, minWidth, tabWidth, padding, padChar, flags)
+		fmt.Fprintln(tw, "NAME\tSIZE (KiB)\tGROUP_SIZE\tTOTAL\t")
+
+		// If no args are provided, list all secrets with the label app=runkperf.
+		// Otherwise, list the secrets with the label secretName in (args).
+		var labelSelector string
+		if cliCtx.NArg() == 0 {
+			labelSelector = fmt.Sprintf("app=%s", appLabel)
+		} else {
+			namesStr := strings.Join(cliCtx.Args(), ",")
+			labelSelector = fmt.Sprintf("app=%s, secretName in (%s)", appLabel, namesStr)
+		}
+
+		sets, err := listSecretSets(clientset, labelSelector, namespace)
+		if err != nil {
+			return err
+		}
+
+		for _, set := range sets {
+			fmt.Fprintf(tw, "%s\t%d\t%d\t%d\n",
+				set.name,
+				set.size,
+				set.groupSize,
+				set.total,
+			)
+		}
+		return tw.Flush()
+	},
+}
+
+// randBytes returns random bytes whose base64-encoded size is sizeInKiB.
+func randBytes(sizeInKiB int) ([]byte, error) {
+	// NOTE: Every 3 bytes are encoded into 4 characters.
+	b := make([]byte, sizeInKiB*1024/4*3)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating random bytes: %w", err)
+	}
+	return b, nil
+}
+
+func createSecrets(clientset kubernetes.Interface, namespace string, secretName string, size int, groupSize int, total int) error {
+	// Generate secrets in parallel with fixed group size and random data
+	for i := 0; i < total; i = i + groupSize {
+		ownerID := i
+		g := new(errgroup.Group)
+		for j := i; j < i+groupSize && j < total; j++ {
+			g.Go(func() error {
+				cli := clientset.CoreV1().Secrets(namespace)
+
+				name := fmt.Sprintf("%s-secret-%s-%d", appLabel, secretName, j)
+
+				secret := &corev1.Secret{}
+				secret.Name = name
+				// Set the labels for the secret to easily identify in delete or list commands
+				secret.Labels = map[string]string{
+					"ownerID":    strconv.Itoa(ownerID),
+					"groupSize":  strconv.Itoa(groupSize),
+					"app":        appLabel,
+					"secretName": secretName,
+				}
+				data, err := randBytes(size)
+				if err != nil {
+					return fmt.Errorf("failed to generate random data for secret %s: %v", name, err)
+				}
+				secret.Type = corev1.SecretTypeOpaque
+				secret.Data = map[string][]byte{
+					"data": data,
+				}
+
+				_, err = cli.Create(context.TODO(), secret, metav1.CreateOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to create secret %s: %v", name, err)
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteSecrets(clientset *kubernetes.Clientset, labelSelector string, namespace string) error {
+	secrets, err := listSecrets(clientset, labelSelector, namespace)
+	if err != nil {
+		return err
+	}
+
+	if len(secrets.Items) == 0 {
+		return fmt.Errorf("no secrets set found in namespace: %s", namespace)
+	}
+	// Delete each secret in parallel with fixed group size
+	n, batch := len(secrets.Items), 10
+	for i := 0; i < n; i = i + batch {
+		g := new(errgroup.Group)
+		for j := i; j < i+batch && j < n; j++ {
+			g.Go(func() error {
+				err := clientset.CoreV1().Secrets(namespace).Delete(context.TODO(), secrets.Items[j].Name, metav1.DeleteOptions{})
+				if err != nil && !errors.IsNotFound(err) {
+					// Ignore not found errors
+					return fmt.Errorf("failed to delete secret %s: %v", secrets.Items[j].Name, err)
+				}
+				return nil
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listSecrets(clientset kubernetes.Interface, labelSelector string, namespace string) (*corev1.SecretList, error) {
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %v", err)
+	}
+	return secrets, nil
+}
+
+// secretSet is the summary of secrets created by the same add command.
+type secretSet struct {
+	name      string
+	size      int
+	groupSize int
+	total     int
+}
+
+// listSecretSets returns the summary of secrets sets.
+func listSecretSets(clientset kubernetes.Interface, labelSelector string, namespace string) ([]*secretSet, error) {
+	secrets, err := listSecrets(clientset, labelSelector, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*secretSet{}
+	sets := map[string]*secretSet{}
+	for _, secret := range secrets.Items {
+		name, ok := secret.Labels["secretName"]
+		if !ok {
+			return nil, fmt.Errorf("failed to find the secretName of secret %s", secret.Name)
+		}
+
+		set, ok := sets[name]
+		if !ok {
+			// NOTE: The size is the base64-encoded size of data.
+			set = &secretSet{name: name, size: (len(secret.Data["data"]) + 2) / 3 * 4 / 1024}
+			sets[name] = set
+			res = append(res, set)
+		}
+		set.total++
+
+		groupSize, err := strconv.Atoi(secret.Labels["groupSize"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert groupSize of secret %s to int: %v", secret.Name, err)
+		}
+		set.groupSize = groupSize
+	}
+	return res, nil
+}
@@ -0,0 +1,33 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestListSecretSets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	require.NoError(t, createSecrets(clientset, "default", "large", 1, 100, 1000))
+	require.NoError(t, createSecrets(clientset, "default", "uneven", 2, 3, 10))
+	require.NoError(t, createSecrets(clientset, "default", "single", 1, 5, 5))
+
+	sets, err := listSecretSets(clientset, "app=runkperf", "default")
+	require.NoError(t, err)
+
+	res := map[string]secretSet{}
+	for _, set := range sets {
+		res[set.name] = *set
+	}
+	assert.Equal(t, map[string]secretSet{
+		"large":  {name: "large", size: 1, groupSize: 100, total: 1000},
+		"uneven": {name: "uneven", size: 2, groupSize: 3, total: 10},
+		"single": {name: "single", size: 1, groupSize: 5, total: 5},
+	}, res)
+}
@@ -160,7 +160,7 @@ var Command = cli.Command{
 				utils.WithJobIntervalOpt(5*time.Second))
 		}()
 
-		_, derr := utils.DeployRunnerGroup(ctx,
+		_, _, derr := utils.DeployRunnerGroup(ctx,
 			kubeCfgPath,
 			cliCtx.String("runner-image"),
 			rgCfgFile,
@@ -0,0 +1,49 @@
+{{- $pattern := .Values.namePattern }}
+{{- $namespace := .Values.namespace }}
+{{- $nodepool := .Values.nodepool }}
+{{- $paddingBytes := int .Values.paddingBytes }}
+{{- range $index := (untilStep 0 (int .Values.count) 1) }}
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: {{ $pattern }}-{{ $index }}
+  namespace: {{ $namespace }}
+  labels:
+    app: {{ $pattern }}
+spec:
+  selector:
+    matchLabels:
+      app: {{ $pattern }}
+      index: "{{ $index }}"
+  template:
+    metadata:
+      labels:
+        app: {{ $pattern }}
+        index: "{{ $index }}"
+      annotations:
+        data: "{{ randAlphaNum $paddingBytes | nospace }}"
+    spec:
+      affinity:
+        nodeAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            nodeSelectorTerms:
+            - matchExpressions:
+              - key: type
+                operator: In
+                values:
+                - kperf-virtualnodes
+{{- if $nodepool }}
+              - key: alpha.kperf.io/nodepool
+                operator: In
+                values:
+                - {{ $nodepool }}
+{{- end }}
+      tolerations:
+      - key: "kperf.io/nodepool"
+        operator: "Exists"
+        effect: "NoSchedule"
+      containers:
+      - name: fake-container
+        image: fake-image
+{{- end }}
@@ -4,6 +4,10 @@
 {{- $parallelism := int .Values.parallelism }}
 {{- $namespace := .Values.namespace }}
 {{- $ttlSecondsAfterFinished := int .Values.ttlSecondsAfterFinished }}
+{{- $podSpec := .Values.podSpec }}
+{{- $annotations := int $podSpec.annotations }}
+{{- $annotationBytes := int $podSpec.annotationBytes }}
+{{- $labels := int $podSpec.labels }}
 {{- range $index := (untilStep 0 (int .Values.jobCount) 1) }}
 ---
 apiVersion: batch/v1
@@ -27,6 +31,19 @@ spec:
         job: {{ $pattern }}-{{ $index }}
         job-group: {{ $pattern }}
         job-index: "{{ $index }}"
+{{- /* NOTE: The padding is derived from name so that reruns are comparable. */}}
+{{- range $i := until $labels }}
+        kperf.io/padding-{{ $i }}: {{ sha256sum (printf "%s-%d-label-%d" $pattern $index $i) | trunc 63 | quote }}
+{{- end }}
+{{- if gt $annotations 0 }}
+      annotations:
+{{- range $i := until $annotations }}
+{{- $size := int (div $annotationBytes $annotations) }}
+{{- if eq $i 0 }}{{ $size = int (add $size (mod $annotationBytes $annotations)) }}{{ end }}
+{{- $seed := sha256sum (printf "%s-%d-annotation-%d" $pattern $index $i) }}
+        kperf.io/padding-{{ $i }}: {{ repeat (int (add (div $size 64) 1)) $seed | trunc $size | quote }}
+{{- end }}
+{{- end }}
     spec:
       restartPolicy: Never
       affinity:
@@ -44,5 +61,12 @@ spec:
         effect: "NoSchedule"
       containers:
       - name: fake-container
-        image: fake-image
+        image: {{ $podSpec.image | quote }}
+{{- with $podSpec.command }}
+        command: {{ toJson . }}
+{{- end }}
+{{- with $podSpec.requests }}
+        resources:
+          requests: {{ toJson . }}
+{{- end }}
 {{- end }}
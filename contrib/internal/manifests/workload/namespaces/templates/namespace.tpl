@@ -0,0 +1,7 @@
+{{- $name:= .Values.namePattern }}
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: {{ $name }}
+  labels:
+    app: fake-namespace
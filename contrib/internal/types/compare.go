@@ -0,0 +1,46 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package types
+
+// BenchmarkComparison represents the difference between baseline and
+// candidate benchmark reports.
+type BenchmarkComparison struct {
+	// Baseline is the path to baseline report.
+	Baseline string `json:"baseline" yaml:"baseline"`
+	// Candidate is the path to candidate report.
+	Candidate string `json:"candidate" yaml:"candidate"`
+	// ThresholdPercent is the allowed degradation in percent before
+	// the delta is flagged as regression.
+	ThresholdPercent float64 `json:"thresholdPercent" yaml:"thresholdPercent"`
+	// PercentileLatencies compares the latency in seconds for each
+	// percentile in both reports.
+	PercentileLatencies []PercentileLatencyDelta `json:"percentileLatencies,omitempty" yaml:"percentileLatencies,omitempty"`
+	// QPS compares the number of completed requests per second.
+	QPS MetricDelta `json:"qps" yaml:"qps"`
+	// ErrorRate compares the ratio of failed requests to completed
+	// requests. Its DeltaPercent is the difference in percentage points.
+	ErrorRate MetricDelta `json:"errorRate" yaml:"errorRate"`
+	// Regressions lists the names of regressed metrics, like P99.
+	Regressions []string `json:"regressions,omitempty" yaml:"regressions,omitempty"`
+}
+
+// MetricDelta is the difference of one metric between two reports.
+type MetricDelta struct {
+	// Baseline is the value in baseline report.
+	Baseline float64 `json:"baseline" yaml:"baseline"`
+	// Candidate is the value in candidate report.
+	Candidate float64 `json:"candidate" yaml:"candidate"`
+	// DeltaPercent is the change from baseline to candidate in percent.
+	DeltaPercent float64 `json:"deltaPercent" yaml:"deltaPercent"`
+	// Regressed is true if the change is worse than threshold.
+	Regressed bool `json:"regressed" yaml:"regressed"`
+}
+
+// PercentileLatencyDelta is the latency difference of one percentile.
+type PercentileLatencyDelta struct {
+	// Percentile is in [0, 1], like 0.99.
+	Percentile float64 `json:"percentile" yaml:"percentile"`
+
+	MetricDelta `json:",inline" yaml:",inline"`
+}
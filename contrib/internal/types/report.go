@@ -18,3 +18,18 @@ type BenchmarkReport struct {
 	// FIXME(weifu): Use struct after finialized.
 	Info map[string]interface{} `json:"info" yaml:"info"`
 }
+
+// LoadInfo returns the effective load settings of spec in a uniform format,
+// so that reports from different benchmark cases can be compared.
+func LoadInfo(spec apitypes.RunnerGroupSpec) map[string]interface{} {
+	info := map[string]interface{}{
+		"runners": spec.Count,
+	}
+	if spec.Profile != nil {
+		info["rate"] = spec.Profile.Spec.Rate
+		info["client"] = spec.Profile.Spec.Client
+		info["total"] = spec.Profile.Spec.Total
+		info["disableKeepAlives"] = spec.Profile.Spec.DisableKeepAlives
+	}
+	return info
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// apiserverRequestTotal is the counter of requests handled by
+	// kube-apiserver, broken down by verb, resource and code.
+	apiserverRequestTotal = "apiserver_request_total"
+	// processCPUSecondsTotal is the total user and system CPU time.
+	processCPUSecondsTotal = "process_cpu_seconds_total"
+	// goMemstatsAllocBytes is the gauge of allocated and still in-use
+	// heap bytes.
+	goMemstatsAllocBytes = "go_memstats_alloc_bytes"
+)
+
+// APIServerLoadStats is a snapshot of load metrics for one kube-apiserver.
+type APIServerLoadStats struct {
+	// Requests is the total number of handled requests.
+	Requests float64 `json:"requests"`
+	// CPUSeconds is the total user and system CPU time in seconds.
+	CPUSeconds float64 `json:"cpuSeconds"`
+	// AllocBytes is the in-use heap bytes.
+	AllocBytes float64 `json:"allocBytes"`
+}
+
+// FetchAPIServerLoadStats fetches load metrics from all the kube-apiservers.
+// The result is keyed by kube-apiserver's IP address.
+func FetchAPIServerLoadStats(ctx context.Context, kubeCfgPath string) (map[string]*APIServerLoadStats, error) {
+	metricsByIP, err := FetchAPIServerMetrics(ctx, kubeCfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]*APIServerLoadStats, len(metricsByIP))
+	for ip, data := range metricsByIP {
+		stats, err := ParseAPIServerLoadMetrics(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse load metrics from %s: %w", ip, err)
+		}
+		res[ip] = stats
+	}
+	return res, nil
+}
+
+// ParseAPIServerLoadMetrics parses load metrics from kube-apiserver /metrics
+// data. The apiserver_request_total is summed up across all the labels.
+func ParseAPIServerLoadMetrics(data []byte) (*APIServerLoadStats, error) {
+	res := &APIServerLoadStats{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, apiserverRequestTotal) &&
+			!strings.HasPrefix(line, processCPUSecondsTotal) &&
+			!strings.HasPrefix(line, goMemstatsAllocBytes) {
+			continue
+		}
+
+		name, _, value, err := ParseMetricSample(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case apiserverRequestTotal:
+			res.Requests += value
+		case processCPUSecondsTotal:
+			res.CPUSeconds = value
+		case goMemstatsAllocBytes:
+			res.AllocBytes = value
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+var (
+	// storageDBTotalSizeMetrics are the gauges of etcd database size
+	// observed by kube-apiserver. The etcd_db_total_size_in_bytes is the
+	// deprecated name before v1.28.
+	storageDBTotalSizeMetrics = []string{
+		"apiserver_storage_db_total_size_in_bytes",
+		"etcd_db_total_size_in_bytes",
+	}
+)
+
+// FetchAPIServerStorageDBSize fetches etcd database size from all the
+// kube-apiservers and returns the largest one.
+func FetchAPIServerStorageDBSize(ctx context.Context, kubeCfgPath string) (float64, error) {
+	metricsByIP, err := FetchAPIServerMetrics(ctx, kubeCfgPath)
+	if err != nil {
+		return 0, err
+	}
+
+	res := float64(0)
+	for ip, data := range metricsByIP {
+		size, err := ParseStorageDBSize(data)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse storage db size from %s: %w", ip, err)
+		}
+		res = max(res, size)
+	}
+	return res, nil
+}
+
+// ParseStorageDBSize parses etcd database size from kube-apiserver /metrics
+// data. It returns the largest one if there are several etcd endpoints.
+func ParseStorageDBSize(data []byte) (float64, error) {
+	res := float64(0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "apiserver_storage_db_") && !strings.HasPrefix(line, "etcd_db_") {
+			continue
+		}
+
+		name, _, value, err := ParseMetricSample(line)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, n := range storageDBTotalSizeMetrics {
+			if name == n {
+				res = max(res, value)
+				break
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// apfCurrentExecutingRequests is the gauge of requests in initial
+	// (for a WATCH) or any (for a non-WATCH) execution stage in APF.
+	apfCurrentExecutingRequests = "apiserver_flowcontrol_current_executing_requests"
+	// apfRequestWaitDurationSum is the sum of time spent in APF queues.
+	apfRequestWaitDurationSum = "apiserver_flowcontrol_request_wait_duration_seconds_sum"
+	// apfRequestWaitDurationCount is the number of requests which have
+	// been released from APF queues.
+	apfRequestWaitDurationCount = "apiserver_flowcontrol_request_wait_duration_seconds_count"
+)
+
+// FlowControlPriorityLevelStats is a snapshot of APF metrics for one
+// priority level.
+type FlowControlPriorityLevelStats struct {
+	// ExecutingRequests is the number of requests in execution stage.
+	ExecutingRequests float64 `json:"executingRequests"`
+	// WaitSeconds is the total time spent waiting in queues.
+	WaitSeconds float64 `json:"waitSeconds"`
+	// WaitCount is the number of requests released from queues.
+	WaitCount float64 `json:"waitCount"`
+	// ExecutingRequestsByFlowSchema breaks ExecutingRequests down by
+	// flow schema.
+	ExecutingRequestsByFlowSchema map[string]float64 `json:"executingRequestsByFlowSchema,omitempty"`
+}
+
+// FetchAPIServerFlowControlStats fetches APF metrics from all the
+// kube-apiservers and sums them up by priority level.
+func FetchAPIServerFlowControlStats(ctx context.Context, kubeCfgPath string) (map[string]*FlowControlPriorityLevelStats, error) {
+	metricsByIP, err := FetchAPIServerMetrics(ctx, kubeCfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*FlowControlPriorityLevelStats{}
+	for ip, data := range metricsByIP {
+		stats, err := ParseFlowControlMetrics(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse flowcontrol metrics from %s: %w", ip, err)
+		}
+
+		for pl, s := range stats {
+			total, ok := res[pl]
+			if !ok {
+				total = &FlowControlPriorityLevelStats{
+					ExecutingRequestsByFlowSchema: map[string]float64{},
+				}
+				res[pl] = total
+			}
+
+			total.ExecutingRequests += s.ExecutingRequests
+			total.WaitSeconds += s.WaitSeconds
+			total.WaitCount += s.WaitCount
+			for fs, v := range s.ExecutingRequestsByFlowSchema {
+				total.ExecutingRequestsByFlowSchema[fs] += v
+			}
+		}
+	}
+	return res, nil
+}
+
+// ParseFlowControlMetrics parses APF metrics from kube-apiserver /metrics
+// data and groups them by priority level.
+func ParseFlowControlMetrics(data []byte) (map[string]*FlowControlPriorityLevelStats, error) {
+	res := map[string]*FlowControlPriorityLevelStats{}
+
+	getOrInit := func(pl string) *FlowControlPriorityLevelStats {
+		s, ok := res[pl]
+		if !ok {
+			s = &FlowControlPriorityLevelStats{
+				ExecutingRequestsByFlowSchema: map[string]float64{},
+			}
+			res[pl] = s
+		}
+		return s
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "apiserver_flowcontrol_") {
+			continue
+		}
+
+		name, labels, value, err := ParseMetricSample(line)
+		if err != nil {
+			return nil, err
+		}
+
+		pl, ok := labels["priority_level"]
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case apfCurrentExecutingRequests:
+			s := getOrInit(pl)
+			s.ExecutingRequests += value
+			if fs := labels["flow_schema"]; fs != "" {
+				s.ExecutingRequestsByFlowSchema[fs] += value
+			}
+		case apfRequestWaitDurationSum:
+			getOrInit(pl).WaitSeconds += value
+		case apfRequestWaitDurationCount:
+			getOrInit(pl).WaitCount += value
+		}
+	}
+	return res, nil
+}
+
+// ParseMetricSample parses one sample line in prometheus text format, like
+//
+//	name{key="value",...} 1.0
+func ParseMetricSample(line string) (name string, labels map[string]string, value float64, _ error) {
+	line = strings.TrimSpace(line)
+	labels = map[string]string{}
+
+	rest := line
+	if idx := strings.IndexByte(line, '{'); idx >= 0 {
+		end := strings.LastIndexByte(line, '}')
+		if end < idx {
+			return "", nil, 0, fmt.Errorf("invalid metric sample: %s", line)
+		}
+
+		name = line[:idx]
+		for _, pair := range splitLabelPairs(line[idx+1 : end]) {
+			k, v, ok := strings.Cut(pair, "=")
+			if !ok {
+				return "", nil, 0, fmt.Errorf("invalid label pair %s in metric sample: %s", pair, line)
+			}
+			labels[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+		rest = line[end+1:]
+	} else {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			return "", nil, 0, fmt.Errorf("invalid metric sample: %s", line)
+		}
+		name = fields[0]
+		rest = strings.TrimPrefix(line, name)
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("missing value in metric sample: %s", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("failed to parse value in metric sample %s: %w", line, err)
+	}
+	return name, labels, value, nil
+}
+
+// splitLabelPairs splits labels by comma which is not quoted.
+func splitLabelPairs(s string) []string {
+	res := []string{}
+
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				if pair := strings.TrimSpace(s[start:i]); pair != "" {
+					res = append(res, pair)
+				}
+				start = i + 1
+			}
+		}
+	}
+	if pair := strings.TrimSpace(s[start:]); pair != "" {
+		res = append(res, pair)
+	}
+	return res
+}
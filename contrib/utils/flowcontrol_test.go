@@ -0,0 +1,131 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricSample(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		line   string
+		metric string
+		labels map[string]string
+		value  float64
+		hasErr bool
+	}{
+		{
+			name:   "without labels",
+			line:   "process_open_fds 12",
+			metric: "process_open_fds",
+			labels: map[string]string{},
+			value:  12,
+		},
+		{
+			name:   "empty labels",
+			line:   "up{} 1",
+			metric: "up",
+			labels: map[string]string{},
+			value:  1,
+		},
+		{
+			name:   "labels with timestamp",
+			line:   `apiserver_flowcontrol_current_executing_requests{flow_schema="kperf",priority_level="workload-low"} 3 1700000000000`,
+			metric: "apiserver_flowcontrol_current_executing_requests",
+			labels: map[string]string{"flow_schema": "kperf", "priority_level": "workload-low"},
+			value:  3,
+		},
+		{
+			name:   "quoted comma and trailing comma",
+			line:   `  m{a="x,y", b = "z",} 2.5e-3  `,
+			metric: "m",
+			labels: map[string]string{"a": "x,y", "b": "z"},
+			value:  0.0025,
+		},
+		{
+			name:   "infinite value",
+			line:   `m_bucket{le="+Inf"} +Inf`,
+			metric: "m_bucket",
+			labels: map[string]string{"le": "+Inf"},
+			value:  math.Inf(1),
+		},
+		{
+			name:   "empty line",
+			line:   "   ",
+			hasErr: true,
+		},
+		{
+			name:   "unclosed labels",
+			line:   `m{a="b" 1`,
+			hasErr: true,
+		},
+		{
+			name:   "label without value",
+			line:   `m{a} 1`,
+			hasErr: true,
+		},
+		{
+			name:   "missing value",
+			line:   `m{a="b"}`,
+			hasErr: true,
+		},
+		{
+			name:   "invalid value",
+			line:   "m one",
+			hasErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metric, labels, value, err := ParseMetricSample(tc.line)
+			if tc.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.metric, metric)
+			assert.Equal(t, tc.labels, labels)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestParseFlowControlMetrics(t *testing.T) {
+	data := []byte(`# HELP apiserver_flowcontrol_current_executing_requests [BETA] Number of requests in initial (for a WATCH) or any (for a non-WATCH) execution stage in the API Priority and Fairness subsystem
+# TYPE apiserver_flowcontrol_current_executing_requests gauge
+apiserver_flowcontrol_current_executing_requests{flow_schema="kperf",priority_level="workload-low"} 3
+apiserver_flowcontrol_current_executing_requests{flow_schema="service-accounts",priority_level="workload-low"} 1
+apiserver_flowcontrol_current_executing_requests{flow_schema="exempt",priority_level="exempt"} 2
+apiserver_flowcontrol_request_wait_duration_seconds_sum{execute="true",flow_schema="kperf",priority_level="workload-low"} 1.5
+apiserver_flowcontrol_request_wait_duration_seconds_count{execute="true",flow_schema="kperf",priority_level="workload-low"} 10
+apiserver_flowcontrol_request_wait_duration_seconds_count{execute="false",flow_schema="kperf",priority_level="workload-low"} 2
+apiserver_flowcontrol_dispatched_requests_total{flow_schema="kperf"} 100
+apiserver_request_total{verb="GET"} 1000
+`)
+
+	stats, err := ParseFlowControlMetrics(data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*FlowControlPriorityLevelStats{
+		"workload-low": {
+			ExecutingRequests: 4,
+			WaitSeconds:       1.5,
+			WaitCount:         12,
+			ExecutingRequestsByFlowSchema: map[string]float64{
+				"kperf":            3,
+				"service-accounts": 1,
+			},
+		},
+		"exempt": {
+			ExecutingRequests:             2,
+			ExecutingRequestsByFlowSchema: map[string]float64{"exempt": 2},
+		},
+	}, stats)
+
+	_, err = ParseFlowControlMetrics([]byte(`apiserver_flowcontrol_current_executing_requests{priority_level="exempt"}`))
+	assert.Error(t, err)
+}
@@ -6,9 +6,13 @@ package utils
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 )
 
 // KubectlRunner is the wrapper of exec.Command to execute kubectl command.
@@ -52,6 +56,49 @@ func (kr *KubectlRunner) FQDN(ctx context.Context, timeout time.Duration) (strin
 	return strings.ToLower(host), nil
 }
 
+// Metrics returns the metrics for a specific kube-apiserver.
+//
+// It requests /metrics endpoint directly with the kubeconfig's credential
+// and dials ip instead of resolving fqdn. It falls back to kubectl in mount
+// namespace if that fails, which is only supported on Linux.
+func (kr *KubectlRunner) Metrics(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
+	data, err := kr.metricsByDirectDial(ctx, timeout, fqdn, ip)
+	if err == nil {
+		return data, nil
+	}
+
+	data, ferr := kr.metricsByMountNamespace(ctx, timeout, fqdn, ip)
+	if ferr != nil {
+		return nil, fmt.Errorf("failed to get metrics by direct dial (%v) and by mount namespace: %w", err, ferr)
+	}
+	return data, nil
+}
+
+// metricsByDirectDial returns the metrics for a specific kube-apiserver by
+// rewriting fqdn to ip when dialing. The TLS server name is still fqdn.
+func (kr *KubectlRunner) metricsByDirectDial(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kr.kubeCfgPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-go config: %w", err)
+	}
+	config.Timeout = timeout
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	config.Dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err == nil && strings.EqualFold(host, fqdn) {
+			address = net.JoinHostPort(ip, port)
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build client-go rest client: %w", err)
+	}
+	return clientset.CoreV1().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+}
+
 // Wait runs wait subcommand.
 func (kr *KubectlRunner) Wait(ctx context.Context, timeout time.Duration, condition, waitTimeout, target string) error {
 	if condition == "" {
@@ -16,8 +16,10 @@ import (
 	"k8s.io/klog/v2"
 )
 
-// Metrics returns the metrics for a specific kube-apiserver.
-func (kr *KubectlRunner) Metrics(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
+// metricsByMountNamespace returns the metrics for a specific kube-apiserver
+// by kubectl. It resolves fqdn to ip by bind-mounting /etc/hosts in a new
+// mount namespace.
+func (kr *KubectlRunner) metricsByMountNamespace(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
 	args := []string{}
 	if kr.kubeCfgPath != "" {
 		args = append(args, "--kubeconfig", kr.kubeCfgPath)
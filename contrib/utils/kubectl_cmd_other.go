@@ -11,7 +11,8 @@ import (
 	"time"
 )
 
-// Metrics returns the metrics for a specific kube-apiserver.
-func (kr *KubectlRunner) Metrics(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
+// metricsByMountNamespace isn't supported because mount namespace is
+// Linux-only.
+func (kr *KubectlRunner) metricsByMountNamespace(ctx context.Context, timeout time.Duration, fqdn, ip string) ([]byte, error) {
 	return nil, fmt.Errorf("not supported")
 }
@@ -22,7 +22,10 @@ import (
 	"github.com/Azure/kperf/contrib/log"
 	"github.com/Azure/kperf/helmcli"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/yaml"
@@ -41,6 +44,15 @@ var (
 	EKSIdleNodepoolInstanceType = "m4.large"
 )
 
+// connectivityCheckTimeout is the timeout to check if kube-apiserver is
+// reachable.
+const connectivityCheckTimeout = 10 * time.Second
+
+// runnerGroupNamespace is the namespace where kperf deploys runners.
+//
+// NOTE: It should be aligned with ../../runner/runnergroup_common.go.
+const runnerGroupNamespace = "runnergroups-kperf-io"
+
 // RepeatJobWithPod repeats to deploy 3k pods.
 func RepeatJobWithPod(ctx context.Context, kubeCfgPath string, namespace string,
 	target string, timeoutOpts ...JobTimeoutOpt) {
@@ -118,14 +130,26 @@ func RepeatJobWithPod(ctx context.Context, kubeCfgPath string, namespace string,
 	}
 }
 
-// RenderTemplate renders a resource template to JSON for K8s API requests
-func RenderTemplate(resource string, values map[string]interface{}) ([]byte, error) {
-	// Resource template
-	// TODO: add more template for resource
-	templatePaths := map[string]string{
-		"pods": "workload/pods/templates/pod.tpl",
+// builtinTemplatePaths are embedded templates keyed by resource.
+//
+// TODO: add more template for resource
+var builtinTemplatePaths = map[string]string{
+	"pods":       "workload/pods/templates/pod.tpl",
+	"namespaces": "workload/namespaces/templates/namespace.tpl",
+}
+
+// LoadTemplate parses content as the object template for resource. If
+// content is empty, it falls back to the built-in template.
+func LoadTemplate(resource string, content string) (*template.Template, error) {
+	if content != "" {
+		tmpl, err := template.New(resource).Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for %s: %w", resource, err)
+		}
+		return tmpl, nil
 	}
-	templatePath, ok := templatePaths[resource]
+
+	templatePath, ok := builtinTemplatePaths[resource]
 	if !ok {
 		return nil, fmt.Errorf("unsupported resource type: %s", resource)
 	}
@@ -139,9 +163,23 @@ func RenderTemplate(resource string, values map[string]interface{}) ([]byte, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template: %w", err)
 	}
+	return tmpl, nil
+}
 
+// RenderTemplate renders the built-in template of resource to JSON for
+// K8s API requests.
+func RenderTemplate(resource string, values map[string]interface{}) ([]byte, error) {
+	tmpl, err := LoadTemplate(resource, "")
+	if err != nil {
+		return nil, err
+	}
+	return ExecuteTemplate(tmpl, values)
+}
+
+// ExecuteTemplate renders the template to JSON for K8s API requests.
+func ExecuteTemplate(tmpl *template.Template, values map[string]interface{}) ([]byte, error) {
 	var buf bytes.Buffer
-	err = tmpl.Execute(&buf, map[string]interface{}{
+	err := tmpl.Execute(&buf, map[string]interface{}{
 		"Values": values,
 	})
 	if err != nil {
@@ -151,6 +189,27 @@ func RenderTemplate(resource string, values map[string]interface{}) ([]byte, err
 	return yaml.YAMLToJSON(buf.Bytes())
 }
 
+// ValidateTemplate verifies that the template can be rendered into object
+// with apiVersion and kind.
+func ValidateTemplate(tmpl *template.Template, values map[string]interface{}) error {
+	data, err := ExecuteTemplate(tmpl, values)
+	if err != nil {
+		return err
+	}
+
+	var obj struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return fmt.Errorf("template for %s isn't rendered as object: %w", tmpl.Name(), err)
+	}
+	if obj.APIVersion == "" || obj.Kind == "" {
+		return fmt.Errorf("template for %s requires apiVersion and kind", tmpl.Name())
+	}
+	return nil
+}
+
 // DeployDeployments deploys deployments.
 func DeployDeployments(
 	ctx context.Context,
@@ -274,7 +333,7 @@ func RollingUpdateDeployments(ctx context.Context, total int,
 func NewRunnerGroupSpecFromYAML(data []byte, tweakFn func(*types.RunnerGroupSpec) error) (*types.RunnerGroupSpec, error) {
 	var spec types.RunnerGroupSpec
 
-	if err := yaml.Unmarshal(data, &spec); err != nil {
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal into RunnerGroupSpec:\n (data: %s)\n: %w",
 			string(data), err)
 	}
@@ -295,18 +354,34 @@ func NewRunnerGroupSpecFileFromEmbed(target string, tweakFn func(*types.RunnerGr
 	if err != nil {
 		return "", nil, fmt.Errorf("unexpected error when read %s from embed memory: %v", target, err)
 	}
+	return newRunnerGroupSpecFile(data, tweakFn)
+}
 
+// NewRunnerGroupSpecFileFromFile is like NewRunnerGroupSpecFileFromEmbed
+// but reads load profile (RunnerGroupSpec) from local file.
+func NewRunnerGroupSpecFileFromFile(path string, tweakFn func(*types.RunnerGroupSpec) error) (_name string, _cleanup func() error, _ error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read load profile %s: %w", path, err)
+	}
+	return newRunnerGroupSpecFile(data, tweakFn)
+}
+
+// newRunnerGroupSpecFile tweaks RunnerGroupSpec in data and marshals it into
+// temporary file.
+func newRunnerGroupSpecFile(data []byte, tweakFn func(*types.RunnerGroupSpec) error) (_name string, _cleanup func() error, _ error) {
 	if tweakFn != nil {
 		var spec types.RunnerGroupSpec
-		if err = yaml.Unmarshal(data, &spec); err != nil {
+		if err := yaml.UnmarshalStrict(data, &spec); err != nil {
 			return "", nil, fmt.Errorf("failed to unmarshal into RunnerGroupSpec:\n (data: %s)\n: %w",
 				string(data), err)
 		}
 
-		if err = tweakFn(&spec); err != nil {
+		if err := tweakFn(&spec); err != nil {
 			return "", nil, err
 		}
 
+		var err error
 		data, err = yaml.Marshal(spec)
 		if err != nil {
 			return "", nil, fmt.Errorf("failed to marshal RunnerGroupSpec after tweak: %w", err)
@@ -315,10 +390,12 @@ func NewRunnerGroupSpecFileFromEmbed(target string, tweakFn func(*types.RunnerGr
 	return CreateTempFileWithContent(data)
 }
 
-// DeployRunnerGroup deploys runner group for benchmark.
+// DeployRunnerGroup deploys runner group for benchmark. It returns the
+// runner group's report and the time it took until all the runners were
+// started. The ready time is zero if it's unknown.
 func DeployRunnerGroup(ctx context.Context,
 	kubeCfgPath, runnerImage, rgCfgFile string,
-	runnerFlowControl, runnerGroupAffinity string) (*types.RunnerGroupsReport, error) {
+	runnerFlowControl, runnerGroupAffinity string) (*types.RunnerGroupsReport, time.Duration, error) {
 
 	infoLogger := log.GetLogger(ctx).WithKeyValues("level", "info")
 	warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
@@ -328,20 +405,51 @@ func DeployRunnerGroup(ctx context.Context,
 	infoLogger.LogKV("msg", "deleting existing runner group")
 	derr := kr.RGDelete(ctx, 0)
 	if derr != nil {
-		return nil, fmt.Errorf("failed to delete existing runner group: %w", derr)
+		return nil, 0, fmt.Errorf("failed to delete existing runner group: %w", derr)
 	}
 
+	rgSpecInRaw, err := os.ReadFile(rgCfgFile)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read runner group spec %s: %w", rgCfgFile, err)
+	}
+
+	rgSpec, err := NewRunnerGroupSpecFromYAML(rgSpecInRaw, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	startAt := time.Now()
+
 	infoLogger.LogKV("msg", "deploying runner group")
 	rerr := kr.RGRun(ctx, 0, rgCfgFile, runnerFlowControl, runnerGroupAffinity)
 	if rerr != nil {
-		return nil, fmt.Errorf("failed to deploy runner group: %w", rerr)
+		return nil, 0, fmt.Errorf("failed to deploy runner group: %w", rerr)
 	}
 
+	readyCtx, readyCancel := context.WithCancel(ctx)
+	defer readyCancel()
+
+	readyCh := make(chan time.Duration, 1)
+	go func() {
+		defer close(readyCh)
+
+		if err := waitForRunnersStarted(readyCtx, kubeCfgPath, int(rgSpec.Count)); err != nil {
+			if readyCtx.Err() == nil {
+				warnLogger.LogKV("msg", "failed to wait for runners started", "error", err)
+			}
+			return
+		}
+
+		readyTime := time.Since(startAt)
+		infoLogger.LogKV("msg", fmt.Sprintf("all the runners have been started in %v", readyTime))
+		readyCh <- readyTime
+	}()
+
 	infoLogger.LogKV("msg", "start to wait runner group")
 	for {
 		select {
 		case <-ctx.Done():
-			return nil, ctx.Err()
+			return nil, 0, ctx.Err()
 		default:
 		}
 
@@ -358,7 +466,7 @@ func DeployRunnerGroup(ctx context.Context,
 			// match. We should use specific commandline error code
 			// or use package instead of binary call.
 			if strings.Contains(err.Error(), `pods "runnergroup-server" not found`) {
-				return nil, err
+				return nil, 0, err
 			}
 
 			warnLogger.LogKV("msg", fmt.Errorf("failed to fetch runner group's result: %w", err))
@@ -369,14 +477,61 @@ func DeployRunnerGroup(ctx context.Context,
 
 		var rgResult types.RunnerGroupsReport
 		if err = json.Unmarshal([]byte(data), &rgResult); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal into RunnerGroupsReport: %w", err)
+			return nil, 0, fmt.Errorf("failed to unmarshal into RunnerGroupsReport: %w", err)
 		}
 
+		readyCancel()
+		readyTime := <-readyCh
+
 		infoLogger.LogKV("msg", "deleting runner group")
 		if derr := kr.RGDelete(ctx, 0); derr != nil {
 			warnLogger.LogKV("msg", "failed to delete runner group", "err", err)
 		}
-		return &rgResult, nil
+		return &rgResult, readyTime, nil
+	}
+}
+
+// waitForRunnersStarted waits until the expected number of runner pods
+// have been started.
+func waitForRunnersStarted(ctx context.Context, kubeCfgPath string, expected int) error {
+	clientset, err := BuildClientset(kubeCfgPath)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: The runner is created by batch job and the runnergroup-server
+	// doesn't have job-name label.
+	opts := metav1.ListOptions{
+		LabelSelector:   "job-name",
+		ResourceVersion: "0",
+	}
+
+	for {
+		pods, err := clientset.CoreV1().Pods(runnerGroupNamespace).List(ctx, opts)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.GetLogger(ctx).
+				WithKeyValues("level", "warn").
+				LogKV("msg", "failed to list runner pods", "error", err)
+		} else {
+			started := 0
+			for _, pod := range pods.Items {
+				if pod.Status.Phase != corev1.PodPending {
+					started++
+				}
+			}
+			if started >= expected {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+		}
 	}
 }
 
@@ -386,25 +541,14 @@ func FetchAPIServerCores(ctx context.Context, kubeCfgPath string) (map[string]in
 
 	logger.WithKeyValues("level", "info").LogKV("msg", "fetching apiserver's cores")
 
-	kr := NewKubectlRunner(kubeCfgPath, "")
-	fqdn, err := kr.FQDN(ctx, 0)
+	metricsByIP, err := FetchAPIServerMetrics(ctx, kubeCfgPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get cluster fqdn: %w", err)
-	}
-
-	ips, nerr := NSLookup(fqdn)
-	if nerr != nil {
-		return nil, fmt.Errorf("failed get dns records of fqdn %s: %w", fqdn, nerr)
+		return nil, err
 	}
 
 	res := map[string]int{}
-	for _, ip := range ips {
+	for ip, data := range metricsByIP {
 		cores, err := func() (int, error) {
-			data, err := kr.Metrics(ctx, 0, fqdn, ip)
-			if err != nil {
-				return 0, fmt.Errorf("failed to get metrics for ip %s: %w", ip, err)
-			}
-
 			lines := strings.Split(string(data), "\n")
 			for _, line := range lines {
 				if strings.HasPrefix(line, "go_sched_gomaxprocs_threads") {
@@ -428,6 +572,34 @@ func FetchAPIServerCores(ctx context.Context, kubeCfgPath string) (map[string]in
 	return res, nil
 }
 
+// FetchAPIServerMetrics fetches raw /metrics data for each kube-apiserver.
+// The result is keyed by kube-apiserver's IP address.
+func FetchAPIServerMetrics(ctx context.Context, kubeCfgPath string) (map[string][]byte, error) {
+	logger := log.GetLogger(ctx)
+
+	kr := NewKubectlRunner(kubeCfgPath, "")
+	fqdn, err := kr.FQDN(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster fqdn: %w", err)
+	}
+
+	ips, nerr := NSLookup(fqdn)
+	if nerr != nil {
+		return nil, fmt.Errorf("failed get dns records of fqdn %s: %w", fqdn, nerr)
+	}
+
+	res := make(map[string][]byte, len(ips))
+	for _, ip := range ips {
+		data, err := kr.Metrics(ctx, 0, fqdn, ip)
+		if err != nil {
+			logger.WithKeyValues("level", "warn").LogKV("msg", "failed to get metrics", "ip", ip, "error", err)
+			continue
+		}
+		res[ip] = data
+	}
+	return res, nil
+}
+
 // FetchNodeProviderIDByType is used to get one node's provider id with a given
 // instance type.
 func FetchNodeProviderIDByType(ctx context.Context, kubeCfgPath string, instanceType string) (string, error) {
@@ -464,6 +636,26 @@ func BuildClientset(kubeCfgPath string) (*kubernetes.Clientset, error) {
 	return clientset, nil
 }
 
+// CheckAPIServerConnectivity checks if kube-apiserver is reachable by
+// requesting /version. It's used to fail fast before deploying anything.
+func CheckAPIServerConnectivity(kubeCfgPath string) error {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	if err != nil {
+		return fmt.Errorf("failed to build client-go config: %w", err)
+	}
+	config.Timeout = connectivityCheckTimeout
+
+	cli, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to build discovery client: %w", err)
+	}
+
+	if _, err = cli.ServerVersion(); err != nil {
+		return fmt.Errorf("cannot reach apiserver at %s: %w", config.Host, err)
+	}
+	return nil
+}
+
 // NSLookup returns ips for URL.
 func NSLookup(domainURL string) ([]string, error) {
 	ips, err := net.LookupHost(domainURL)
@@ -574,7 +766,8 @@ func DeleteConfigmaps(ctx context.Context, kubeCfgPath, namespace, namePattern s
 
 }
 
-// Deploy jobs using template
+// DeployJobs deploys jobs using template. The pod template can be overridden
+// by WithDeployJobsPodSpecOpt.
 func DeployJobs(
 	ctx context.Context,
 	kubeCfgPath string,
@@ -582,31 +775,47 @@ func DeployJobs(
 	jobCount, podsPerJob, parallelism int,
 	namespace string,
 	deployTimeout time.Duration,
+	opts ...DeployJobsOpt,
 ) (cleanupFn func(), retErr error) {
 	infoLogger := log.GetLogger(ctx).WithKeyValues("level", "info")
 	warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
 
+	var jobsOpt deployJobsOption
+	for _, opt := range opts {
+		opt(&jobsOpt)
+	}
+
+	namePattern := releaseName
+
+	valuesAppliers := []helmcli.ValuesApplier{
+		helmcli.StringPathValuesApplier(
+			fmt.Sprintf("namePattern=%s", namePattern),
+			fmt.Sprintf("jobCount=%d", jobCount),
+			fmt.Sprintf("podsPerJob=%d", podsPerJob),
+			fmt.Sprintf("parallelism=%d", parallelism),
+			fmt.Sprintf("namespace=%s", namespace),
+		),
+	}
+	if jobsOpt.podSpec != nil {
+		if err := jobsOpt.podSpec.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid pod spec override: %w", err)
+		}
+		valuesAppliers = append(valuesAppliers, helmcli.MapValuesApplier(jobsOpt.podSpec.toValues()))
+	}
+
 	target := "workload/jobs"
 	ch, err := manifests.LoadChart(target)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load %s chart: %w", target, err)
 	}
 
-	namePattern := releaseName
-
 	releaseCli, err := helmcli.NewReleaseCli(
 		kubeCfgPath,
 		namespace,
 		releaseName,
 		ch,
 		nil,
-		helmcli.StringPathValuesApplier(
-			fmt.Sprintf("namePattern=%s", namePattern),
-			fmt.Sprintf("jobCount=%d", jobCount),
-			fmt.Sprintf("podsPerJob=%d", podsPerJob),
-			fmt.Sprintf("parallelism=%d", parallelism),
-			fmt.Sprintf("namespace=%s", namespace),
-		),
+		valuesAppliers...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a new helm release cli: %w", err)
@@ -653,3 +862,121 @@ func DeployJobs(
 
 	return cleanupFn, nil
 }
+
+// DeployDaemonsets deploys daemonsets onto the virtual nodes in nodepool by
+// template, so that each node runs one pod per daemonset.
+func DeployDaemonsets(
+	ctx context.Context,
+	kubeCfgPath string,
+	releaseName string,
+	dsCount int,
+	nodepool string,
+	paddingBytes int,
+	namespace string,
+	deployTimeout time.Duration,
+) (cleanupFn func(), retErr error) {
+	infoLogger := log.GetLogger(ctx).WithKeyValues("level", "info")
+	warnLogger := log.GetLogger(ctx).WithKeyValues("level", "warn")
+
+	target := "workload/daemonsets"
+	ch, err := manifests.LoadChart(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s chart: %w", target, err)
+	}
+
+	releaseCli, err := helmcli.NewReleaseCli(
+		kubeCfgPath,
+		namespace,
+		releaseName,
+		ch,
+		nil,
+		helmcli.StringPathValuesApplier(
+			fmt.Sprintf("namePattern=%s", releaseName),
+			fmt.Sprintf("count=%d", dsCount),
+			fmt.Sprintf("nodepool=%s", nodepool),
+			fmt.Sprintf("paddingBytes=%d", paddingBytes),
+			fmt.Sprintf("namespace=%s", namespace),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new helm release cli: %w", err)
+	}
+
+	infoLogger.LogKV(
+		"msg", "deploying daemonsets",
+		"count", dsCount,
+		"nodepool", nodepool,
+		"namespace", namespace,
+	)
+
+	kr := NewKubectlRunner(kubeCfgPath, namespace)
+	err = kr.CreateNamespace(ctx, 2*time.Minute, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+
+	err = releaseCli.Deploy(ctx, deployTimeout)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			infoLogger.LogKV("msg", "deploy is canceled")
+			return func() {}, nil
+		}
+		return nil, fmt.Errorf("failed to deploy helm chart %s: %w", target, err)
+	}
+	infoLogger.LogKV("msg", "deployed daemonsets")
+
+	cleanupFn = func() {
+		err := releaseCli.Uninstall()
+		if err != nil {
+			warnLogger.LogKV("msg", "failed to cleanup helm chart", "error", err)
+		}
+
+		err = kr.DeleteNamespace(context.TODO(), 5*time.Minute, namespace)
+		if err != nil {
+			warnLogger.LogKV("msg", "failed to cleanup namespace", "error", err)
+		}
+	}
+	return cleanupFn, nil
+}
+
+// SaveReportIntoConfigmap stores report data into configmap with labels,
+// so that in-cluster jobs can retrieve results without scraping logs. The
+// configmap is created if it doesn't exist. Otherwise, it's overwritten.
+func SaveReportIntoConfigmap(ctx context.Context, kubeCfgPath, namespace, name string,
+	labels map[string]string, key string, data []byte) error {
+
+	clientset, err := BuildClientset(kubeCfgPath)
+	if err != nil {
+		return err
+	}
+
+	cli := clientset.CoreV1().ConfigMaps(namespace)
+
+	cm, err := cli.Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		if cm.Labels == nil {
+			cm.Labels = map[string]string{}
+		}
+		for k, v := range labels {
+			cm.Labels[k] = v
+		}
+		cm.Data = map[string]string{key: string(data)}
+		cm.BinaryData = nil
+
+		_, err = cli.Update(ctx, cm, metav1.UpdateOptions{})
+	case apierrors.IsNotFound(err):
+		_, err = cli.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    labels,
+			},
+			Data: map[string]string{key: string(data)},
+		}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save report into configmap %s/%s: %w", namespace, name, err)
+	}
+	return nil
+}
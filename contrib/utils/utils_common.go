@@ -4,7 +4,11 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type rollingUpdateTimeoutOption struct {
@@ -63,3 +67,126 @@ func WithJobDeleteTimeoutOpt(to time.Duration) JobTimeoutOpt {
 		jto.deleteTimeout = to
 	}
 }
+
+// JobPodSpecOverride overrides the pod template of jobs deployed by
+// DeployJobs. The zero value keeps the embedded default.
+type JobPodSpecOverride struct {
+	// Image is the container image. Empty means the default fake image.
+	Image string `json:"image,omitempty"`
+	// Command overrides the entrypoint of container.
+	Command []string `json:"command,omitempty"`
+	// Requests is the resource requests of container, like cpu=100m.
+	Requests map[string]string `json:"requests,omitempty"`
+	// Annotations is the number of annotations added into pod to inflate
+	// its object size. It's 1 if AnnotationBytes is set.
+	Annotations int `json:"annotations,omitempty"`
+	// AnnotationBytes is the total size of annotations' values in bytes,
+	// which is split evenly across annotations.
+	AnnotationBytes int `json:"annotationBytes,omitempty"`
+	// Labels is the number of synthetic labels added into pod.
+	Labels int `json:"labels,omitempty"`
+}
+
+// Validate returns error if override is invalid.
+func (o *JobPodSpecOverride) Validate() error {
+	if o.Image != "" && strings.ContainsAny(o.Image, " \t\n") {
+		return fmt.Errorf("invalid image %q", o.Image)
+	}
+
+	for _, arg := range o.Command {
+		if arg == "" {
+			return fmt.Errorf("required non-empty command argument: %v", o.Command)
+		}
+	}
+
+	for name, quantity := range o.Requests {
+		if name == "" {
+			return fmt.Errorf("required non-empty resource name")
+		}
+		if _, err := resource.ParseQuantity(quantity); err != nil {
+			return fmt.Errorf("invalid quantity %s of resource %s: %w", quantity, name, err)
+		}
+	}
+
+	if o.Annotations < 0 {
+		return fmt.Errorf("annotations requires >= 0: %v", o.Annotations)
+	}
+	if o.AnnotationBytes < 0 {
+		return fmt.Errorf("annotationBytes requires >= 0: %v", o.AnnotationBytes)
+	}
+	if size := o.annotationsSize(); size > maxTotalAnnotationBytes {
+		return fmt.Errorf("annotations take %d bytes, including keys, which exceeds %d bytes limited by kube-apiserver",
+			size, maxTotalAnnotationBytes)
+	}
+	if o.Labels < 0 {
+		return fmt.Errorf("labels requires >= 0: %v", o.Labels)
+	}
+	return nil
+}
+
+// maxTotalAnnotationBytes is the maximum total size of annotations' keys
+// and values accepted by kube-apiserver.
+const maxTotalAnnotationBytes = 256 * 1024
+
+// annotationsSize returns the total size of padding annotations' keys and
+// values.
+//
+// NOTE: Please align with ../internal/manifests/workload/jobs/templates/jobs.tpl
+func (o *JobPodSpecOverride) annotationsSize() int {
+	size := o.AnnotationBytes
+	for i := 0; i < o.annotations(); i++ {
+		size += len(fmt.Sprintf("kperf.io/padding-%d", i))
+	}
+	return size
+}
+
+// annotations returns the number of padding annotations.
+func (o *JobPodSpecOverride) annotations() int {
+	if o.Annotations == 0 && o.AnnotationBytes > 0 {
+		return 1
+	}
+	return o.Annotations
+}
+
+// toValues returns values of workload/jobs chart.
+//
+// NOTE: Please align with ../internal/manifests/workload/jobs/values.yaml
+func (o *JobPodSpecOverride) toValues() map[string]interface{} {
+	podSpec := map[string]interface{}{
+		"annotations":     o.annotations(),
+		"annotationBytes": o.AnnotationBytes,
+		"labels":          o.Labels,
+	}
+	if o.Image != "" {
+		podSpec["image"] = o.Image
+	}
+	if len(o.Command) > 0 {
+		command := make([]interface{}, 0, len(o.Command))
+		for _, arg := range o.Command {
+			command = append(command, arg)
+		}
+		podSpec["command"] = command
+	}
+	if len(o.Requests) > 0 {
+		requests := make(map[string]interface{}, len(o.Requests))
+		for name, quantity := range o.Requests {
+			requests[name] = quantity
+		}
+		podSpec["requests"] = requests
+	}
+	return map[string]interface{}{"podSpec": podSpec}
+}
+
+type deployJobsOption struct {
+	podSpec *JobPodSpecOverride
+}
+
+// DeployJobsOpt is used to update default DeployJobs's setting.
+type DeployJobsOpt func(*deployJobsOption)
+
+// WithDeployJobsPodSpecOpt overrides the pod template of jobs.
+func WithDeployJobsPodSpecOpt(podSpec JobPodSpecOverride) DeployJobsOpt {
+	return func(o *deployJobsOption) {
+		o.podSpec = &podSpec
+	}
+}
@@ -47,6 +47,14 @@ func StringPathValuesApplier(values ...string) ValuesApplier {
 	}
 }
 
+// MapValuesApplier applies key/values from map, like the values of an
+// existing release.
+func MapValuesApplier(values map[string]interface{}) ValuesApplier {
+	return func(to map[string]interface{}) error {
+		return applyValues(to, values)
+	}
+}
+
 // YAMLValuesApplier applies key/values by YAML.
 func YAMLValuesApplier(yamlValues string) (ValuesApplier, error) {
 	values := make(map[string]interface{})
@@ -97,6 +105,9 @@ type ReleaseCli struct {
 	ch     *chart.Chart
 	values map[string]interface{}
 	labels map[string]string
+
+	// atomic is to roll back the failed install or upgrade.
+	atomic bool
 }
 
 // NewReleaseCli returns new ReleaseCli instance.
@@ -142,9 +153,17 @@ func NewReleaseCli(
 		ch:        ch,
 		values:    values,
 		labels:    labels,
+		atomic:    true,
 	}, nil
 }
 
+// SetAtomic sets whether to roll back the release if install or upgrade
+// fails. It's true by default. The failed release is kept for inspection
+// if it's false.
+func (cli *ReleaseCli) SetAtomic(atomic bool) {
+	cli.atomic = atomic
+}
+
 // Deploy will install or upgrade that release.
 func (cli *ReleaseCli) Deploy(ctx context.Context, timeout time.Duration, valuesAppliers ...ValuesApplier) error {
 	values, err := cli.initValues(valuesAppliers...)
@@ -159,7 +178,7 @@ func (cli *ReleaseCli) Deploy(ctx context.Context, timeout time.Duration, values
 	if _, err = histCli.Run(cli.name); err == driver.ErrReleaseNotFound {
 		installCli := action.NewInstall(cli.cfg)
 		installCli.CreateNamespace = true
-		installCli.Atomic = true
+		installCli.Atomic = cli.atomic
 		installCli.Namespace = cli.namespace
 		installCli.ReleaseName = cli.name
 		installCli.IsUpgrade = true
@@ -177,7 +196,7 @@ func (cli *ReleaseCli) Deploy(ctx context.Context, timeout time.Duration, values
 
 	upgradeCli := action.NewUpgrade(cli.cfg)
 	upgradeCli.Namespace = cli.namespace
-	upgradeCli.Atomic = true
+	upgradeCli.Atomic = cli.atomic
 	upgradeCli.Timeout = timeout
 	upgradeCli.MaxHistory = 1
 	upgradeCli.Wait = true
@@ -192,6 +211,33 @@ func (cli *ReleaseCli) Deploy(ctx context.Context, timeout time.Duration, values
 	return nil
 }
 
+// GetValues returns the values of the given release, including the chart's
+// default values which aren't overridden.
+func (cli *ReleaseCli) GetValues(name string) (map[string]interface{}, error) {
+	getValuesCli := action.NewGetValues(cli.cfg)
+	getValuesCli.AllValues = true
+
+	values, err := getValuesCli.Run(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get values of release %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// Rollback rolls back the given release to that revision. The rollback is
+// recorded as a new revision.
+func (cli *ReleaseCli) Rollback(name string, revision int) error {
+	rollbackCli := action.NewRollback(cli.cfg)
+	rollbackCli.Version = revision
+	rollbackCli.MaxHistory = 1
+	rollbackCli.CleanupOnFail = true
+
+	if err := rollbackCli.Run(name); err != nil {
+		return fmt.Errorf("failed to roll back release %s to revision %d: %w", name, revision, err)
+	}
+	return nil
+}
+
 // Uninstall deletes that release.
 func (cli *ReleaseCli) Uninstall() error {
 	uninstallCli := action.NewUninstall(cli.cfg)
@@ -4,9 +4,18 @@
 package helmcli
 
 import (
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
 )
 
 func TestApplyValues(t *testing.T) {
@@ -106,3 +115,111 @@ func TestApplyValues(t *testing.T) {
 		assert.Equal(t, tc.expected, tc.to, "#%v", idx)
 	}
 }
+
+func TestMapValuesApplierWithYAMLValuesApplier(t *testing.T) {
+	values := map[string]interface{}{
+		"replicas":   int64(10),
+		"nodeLabels": map[string]interface{}{},
+	}
+
+	existing := map[string]interface{}{
+		"replicas": int64(20),
+		"nodeLabels": map[string]interface{}{
+			"tier": "bench",
+			"zone": "a",
+		},
+	}
+	assert.NoError(t, MapValuesApplier(existing)(values))
+
+	labelsApplier, err := YAMLValuesApplier("nodeLabels:\n  zone: b\n  team: perf\n")
+	assert.NoError(t, err)
+	assert.NoError(t, labelsApplier(values))
+
+	assert.Equal(t, map[string]interface{}{
+		"replicas": int64(20),
+		"nodeLabels": map[string]interface{}{
+			"tier": "bench",
+			"zone": "b",
+			"team": "perf",
+		},
+	}, values)
+}
+
+func newFakeActionConfig() *action.Configuration {
+	return &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          debugLog,
+	}
+}
+
+func TestReleaseCliGetValues(t *testing.T) {
+	actionCfg := newFakeActionConfig()
+
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "nodes", Version: "0.1.0"},
+		Values: map[string]interface{}{
+			"replicas": int64(10),
+			"cpu":      "8",
+		},
+	}
+	require.NoError(t, actionCfg.Releases.Create(&release.Release{
+		Name:      "example",
+		Namespace: "default",
+		Version:   1,
+		Chart:     ch,
+		Config: map[string]interface{}{
+			"replicas": int64(20),
+		},
+		Info: &release.Info{Status: release.StatusDeployed},
+	}))
+
+	cli := &ReleaseCli{namespace: "default", name: "example", cfg: actionCfg, ch: ch}
+
+	values, err := cli.GetValues("example")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicas": int64(20),
+		"cpu":      "8",
+	}, values)
+
+	_, err = cli.GetValues("unknown")
+	assert.ErrorIs(t, err, driver.ErrReleaseNotFound)
+}
+
+func TestReleaseCliRollback(t *testing.T) {
+	actionCfg := newFakeActionConfig()
+
+	ch := &chart.Chart{
+		Metadata: &chart.Metadata{Name: "nodes", Version: "0.1.0"},
+		Values:   map[string]interface{}{"replicas": int64(10)},
+	}
+	for _, rel := range []*release.Release{
+		{
+			Version: 1,
+			Config:  map[string]interface{}{"replicas": int64(20)},
+			Info:    &release.Info{Status: release.StatusSuperseded},
+		},
+		{
+			Version: 2,
+			Config:  map[string]interface{}{"replicas": int64(30)},
+			Info:    &release.Info{Status: release.StatusFailed},
+		},
+	} {
+		rel.Name, rel.Namespace, rel.Chart = "example", "default", ch
+		require.NoError(t, actionCfg.Releases.Create(rel))
+	}
+
+	cli := &ReleaseCli{namespace: "default", name: "example", cfg: actionCfg, ch: ch}
+
+	require.NoError(t, cli.Rollback("example", 1))
+
+	last, err := actionCfg.Releases.Last("example")
+	require.NoError(t, err)
+	assert.Equal(t, 3, last.Version)
+	assert.Equal(t, release.StatusDeployed, last.Info.Status)
+	assert.Equal(t, map[string]interface{}{"replicas": int64(20)}, last.Config)
+
+	assert.Error(t, cli.Rollback("example", 5))
+}
@@ -3,6 +3,8 @@
 {{- $memory := .Values.memory }}
 {{- $maxPods := .Values.maxPods }}
 {{- $labels := .Values.nodeLabels }}
+{{- $taints := .Values.nodeTaints }}
+{{- $extendedResources := .Values.extendedResources }}
 {{- $sharedProviderID := .Values.sharedProviderID }}
 {{- range $index := (untilStep 0 (int .Values.replicas) 1) }}
 apiVersion: v1
@@ -33,18 +35,31 @@ spec:
   - effect: NoSchedule
     key: kperf.io/nodepool
     value: fake
+{{- range $taint := $taints }}
+  - effect: {{ $taint.effect }}
+    key: {{ $taint.key }}
+{{- if $taint.value }}
+    value: {{ $taint.value | quote }}
+{{- end }}
+{{- end }}
 {{- if $sharedProviderID }}
   providerID: {{ $sharedProviderID }}
 {{- end}}
 status:
   allocatable:
-    cpu: {{ $cpu }}
-    memory: {{ $memory }}Gi
+    cpu: {{ $cpu | quote }}
+    memory: {{ $memory | quote }}
     pods: {{ $maxPods }}
+{{- range $name, $quantity := $extendedResources }}
+    {{ $name }}: {{ $quantity | quote }}
+{{- end }}
   capacity:
-    cpu: {{ $cpu }}
-    memory: {{ $memory }}Gi
+    cpu: {{ $cpu | quote }}
+    memory: {{ $memory | quote }}
     pods: {{ $maxPods }}
+{{- range $name, $quantity := $extendedResources }}
+    {{ $name }}: {{ $quantity | quote }}
+{{- end }}
   nodeInfo:
     architecture: amd64
     containerRuntimeVersion: "kwok"
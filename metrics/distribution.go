@@ -0,0 +1,156 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/Azure/kperf/api/types"
+)
+
+const (
+	// DistributionFormatCSV writes distribution in CSV with le,count header.
+	DistributionFormatCSV = "csv"
+	// DistributionFormatJSON writes distribution as types.LatencyHistogram.
+	DistributionFormatJSON = "json"
+)
+
+// distributionResolution is the bucket width in seconds of the
+// distribution built from raw latencies.
+const distributionResolution = 0.001
+
+// RandSource generates random numbers for sampling.
+type RandSource interface {
+	// Int63n returns a random number in [0, n).
+	Int63n(n int64) int64
+}
+
+// WithLatencyReservoirOpt keeps a uniform random sample of at most size
+// latencies picked by rnd, which is used to export latency distribution
+// with bounded memory, even with histogram. The raw latencies aren't kept
+// with reservoir. The latencies by URL and by method are recorded in
+// histogram, DefaultLatencyBuckets if not set, and the times to first byte
+// and the latencies by instance are sampled in their own reservoirs of the
+// same size. The value <= 0 means that the distribution is built from all
+// the raw latencies or the histograms.
+func WithLatencyReservoirOpt(size int, rnd RandSource) ResponseMetricOpt {
+	return func(m *responseMetricImpl) {
+		if size > 0 {
+			m.reservoirSize = size
+			m.reservoirRnd = rnd
+			m.reservoir = m.newReservoir()
+			m.ttfbReservoir = m.newReservoir()
+			m.instanceReservoirs = map[string]*latencyReservoir{}
+		}
+	}
+}
+
+// newReservoir returns an empty reservoir with the size and rnd from
+// WithLatencyReservoirOpt.
+func (m *responseMetricImpl) newReservoir() *latencyReservoir {
+	return &latencyReservoir{size: m.reservoirSize, rnd: m.reservoirRnd}
+}
+
+// latencyReservoir is reservoir sampling (Algorithm R) of latencies.
+type latencyReservoir struct {
+	size    int
+	rnd     RandSource
+	seen    int64
+	samples []float64
+}
+
+// observe must be called with responseMetricImpl's lock.
+func (r *latencyReservoir) observe(seconds float64) {
+	r.seen++
+	if len(r.samples) < r.size {
+		r.samples = append(r.samples, seconds)
+		return
+	}
+
+	if idx := r.rnd.Int63n(r.seen); idx < int64(r.size) {
+		r.samples[idx] = seconds
+	}
+}
+
+// ExportDistribution implements ResponseMetric.
+func (m *responseMetricImpl) ExportDistribution(w io.Writer, format string) error {
+	h := m.latencyDistribution()
+
+	switch format {
+	case DistributionFormatJSON:
+		return json.NewEncoder(w).Encode(h)
+	case DistributionFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"le", "count"}); err != nil {
+			return err
+		}
+		for idx, count := range h.Counts {
+			le := "+Inf"
+			if idx < len(h.Buckets) {
+				le = strconv.FormatFloat(h.Buckets[idx], 'f', -1, 64)
+			}
+			if err := cw.Write([]string{le, strconv.FormatInt(count, 10)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported distribution format: %s", format)
+	}
+}
+
+// latencyDistribution returns the latency distribution of all the requests.
+// The reservoir samples, if any, or the raw latencies are counted in buckets
+// with distributionResolution. Without both, it's the merged histogram if
+// the metric is backed by histogram.
+func (m *responseMetricImpl) latencyDistribution() types.LatencyHistogram {
+	var latencies []float64
+	switch {
+	case m.reservoir != nil:
+		m.mu.Lock()
+		latencies = append(latencies, m.reservoir.samples...)
+		m.mu.Unlock()
+	case m.latencyBuckets != nil:
+		res := types.LatencyHistogram{}
+		for _, h := range m.dumpHistograms(byMethod) {
+			// NOTE: All the histograms share the same buckets.
+			_ = MergeLatencyHistogram(&res, h)
+		}
+		if len(res.Counts) == 0 {
+			res = *newLatencyHistogram(m.latencyBuckets)
+		}
+		return res
+	default:
+		for _, l := range m.dumpLatencies(byMethod) {
+			latencies = append(latencies, l...)
+		}
+	}
+
+	counts := map[float64]int64{}
+	for _, l := range latencies {
+		counts[math.Ceil(l/distributionResolution)*distributionResolution]++
+	}
+
+	buckets := make([]float64, 0, len(counts))
+	for b := range counts {
+		buckets = append(buckets, b)
+	}
+	sort.Float64s(buckets)
+
+	res := *newLatencyHistogram(buckets)
+	for idx, b := range buckets {
+		res.Counts[idx] = counts[b]
+	}
+	for _, l := range latencies {
+		res.Sum += l
+	}
+	return res
+}
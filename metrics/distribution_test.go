@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	mathrand "math/rand"
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseMetric_ExportDistribution(t *testing.T) {
+	m := NewResponseMetric()
+	m.ObserveLatency("GET", "/api/v1/pods/x", 0.0012)
+	m.ObserveLatency("GET", "/api/v1/pods/y", 0.0018)
+	m.ObserveLatency("LIST", "/api/v1/pods", 0.5)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.ExportDistribution(&buf, DistributionFormatCSV))
+	assert.Equal(t, "le,count\n0.002,2\n0.5,1\n+Inf,0\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, m.ExportDistribution(&buf, DistributionFormatJSON))
+	var h types.LatencyHistogram
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &h))
+	assert.Equal(t, []float64{0.002, 0.5}, h.Buckets)
+	assert.Equal(t, []int64{2, 1, 0}, h.Counts)
+
+	assert.Error(t, m.ExportDistribution(&buf, "xml"))
+}
+
+func TestResponseMetric_ExportDistributionWithHistogram(t *testing.T) {
+	m := NewResponseMetric(WithLatencyHistogramOpt([]float64{0.1, 1}))
+	m.ObserveLatency("GET", "/api/v1/pods/x", 0.05)
+	m.ObserveLatency("LIST", "/api/v1/pods", 0.5)
+	m.ObserveLatency("LIST", "/api/v1/pods", 5)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.ExportDistribution(&buf, DistributionFormatCSV))
+	assert.Equal(t, "le,count\n0.1,1\n1,1\n+Inf,1\n", buf.String())
+}
+
+func TestResponseMetric_LatencyReservoir(t *testing.T) {
+	m := NewResponseMetric(
+		WithLatencyHistogramOpt(nil),
+		WithLatencyReservoirOpt(10, mathrand.New(mathrand.NewSource(1))),
+	)
+	for i := 0; i < 1000; i++ {
+		m.ObserveLatency("GET", "/api/v1/pods/x", 0.01)
+	}
+
+	h := m.(*responseMetricImpl).latencyDistribution()
+	assert.Equal(t, []float64{0.01}, h.Buckets)
+	assert.Equal(t, []int64{10, 0}, h.Counts)
+}
+
+func TestResponseMetric_LatencyReservoirBoundsRawLatencies(t *testing.T) {
+	m := NewResponseMetric(WithLatencyReservoirOpt(10, mathrand.New(mathrand.NewSource(1))))
+	for i := 0; i < 1000; i++ {
+		m.ObserveLatency("GET", "/api/v1/pods/x", 0.01)
+		m.ObserveTimeToFirstByte(0.005)
+		m.ObserveInstanceLatency("10.0.0.1", 0.01)
+	}
+
+	stats := m.Gather()
+	assert.Empty(t, stats.LatenciesByURL)
+	assert.Empty(t, stats.LatenciesByMethod)
+	assert.Equal(t, int64(1000), LatencyHistogramTotal(stats.LatencyHistogramsByMethod["GET"]))
+	assert.Len(t, stats.TimesToFirstByte, 10)
+	assert.Len(t, stats.LatenciesByInstance["10.0.0.1"], 10)
+
+	h := m.(*responseMetricImpl).latencyDistribution()
+	assert.Equal(t, []int64{10, 0}, h.Counts)
+}
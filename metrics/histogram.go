@@ -0,0 +1,124 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Azure/kperf/api/types"
+)
+
+// DefaultLatencyBuckets is the default upper bounds in seconds of latency
+// histogram. It's tuned for kube-apiserver requests, from a few
+// milliseconds for cached GET to tens of seconds for large LIST.
+var DefaultLatencyBuckets = []float64{
+	0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5,
+	1, 2.5, 5, 10, 30, 60,
+}
+
+// newLatencyHistogram returns empty histogram with given buckets.
+func newLatencyHistogram(buckets []float64) *types.LatencyHistogram {
+	return &types.LatencyHistogram{
+		Buckets: buckets,
+		Counts:  make([]int64, len(buckets)+1),
+	}
+}
+
+// observeLatencyHistogram puts seconds into the first bucket whose upper
+// bound is greater than or equal to it.
+func observeLatencyHistogram(h *types.LatencyHistogram, seconds float64) {
+	h.Counts[sort.SearchFloat64s(h.Buckets, seconds)]++
+	h.Sum += seconds
+}
+
+// BuildLatencyHistogram builds histogram from raw latencies in seconds.
+func BuildLatencyHistogram(buckets []float64, latencies []float64) types.LatencyHistogram {
+	h := newLatencyHistogram(buckets)
+	for _, l := range latencies {
+		observeLatencyHistogram(h, l)
+	}
+	return *h
+}
+
+// MergeLatencyHistogram adds src into dst. It returns error if they have
+// different buckets.
+func MergeLatencyHistogram(dst *types.LatencyHistogram, src types.LatencyHistogram) error {
+	if len(dst.Buckets) == 0 && len(dst.Counts) == 0 {
+		dst.Buckets = append([]float64{}, src.Buckets...)
+		dst.Counts = append([]int64{}, src.Counts...)
+		dst.Sum = src.Sum
+		return nil
+	}
+
+	if len(dst.Buckets) != len(src.Buckets) || len(dst.Counts) != len(src.Counts) {
+		return fmt.Errorf("unmatched buckets: %v vs %v", dst.Buckets, src.Buckets)
+	}
+	for idx := range dst.Buckets {
+		if dst.Buckets[idx] != src.Buckets[idx] {
+			return fmt.Errorf("unmatched buckets: %v vs %v", dst.Buckets, src.Buckets)
+		}
+	}
+
+	for idx := range dst.Counts {
+		dst.Counts[idx] += src.Counts[idx]
+	}
+	dst.Sum += src.Sum
+	return nil
+}
+
+// LatencyHistogramTotal returns the number of latencies in histogram.
+func LatencyHistogramTotal(h types.LatencyHistogram) int64 {
+	total := int64(0)
+	for _, c := range h.Counts {
+		total += c
+	}
+	return total
+}
+
+// BuildPercentileLatenciesFromHistogram estimates percentile latencies from
+// histogram by linear interpolation within the bucket, like Prometheus's
+// histogram_quantile. The latency in +Inf bucket is reported as the largest
+// upper bound. The empty percentiles means DefaultPercentiles.
+func BuildPercentileLatenciesFromHistogram(h types.LatencyHistogram, percentiles []float64) [][2]float64 {
+	total := LatencyHistogramTotal(h)
+	if total == 0 {
+		return nil
+	}
+
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+
+	res := make([][2]float64, len(percentiles))
+	for pi, pv := range percentiles {
+		rank := pv * float64(total)
+
+		cumulative := int64(0)
+		for idx, c := range h.Counts {
+			if c == 0 {
+				continue
+			}
+
+			if float64(cumulative+c) < rank {
+				cumulative += c
+				continue
+			}
+
+			lower := 0.0
+			if idx > 0 {
+				lower = h.Buckets[idx-1]
+			}
+			if idx == len(h.Buckets) {
+				res[pi] = [2]float64{pv, lower}
+				break
+			}
+
+			upper := h.Buckets[idx]
+			res[pi] = [2]float64{pv, lower + (upper-lower)*(rank-float64(cumulative))/float64(c)}
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	buckets := []float64{0.1, 0.2, 0.5}
+
+	h := BuildLatencyHistogram(buckets, []float64{0.05, 0.1, 0.15, 0.3, 0.4, 1})
+	assert.Equal(t, []int64{2, 1, 2, 1}, h.Counts)
+	assert.InDelta(t, 2.0, h.Sum, 1e-9)
+
+	// merge runners
+	all := types.LatencyHistogram{}
+	require.NoError(t, MergeLatencyHistogram(&all, h))
+	require.NoError(t, MergeLatencyHistogram(&all, BuildLatencyHistogram(buckets, []float64{0.01, 0.02})))
+	assert.Equal(t, []int64{4, 1, 2, 1}, all.Counts)
+	assert.Equal(t, int64(8), LatencyHistogramTotal(all))
+	// source isn't changed
+	assert.Equal(t, []int64{2, 1, 2, 1}, h.Counts)
+
+	assert.Error(t, MergeLatencyHistogram(&all, BuildLatencyHistogram([]float64{0.1, 0.3, 0.5}, nil)))
+	assert.Error(t, MergeLatencyHistogram(&all, BuildLatencyHistogram([]float64{0.1}, nil)))
+
+	res := BuildPercentileLatenciesFromHistogram(all, []float64{0, 0.25, 0.5, 0.75, 1})
+	assert.Equal(t, [2]float64{0, 0}, res[0])
+	assert.InDelta(t, 0.05, res[1][1], 1e-9)
+	assert.InDelta(t, 0.1, res[2][1], 1e-9)
+	assert.InDelta(t, 0.35, res[3][1], 1e-9)
+	// +Inf bucket is reported as the largest upper bound.
+	assert.Equal(t, [2]float64{1, 0.5}, res[4])
+
+	assert.Len(t, BuildPercentileLatenciesFromHistogram(all, nil), len(DefaultPercentiles))
+	assert.Nil(t, BuildPercentileLatenciesFromHistogram(types.LatencyHistogram{}, nil))
+}
+
+func TestResponseMetric_LatencyHistogram(t *testing.T) {
+	m := NewResponseMetric(WithLatencyHistogramOpt([]float64{0.1, 1}))
+	m.ObserveLatency("GET", "a", 0.05)
+	m.ObserveLatency("GET", "a", 0.5)
+	m.ObserveLatency("LIST", "b", 2)
+
+	stats := m.Gather()
+	assert.Empty(t, stats.LatenciesByURL)
+	assert.Equal(t, map[string]types.LatencyHistogram{
+		"a": {Buckets: []float64{0.1, 1}, Counts: []int64{1, 1, 0}, Sum: 0.55},
+		"b": {Buckets: []float64{0.1, 1}, Counts: []int64{0, 0, 1}, Sum: 2},
+	}, stats.LatencyHistogramsByURL)
+	assert.Equal(t, map[string]int{"GET": 2, "LIST": 1}, stats.TotalByMethod)
+	assert.Equal(t, map[string]types.LatencyHistogram{
+		"GET":  {Buckets: []float64{0.1, 1}, Counts: []int64{1, 1, 0}, Sum: 0.55},
+		"LIST": {Buckets: []float64{0.1, 1}, Counts: []int64{0, 0, 1}, Sum: 2},
+	}, stats.LatencyHistogramsByMethod)
+
+	// raw latencies by default
+	m = NewResponseMetric()
+	m.ObserveLatency("GET", "a", 0.05)
+	stats = m.Gather()
+	assert.Equal(t, map[string][]float64{"a": {0.05}}, stats.LatenciesByURL)
+	assert.Nil(t, stats.LatencyHistogramsByURL)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// promQuantileObjectives is the quantiles with absolute errors of live
+// latency summary.
+var promQuantileObjectives = map[float64]float64{
+	0.5:  0.05,
+	0.9:  0.01,
+	0.99: 0.001,
+}
+
+// promCollectors exports live ResponseMetric in prometheus format. The URL
+// isn't used as label because of high cardinality.
+type promCollectors struct {
+	requests      *prometheus.CounterVec
+	failures      *prometheus.CounterVec
+	latencies     *prometheus.HistogramVec
+	quantiles     *prometheus.SummaryVec
+	receivedBytes *prometheus.CounterVec
+	// instanceLatencies uses kube-apiserver instance as label, whose
+	// cardinality is the number of replicas.
+	instanceLatencies *prometheus.HistogramVec
+}
+
+func newPromCollectors() *promCollectors {
+	return &promCollectors{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kperf",
+			Name:      "requests_total",
+			Help:      "The number of completed requests.",
+		}, []string{"method"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kperf",
+			Name:      "request_failures_total",
+			Help:      "The number of failed requests.",
+		}, []string{"method"}),
+		latencies: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "kperf",
+			Name:      "request_duration_seconds",
+			Help:      "The latency of successful requests in seconds.",
+			Buckets:   DefaultLatencyBuckets,
+		}, []string{"method"}),
+		quantiles: prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Namespace:  "kperf",
+			Name:       "request_duration_quantile_seconds",
+			Help:       "The latency quantiles of successful requests in seconds.",
+			Objectives: promQuantileObjectives,
+		}, []string{"method"}),
+		receivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kperf",
+			Name:      "received_bytes_total",
+			Help:      "The bytes read from apiserver.",
+		}, []string{"method"}),
+		instanceLatencies: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "kperf",
+			Name:      "request_instance_duration_seconds",
+			Help:      "The latency of successful requests in seconds for each apiserver instance.",
+			Buckets:   DefaultLatencyBuckets,
+		}, []string{"instance"}),
+	}
+}
+
+// collectors returns all the collectors.
+func (c *promCollectors) collectors() []prometheus.Collector {
+	return []prometheus.Collector{c.requests, c.failures, c.latencies, c.quantiles, c.receivedBytes, c.instanceLatencies}
+}
+
+// observeLatency observes the latency of successful request.
+func (c *promCollectors) observeLatency(method string, seconds float64) {
+	c.requests.WithLabelValues(method).Inc()
+	c.latencies.WithLabelValues(method).Observe(seconds)
+	c.quantiles.WithLabelValues(method).Observe(seconds)
+}
+
+// observeInstanceLatency observes the latency of successful request served
+// by the kube-apiserver instance.
+func (c *promCollectors) observeInstanceLatency(instance string, seconds float64) {
+	c.instanceLatencies.WithLabelValues(instance).Observe(seconds)
+}
+
+// WithPrometheusRegistererOpt registers the live request counters and
+// latencies to reg as well, so that they can be scraped with other metrics
+// during the run. It panics if the collectors are already registered.
+func WithPrometheusRegistererOpt(reg prometheus.Registerer) ResponseMetricOpt {
+	return func(m *responseMetricImpl) {
+		reg.MustRegister(m.prom.collectors()...)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseMetric_PrometheusRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := NewResponseMetric(WithPrometheusRegistererOpt(reg))
+	m.ObserveLatency("GET", "/api/v1/pods/x", 0.1)
+	m.ObserveLatency("GET", "/api/v1/pods/y", 0.2)
+	m.ObserveFailure("LIST", "/api/v1/pods", time.Now(), 1, fmt.Errorf("unknown"))
+	m.ObserveReceivedBytes("GET", 100)
+
+	values := gatherPromValues(t, reg)
+	assert.Equal(t, map[string]float64{
+		"kperf_requests_total/GET":                    2,
+		"kperf_requests_total/LIST":                   1,
+		"kperf_request_failures_total/LIST":           1,
+		"kperf_request_duration_seconds/GET":          2,
+		"kperf_request_duration_quantile_seconds/GET": 2,
+		"kperf_received_bytes_total/GET":              100,
+	}, values)
+	assert.Equal(t, values, gatherPromValues(t, m.Registry()))
+
+	// The collectors can't be registered twice.
+	assert.Panics(t, func() { NewResponseMetric(WithPrometheusRegistererOpt(reg)) })
+}
+
+func TestResponseMetric_RegistryWithConcurrentGather(t *testing.T) {
+	m := NewResponseMetric()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				m.ObserveLatency("GET", "/api/v1/pods/x", 0.1)
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		m.Gather()
+		gatherPromValues(t, m.Registry())
+	}
+	wg.Wait()
+
+	// NOTE: Gather doesn't re-register anything so that the registry
+	// keeps all the observations.
+	assert.Equal(t, 400, m.Gather().TotalByMethod["GET"])
+	assert.Equal(t, float64(400), gatherPromValues(t, m.Registry())["kperf_requests_total/GET"])
+}
+
+// gatherPromValues returns counter values and the sample counts of
+// histograms and summaries by "name/method".
+func gatherPromValues(t *testing.T, g prometheus.Gatherer) map[string]float64 {
+	families, err := g.Gather()
+	require.NoError(t, err)
+
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			key := f.GetName() + "/" + metric.GetLabel()[0].GetValue()
+			switch {
+			case metric.GetCounter() != nil:
+				values[key] = metric.GetCounter().GetValue()
+			case metric.GetHistogram() != nil:
+				values[key] = float64(metric.GetHistogram().GetSampleCount())
+			case metric.GetSummary() != nil:
+				values[key] = float64(metric.GetSummary().GetSampleCount())
+			}
+		}
+	}
+	return values
+}
@@ -5,54 +5,186 @@ package metrics
 
 import (
 	"container/list"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/Azure/kperf/api/types"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ResponseMetric is a measurement related to http response.
 type ResponseMetric interface {
 	// ObserveLatency observes latency.
-	ObserveLatency(url string, seconds float64)
+	ObserveLatency(method string, url string, seconds float64)
 	// ObserveFailure observes failure response.
-	ObserveFailure(url string, now time.Time, seconds float64, err error)
+	ObserveFailure(method string, url string, now time.Time, seconds float64, err error)
 	// ObserveReceivedBytes observes the bytes read from apiserver.
-	ObserveReceivedBytes(bytes int64)
+	ObserveReceivedBytes(method string, bytes int64)
+	// ObserveSentBytes observes the bytes of request body sent to
+	// apiserver.
+	ObserveSentBytes(bytes int64)
+	// ObserveWatchEvents observes the events received by one watch stream.
+	// The timeToFirstEvent in seconds is ignored if there is no event.
+	// The partial means that the stream was closed before the initial
+	// events end.
+	ObserveWatchEvents(eventsByType map[string]int64, timeToFirstEvent float64, partial bool)
+	// ObserveWatchReconnects observes the reconnect latencies in seconds
+	// of one resumed watch stream.
+	ObserveWatchReconnects(latencies []float64)
+	// ObserveTimeToFirstByte observes the time in seconds from sending
+	// request to receiving response headers.
+	ObserveTimeToFirstByte(seconds float64)
+	// ObserveInstanceLatency observes latency of successful request
+	// served by the kube-apiserver instance.
+	ObserveInstanceLatency(instance string, seconds float64)
 	// Gather returns the summary.
 	Gather() types.ResponseStats
+	// GatherErrorClasses returns the number of failures for each error
+	// class, like timeout or throttled.
+	GatherErrorClasses() map[string]int
+	// ExportDistribution writes the latency distribution, bucket upper
+	// bound to count, in csv or json format.
+	ExportDistribution(w io.Writer, format string) error
+	// Registry returns the prometheus registry which is created once with
+	// ResponseMetric and updated by each observation, so that it can be
+	// scraped during the run.
+	Registry() prometheus.Gatherer
+}
+
+// ResponseMetricOpt is used to update default ResponseMetric setting.
+type ResponseMetricOpt func(*responseMetricImpl)
+
+// WithMaxFailureSamplesOpt sets the maximum number of recent failures to
+// keep. The older failures are dropped but still counted in error stats.
+// The value <= 0 means no limit.
+func WithMaxFailureSamplesOpt(n int) ResponseMetricOpt {
+	return func(m *responseMetricImpl) {
+		m.maxFailureSamples = n
+	}
+}
+
+// WithLatencyHistogramOpt records latencies in histogram with the given
+// buckets instead of keeping all the raw latencies. The empty buckets means
+// DefaultLatencyBuckets.
+func WithLatencyHistogramOpt(buckets []float64) ResponseMetricOpt {
+	return func(m *responseMetricImpl) {
+		if len(buckets) == 0 {
+			buckets = DefaultLatencyBuckets
+		}
+		m.latencyBuckets = buckets
+	}
+}
+
+// maxInstances is the maximum number of distinct kube-apiserver instances
+// whose latencies are reported separately. The others are reported as
+// OtherInstance.
+const maxInstances = 32
+
+// OtherInstance is the instance of latencies served by the instances beyond
+// maxInstances.
+const OtherInstance = "other"
+
+// latencyKey identifies latencies by request's method and URL so that they
+// can be reported by URL or by method.
+type latencyKey struct {
+	method string
+	url    string
 }
 
 type responseMetricImpl struct {
-	mu              sync.Mutex
-	errors          *list.List
-	receivedBytes   int64
-	latenciesByURLs map[string]*list.List
+	mu                    sync.Mutex
+	errors                *list.List
+	maxFailureSamples     int
+	errorStats            map[string]int32
+	receivedBytes         int64
+	receivedBytesByMethod map[string]int64
+	sentBytes             int64
+	latencies             map[latencyKey]*list.List
+	latencyBuckets        []float64
+	histograms            map[latencyKey]*types.LatencyHistogram
+	totalByMethod         map[string]int
+	failuresByMethod      map[string]int
+	failuresByCode        map[int]int
+	errorClasses          map[string]int
+	watchEventsByType     map[string]int64
+	timesToFirstEvent     []float64
+	partialWatches        int
+	reconnectLatencies    []float64
+	timesToFirstByte      []float64
+	latenciesByInstance   map[string][]float64
+	instances             map[string]struct{}
+	reservoir             *latencyReservoir
+	reservoirSize         int
+	reservoirRnd          RandSource
+	ttfbReservoir         *latencyReservoir
+	instanceReservoirs    map[string]*latencyReservoir
+	prom                  *promCollectors
+	registry              *prometheus.Registry
 }
 
-func NewResponseMetric() ResponseMetric {
-	return &responseMetricImpl{
-		errors:          list.New(),
-		latenciesByURLs: map[string]*list.List{},
+func NewResponseMetric(opts ...ResponseMetricOpt) ResponseMetric {
+	m := &responseMetricImpl{
+		errors:                list.New(),
+		errorStats:            map[string]int32{},
+		receivedBytesByMethod: map[string]int64{},
+		latencies:             map[latencyKey]*list.List{},
+		histograms:            map[latencyKey]*types.LatencyHistogram{},
+		totalByMethod:         map[string]int{},
+		failuresByMethod:      map[string]int{},
+		failuresByCode:        map[int]int{},
+		errorClasses:          map[string]int{},
+		watchEventsByType:     map[string]int64{},
+		latenciesByInstance:   map[string][]float64{},
+		instances:             map[string]struct{}{},
+		prom:                  newPromCollectors(),
+		registry:              prometheus.NewRegistry(),
 	}
+	m.registry.MustRegister(m.prom.collectors()...)
+
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.reservoir != nil && m.latencyBuckets == nil {
+		m.latencyBuckets = DefaultLatencyBuckets
+	}
+	return m
 }
 
 // ObserveLatency implements ResponseMetric.
-func (m *responseMetricImpl) ObserveLatency(url string, seconds float64) {
+func (m *responseMetricImpl) ObserveLatency(method string, url string, seconds float64) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	l, ok := m.latenciesByURLs[url]
+	m.totalByMethod[method]++
+	m.prom.observeLatency(method, seconds)
+	if m.reservoir != nil {
+		m.reservoir.observe(seconds)
+	}
+
+	key := latencyKey{method: method, url: url}
+	if m.latencyBuckets != nil {
+		h, ok := m.histograms[key]
+		if !ok {
+			h = newLatencyHistogram(m.latencyBuckets)
+			m.histograms[key] = h
+		}
+		observeLatencyHistogram(h, seconds)
+		return
+	}
+
+	l, ok := m.latencies[key]
 	if !ok {
-		m.latenciesByURLs[url] = list.New()
-		l = m.latenciesByURLs[url]
+		l = list.New()
+		m.latencies[key] = l
 	}
 	l.PushBack(seconds)
 }
 
 // ObserveFailure implements ResponseMetric.
-func (m *responseMetricImpl) ObserveFailure(url string, now time.Time, seconds float64, err error) {
+func (m *responseMetricImpl) ObserveFailure(method string, url string, now time.Time, seconds float64, err error) {
 	if err == nil {
 		return
 	}
@@ -60,23 +192,33 @@ func (m *responseMetricImpl) ObserveFailure(url string, now time.Time, seconds f
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.totalByMethod[method]++
+	m.failuresByMethod[method]++
+	m.prom.requests.WithLabelValues(method).Inc()
+	m.prom.failures.WithLabelValues(method).Inc()
+
 	oerr := types.ResponseError{
 		URL:       url,
 		Timestamp: now,
 		Duration:  seconds,
 	}
 
-	// HTTP Code -> HTTP2 -> Connection -> Unknown
+	// HTTP Code -> HTTP2 -> Decode -> Connection -> Unknown
 	code := codeFromHTTP(err)
 	http2Err, isHTTP2Err := isHTTP2Error(err)
+	decodeErr, isDecodeErr := isDecodeError(err)
 	connErr, isConnErr := isConnectionError(err)
 	switch {
 	case code != 0:
 		oerr.Type = types.ResponseErrorTypeHTTP
 		oerr.Code = code
+		m.failuresByCode[code]++
 	case isHTTP2Err:
 		oerr.Type = types.ResponseErrorTypeHTTP2Protocol
 		oerr.Message = http2Err
+	case isDecodeErr:
+		oerr.Type = types.ResponseErrorTypeDecode
+		oerr.Message = decodeErr
 	case isConnErr:
 		oerr.Type = types.ResponseErrorTypeConnection
 		oerr.Message = connErr
@@ -84,33 +226,262 @@ func (m *responseMetricImpl) ObserveFailure(url string, now time.Time, seconds f
 		oerr.Type = types.ResponseErrorTypeUnknown
 		oerr.Message = err.Error()
 	}
+	class := classifyError(err)
+	m.errorStats[errorStatKey(oerr, class)]++
+	m.errorClasses[class]++
+
 	m.errors.PushBack(oerr)
+	if m.maxFailureSamples > 0 && m.errors.Len() > m.maxFailureSamples {
+		m.errors.Remove(m.errors.Front())
+	}
 }
 
 // ObserveReceivedBytes implements ResponseMetric.
-func (m *responseMetricImpl) ObserveReceivedBytes(bytes int64) {
+func (m *responseMetricImpl) ObserveReceivedBytes(method string, bytes int64) {
 	atomic.AddInt64(&m.receivedBytes, bytes)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.receivedBytesByMethod[method] += bytes
+	m.prom.receivedBytes.WithLabelValues(method).Add(float64(bytes))
+}
+
+// ObserveSentBytes implements ResponseMetric.
+func (m *responseMetricImpl) ObserveSentBytes(bytes int64) {
+	atomic.AddInt64(&m.sentBytes, bytes)
+}
+
+// ObserveWatchEvents implements ResponseMetric.
+func (m *responseMetricImpl) ObserveWatchEvents(eventsByType map[string]int64, timeToFirstEvent float64, partial bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	total := int64(0)
+	for typ, n := range eventsByType {
+		m.watchEventsByType[typ] += n
+		total += n
+	}
+	if total > 0 {
+		m.timesToFirstEvent = append(m.timesToFirstEvent, timeToFirstEvent)
+	}
+	if partial {
+		m.partialWatches++
+	}
+}
+
+// ObserveWatchReconnects implements ResponseMetric.
+func (m *responseMetricImpl) ObserveWatchReconnects(latencies []float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnectLatencies = append(m.reconnectLatencies, latencies...)
+}
+
+// ObserveTimeToFirstByte implements ResponseMetric.
+func (m *responseMetricImpl) ObserveTimeToFirstByte(seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttfbReservoir != nil {
+		m.ttfbReservoir.observe(seconds)
+		return
+	}
+	m.timesToFirstByte = append(m.timesToFirstByte, seconds)
+}
+
+// ObserveInstanceLatency implements ResponseMetric.
+func (m *responseMetricImpl) ObserveInstanceLatency(instance string, seconds float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// NOTE: The header might be set per request, like Audit-Id, by
+	// mistake. Cap the distinct instances so that both the report and the
+	// prometheus labels stay bounded.
+	if _, ok := m.instances[instance]; !ok {
+		if len(m.instances) >= maxInstances {
+			instance = OtherInstance
+		} else {
+			m.instances[instance] = struct{}{}
+		}
+	}
+
+	m.prom.observeInstanceLatency(instance, seconds)
+	if m.instanceReservoirs != nil {
+		r, ok := m.instanceReservoirs[instance]
+		if !ok {
+			r = m.newReservoir()
+			m.instanceReservoirs[instance] = r
+		}
+		r.observe(seconds)
+		return
+	}
+	m.latenciesByInstance[instance] = append(m.latenciesByInstance[instance], seconds)
 }
 
 // Gather implements ResponseMetric.
 func (m *responseMetricImpl) Gather() types.ResponseStats {
 	return types.ResponseStats{
 		Errors:             m.dumpErrors(),
-		LatenciesByURL:     m.dumpLatencies(),
+		ErrorStats:         m.dumpErrorStats(),
+		ErrorClasses:       m.GatherErrorClasses(),
+		LatenciesByURL:     m.dumpLatencies(byURL),
 		TotalReceivedBytes: atomic.LoadInt64(&m.receivedBytes),
+		TotalSentBytes:     atomic.LoadInt64(&m.sentBytes),
+		TotalByMethod:      m.dumpCounts(m.totalByMethod),
+		FailuresByMethod:   m.dumpCounts(m.failuresByMethod),
+
+		FailuresByStatusCode:      m.dumpCodeCounts(),
+		LatenciesByMethod:         m.dumpLatencies(byMethod),
+		LatencyHistogramsByURL:    m.dumpHistograms(byURL),
+		LatencyHistogramsByMethod: m.dumpHistograms(byMethod),
+		ReceivedBytesByMethod:     m.dumpReceivedBytes(),
+		WatchStats:                m.dumpWatchStats(),
+		TimesToFirstByte:          m.dumpTimesToFirstByte(),
+		LatenciesByInstance:       m.dumpLatenciesByInstance(),
 	}
 }
 
-func (m *responseMetricImpl) dumpLatencies() map[string][]float64 {
+func (m *responseMetricImpl) dumpWatchStats() *types.WatchStats {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	res := make(map[string][]float64)
-	for u, latencies := range m.latenciesByURLs {
-		res[u] = make([]float64, 0, latencies.Len())
+	if len(m.watchEventsByType) == 0 && m.partialWatches == 0 && len(m.reconnectLatencies) == 0 {
+		return nil
+	}
 
+	res := &types.WatchStats{
+		EventsByType:      make(map[string]int64, len(m.watchEventsByType)),
+		TimesToFirstEvent: append([]float64(nil), m.timesToFirstEvent...),
+		PartialWatches:    m.partialWatches,
+	}
+	if len(m.reconnectLatencies) > 0 {
+		res.Reconnects = len(m.reconnectLatencies)
+		res.ReconnectLatencies = append([]float64(nil), m.reconnectLatencies...)
+	}
+	for typ, n := range m.watchEventsByType {
+		res.EventsByType[typ] = n
+	}
+	return res
+}
+
+func (m *responseMetricImpl) dumpTimesToFirstByte() []float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ttfbReservoir != nil {
+		return append([]float64(nil), m.ttfbReservoir.samples...)
+	}
+	return append([]float64(nil), m.timesToFirstByte...)
+}
+
+func (m *responseMetricImpl) dumpLatenciesByInstance() map[string][]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.instanceReservoirs != nil {
+		if len(m.instanceReservoirs) == 0 {
+			return nil
+		}
+		res := make(map[string][]float64, len(m.instanceReservoirs))
+		for instance, r := range m.instanceReservoirs {
+			res[instance] = append([]float64(nil), r.samples...)
+		}
+		return res
+	}
+
+	if len(m.latenciesByInstance) == 0 {
+		return nil
+	}
+
+	res := make(map[string][]float64, len(m.latenciesByInstance))
+	for instance, l := range m.latenciesByInstance {
+		res[instance] = append([]float64(nil), l...)
+	}
+	return res
+}
+
+func byURL(key latencyKey) string { return key.url }
+
+func byMethod(key latencyKey) string { return key.method }
+
+func (m *responseMetricImpl) dumpHistograms(groupBy func(latencyKey) string) map[string]types.LatencyHistogram {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.histograms) == 0 {
+		return nil
+	}
+
+	res := make(map[string]types.LatencyHistogram)
+	for key, h := range m.histograms {
+		dst := res[groupBy(key)]
+		// NOTE: All the histograms share the same buckets.
+		_ = MergeLatencyHistogram(&dst, *h)
+		res[groupBy(key)] = dst
+	}
+	return res
+}
+
+func (m *responseMetricImpl) dumpReceivedBytes() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string]int64, len(m.receivedBytesByMethod))
+	for k, v := range m.receivedBytesByMethod {
+		res[k] = v
+	}
+	return res
+}
+
+func (m *responseMetricImpl) dumpCodeCounts() map[int]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[int]int, len(m.failuresByCode))
+	for k, v := range m.failuresByCode {
+		res[k] = v
+	}
+	return res
+}
+
+// Registry implements ResponseMetric.
+func (m *responseMetricImpl) Registry() prometheus.Gatherer {
+	return m.registry
+}
+
+// GatherErrorClasses implements ResponseMetric.
+func (m *responseMetricImpl) GatherErrorClasses() map[string]int {
+	return m.dumpCounts(m.errorClasses)
+}
+
+func (m *responseMetricImpl) dumpCounts(counts map[string]int) map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string]int, len(counts))
+	for k, v := range counts {
+		res[k] = v
+	}
+	return res
+}
+
+func (m *responseMetricImpl) dumpErrorStats() map[string]int32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string]int32, len(m.errorStats))
+	for k, v := range m.errorStats {
+		res[k] = v
+	}
+	return res
+}
+
+func (m *responseMetricImpl) dumpLatencies(groupBy func(latencyKey) string) map[string][]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res := make(map[string][]float64)
+	for key, latencies := range m.latencies {
+		k := groupBy(key)
 		for e := latencies.Front(); e != nil; e = e.Next() {
-			res[u] = append(res[u], e.Value.(float64))
+			res[k] = append(res[k], e.Value.(float64))
 		}
 	}
 	return res
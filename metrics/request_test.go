@@ -8,6 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/url"
+	"os"
 	"syscall"
 	"testing"
 	"time"
@@ -17,6 +20,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/http2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestResponseMetric_ObserveFailure(t *testing.T) {
@@ -126,6 +131,13 @@ func TestResponseMetric_ObserveFailure(t *testing.T) {
 			URL:       "14",
 			Timestamp: observedAt,
 			Duration:  dur.Seconds(),
+			Type:      types.ResponseErrorTypeDecode,
+			Message:   "invalid object",
+		},
+		{
+			URL:       "15",
+			Timestamp: observedAt,
+			Duration:  dur.Seconds(),
 			Type:      types.ResponseErrorTypeUnknown,
 			Message:   "unknown",
 		},
@@ -160,14 +172,172 @@ func TestResponseMetric_ObserveFailure(t *testing.T) {
 		fmt.Errorf("oops: %w", syscall.ECONNRESET),
 		fmt.Errorf("oops: %w", syscall.ECONNREFUSED),
 		fmt.Errorf("oops: %w", io.ErrUnexpectedEOF),
+		// decode
+		&DecodeError{Reason: "invalid object", Err: io.ErrUnexpectedEOF},
 		// unknown
 		fmt.Errorf("unknown"),
 	}
 
 	m := NewResponseMetric()
 	for idx, err := range errs {
-		m.ObserveFailure(fmt.Sprintf("%d", idx), observedAt, dur.Seconds(), err)
+		m.ObserveFailure("GET", fmt.Sprintf("%d", idx), observedAt, dur.Seconds(), err)
+	}
+	stats := m.Gather()
+	assert.Equal(t, expectedErrors, stats.Errors)
+	assert.Equal(t, map[string]int{"GET": len(errs)}, stats.TotalByMethod)
+	assert.Equal(t, map[string]int{"GET": len(errs)}, stats.FailuresByMethod)
+	assert.Equal(t, map[int]int{429: 1, 500: 1, 504: 1}, stats.FailuresByStatusCode)
+}
+
+func TestResponseMetric_MaxFailureSamples(t *testing.T) {
+	observedAt := time.Now()
+
+	m := NewResponseMetric(WithMaxFailureSamplesOpt(3))
+	for idx := 0; idx < 10; idx++ {
+		m.ObserveFailure("GET", fmt.Sprintf("%d", idx), observedAt, 1, apierrors.NewTooManyRequests("oops", 1))
+	}
+	m.ObserveFailure("GET", "10", observedAt, 1, fmt.Errorf("unknown"))
+	m.ObserveFailure("GET", "11", observedAt, 1, fmt.Errorf("unknown 2"))
+
+	stats := m.Gather()
+	urls := make([]string, 0, len(stats.Errors))
+	for _, e := range stats.Errors {
+		urls = append(urls, e.URL)
+	}
+	assert.Equal(t, []string{"9", "10", "11"}, urls)
+	assert.Equal(t, map[string]int32{
+		"http/429":      10,
+		"unknown/other": 2,
+	}, stats.ErrorStats)
+	assert.Equal(t, map[string]int{"GET": 12}, stats.FailuresByMethod)
+	assert.Equal(t, map[int]int{429: 10}, stats.FailuresByStatusCode)
+}
+
+func TestResponseMetric_GatherErrorClasses(t *testing.T) {
+	errs := []error{
+		// throttled
+		apierrors.NewTooManyRequests("oops", 1),
+		fmt.Errorf("wrapped: %w", apierrors.NewTooManyRequests("oops", 1)),
+		// canceled
+		context.Canceled,
+		&url.Error{Op: "Get", URL: "x", Err: context.Canceled},
+		// timeout
+		context.DeadlineExceeded,
+		&url.Error{Op: "Get", URL: "x", Err: os.ErrDeadlineExceeded},
+		apierrors.NewTimeoutError("oops", 1),
+		// connection-refused
+		&url.Error{Op: "Get", URL: "x", Err: &net.OpError{
+			Op:  "dial",
+			Err: os.NewSyscallError("connect", syscall.ECONNREFUSED),
+		}},
+		// unauthorized
+		apierrors.NewUnauthorized("oops"),
+		apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "x", fmt.Errorf("oops")),
+		// conflict
+		apierrors.NewApplyConflict([]metav1.StatusCause{{Field: ".data.a"}}, "oops"),
+		// other
+		apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "x"),
+		io.ErrUnexpectedEOF,
+	}
+
+	m := NewResponseMetric()
+	for idx, err := range errs {
+		m.ObserveFailure("GET", fmt.Sprintf("%d", idx), time.Now(), 1, err)
+	}
+	assert.Equal(t, map[string]int{
+		ErrorClassThrottled:         2,
+		ErrorClassCanceled:          2,
+		ErrorClassTimeout:           3,
+		ErrorClassConnectionRefused: 1,
+		ErrorClassUnauthorized:      2,
+		ErrorClassConflict:          1,
+		ErrorClassOther:             2,
+	}, m.GatherErrorClasses())
+	assert.Equal(t, m.GatherErrorClasses(), m.Gather().ErrorClasses)
+}
+
+func TestResponseMetric_ByMethod(t *testing.T) {
+	m := NewResponseMetric()
+	m.ObserveLatency("GET", "/api/v1/pods/x", 1)
+	m.ObserveLatency("PATCH", "/api/v1/pods/x", 2)
+	m.ObserveLatency("LIST", "/api/v1/pods", 3)
+	m.ObserveLatency("WATCHLIST", "/api/v1/pods", 4)
+	m.ObserveReceivedBytes("LIST", 100)
+	m.ObserveReceivedBytes("LIST", 50)
+	m.ObserveReceivedBytes("GET", 10)
+
+	stats := m.Gather()
+	assert.ElementsMatch(t, []float64{1, 2}, stats.LatenciesByURL["/api/v1/pods/x"])
+	assert.ElementsMatch(t, []float64{3, 4}, stats.LatenciesByURL["/api/v1/pods"])
+	assert.Equal(t, map[string][]float64{
+		"GET":       {1},
+		"PATCH":     {2},
+		"LIST":      {3},
+		"WATCHLIST": {4},
+	}, stats.LatenciesByMethod)
+	assert.Equal(t, map[string]int64{"LIST": 150, "GET": 10}, stats.ReceivedBytesByMethod)
+	assert.Equal(t, int64(160), stats.TotalReceivedBytes)
+}
+
+func TestResponseMetric_ObserveTimeToFirstByte(t *testing.T) {
+	m := NewResponseMetric()
+	assert.Empty(t, m.Gather().TimesToFirstByte)
+
+	m.ObserveTimeToFirstByte(0.1)
+	m.ObserveTimeToFirstByte(0.2)
+	assert.Equal(t, []float64{0.1, 0.2}, m.Gather().TimesToFirstByte)
+}
+
+func TestResponseMetric_ObserveInstanceLatencyCapsInstances(t *testing.T) {
+	m := NewResponseMetric()
+	for i := 0; i < maxInstances+10; i++ {
+		m.ObserveInstanceLatency(fmt.Sprintf("audit-%d", i), 0.1)
+	}
+	// NOTE: The known instance is still reported by itself.
+	m.ObserveInstanceLatency("audit-0", 0.2)
+
+	latencies := m.Gather().LatenciesByInstance
+	assert.Len(t, latencies, maxInstances+1)
+	assert.Equal(t, []float64{0.1, 0.2}, latencies["audit-0"])
+	assert.Len(t, latencies[OtherInstance], 10)
+}
+
+func TestResponseMetric_ObserveSentBytes(t *testing.T) {
+	m := NewResponseMetric()
+	m.ObserveSentBytes(100)
+	m.ObserveSentBytes(0)
+	m.ObserveSentBytes(20)
+
+	assert.Equal(t, int64(120), m.Gather().TotalSentBytes)
+}
+
+func TestResponseMetric_ObserveWatchEvents(t *testing.T) {
+	m := NewResponseMetric()
+	assert.Nil(t, m.Gather().WatchStats)
+
+	m.ObserveWatchEvents(map[string]int64{"ADDED": 2, "BOOKMARK": 1}, 0.1, false)
+	m.ObserveWatchEvents(map[string]int64{"ADDED": 1}, 0.2, true)
+	m.ObserveWatchEvents(map[string]int64{}, 0, true)
+
+	assert.Equal(t, &types.WatchStats{
+		EventsByType:      map[string]int64{"ADDED": 3, "BOOKMARK": 1},
+		TimesToFirstEvent: []float64{0.1, 0.2},
+		PartialWatches:    2,
+	}, m.Gather().WatchStats)
+}
+
+func TestResponseMetric_ObserveWatchReconnects(t *testing.T) {
+	m := NewResponseMetric()
+	m.ObserveWatchReconnects(nil)
+	assert.Nil(t, m.Gather().WatchStats)
+
+	m.ObserveWatchEvents(map[string]int64{"ADDED": 1, "BOOKMARK": 2}, 0.1, false)
+	m.ObserveWatchReconnects([]float64{0.01, 0.02})
+	m.ObserveWatchReconnects([]float64{0.03})
+
+	ws := m.Gather().WatchStats
+	if assert.NotNil(t, ws) {
+		assert.Equal(t, 3, ws.Reconnects)
+		assert.Equal(t, []float64{0.01, 0.02, 0.03}, ws.ReconnectLatencies)
 	}
-	errors := m.Gather().Errors
-	assert.Equal(t, expectedErrors, errors)
 }
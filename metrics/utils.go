@@ -4,6 +4,7 @@
 package metrics
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -19,13 +20,25 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
+// DefaultPercentiles is the default latency percentiles in report. The 0
+// means the minimum latency.
+var DefaultPercentiles = []float64{0, 0.5, 0.90, 0.95, 0.99, 1}
+
 // BuildPercentileLatencies builds percentile latencies.
 func BuildPercentileLatencies(latencies []float64) [][2]float64 {
+	return BuildPercentileLatenciesWithObjectives(latencies, nil)
+}
+
+// BuildPercentileLatenciesWithObjectives builds latencies for the given
+// percentiles in order. The empty percentiles means DefaultPercentiles.
+func BuildPercentileLatenciesWithObjectives(latencies []float64, percentiles []float64) [][2]float64 {
 	if len(latencies) == 0 {
 		return nil
 	}
 
-	var percentiles = []float64{0, 0.5, 0.90, 0.95, 0.99, 1}
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
 
 	res := make([][2]float64, len(percentiles))
 
@@ -41,23 +54,80 @@ func BuildPercentileLatencies(latencies []float64) [][2]float64 {
 	return res
 }
 
-// BuildErrorStatsGroupByType summaries total count for each type of errors.
-func BuildErrorStatsGroupByType(errors []types.ResponseError) map[string]int32 {
-	res := map[string]int32{}
+// errorStatKey returns the key of error stats for that error. The non-HTTP
+// errors are keyed by class instead of message, which might contain
+// addresses or object names, so that the number of keys is bounded.
+func errorStatKey(err types.ResponseError, class string) string {
+	switch err.Type {
+	case types.ResponseErrorTypeHTTP:
+		return fmt.Sprintf("%s/%d", err.Type, err.Code)
+	default:
+		return fmt.Sprintf("%s/%s", err.Type, class)
+	}
+}
+
+// BuildFailureThresholdViolations returns verbs whose failure rate exceeds
+// the given threshold.
+func BuildFailureThresholdViolations(totalByMethod, failuresByMethod map[string]int, maxFailureRateByVerb map[string]float64) []types.FailureThresholdViolation {
+	res := []types.FailureThresholdViolation{}
 
-	for _, err := range errors {
-		var key string
-		switch err.Type {
-		case types.ResponseErrorTypeHTTP:
-			key = fmt.Sprintf("%s/%d", err.Type, err.Code)
-		default:
-			key = fmt.Sprintf("%s/%s", err.Type, err.Message)
+	for verb, maxRate := range maxFailureRateByVerb {
+		method := strings.ToUpper(verb)
+
+		total := totalByMethod[method]
+		if total == 0 {
+			continue
+		}
+
+		rate := float64(failuresByMethod[method]) / float64(total)
+		if rate > maxRate {
+			res = append(res, types.FailureThresholdViolation{
+				Method:         method,
+				FailureRate:    rate,
+				MaxFailureRate: maxRate,
+			})
 		}
-		res[key]++
 	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Method < res[j].Method
+	})
 	return res
 }
 
+// BuildAmplificationFactor returns the ratio of HTTP attempts, including
+// retries, to logical requests. It returns zero if there is no request.
+func BuildAmplificationFactor(attempts int64, totalByMethod map[string]int) float64 {
+	total := 0
+	for _, n := range totalByMethod {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(attempts) / float64(total)
+}
+
+// DecodeError means that the response can't be decoded or the object
+// doesn't match the request.
+type DecodeError struct {
+	// Reason is the short description used in error stats, like
+	// "unexpected kind".
+	Reason string
+	// Err is the detail.
+	Err error
+}
+
+// Error implements error interface.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
 var (
 	// errHTTP2ClientConnectionLost is used to track unexported http2 error.
 	errHTTP2ClientConnectionLost = errors.New("http2: client connection lost")
@@ -85,7 +155,7 @@ func codeFromHTTP(err error) int {
 		return http.StatusMethodNotAllowed // 405
 	case apierrors.IsNotAcceptable(err):
 		return http.StatusNotAcceptable // 406
-	case apierrors.IsAlreadyExists(err):
+	case apierrors.IsAlreadyExists(err), apierrors.IsConflict(err):
 		return http.StatusConflict // 409
 	case apierrors.IsGone(err):
 		return http.StatusGone // 410
@@ -136,6 +206,15 @@ func isHTTP2Error(err error) (string, bool) {
 	return "", false
 }
 
+// isDecodeError returns true if it's DecodeError.
+func isDecodeError(err error) (string, bool) {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return decodeErr.Reason, true
+	}
+	return "", false
+}
+
 // isConnectionError returns true if it's related to connection error.
 func isConnectionError(err error) (string, bool) {
 	if err == nil {
@@ -160,6 +239,44 @@ func isConnectionError(err error) (string, bool) {
 	}
 }
 
+// Error classes group failures by the cause, regardless of transport.
+const (
+	ErrorClassTimeout           = "timeout"
+	ErrorClassConnectionRefused = "connection-refused"
+	ErrorClassThrottled         = "throttled"
+	ErrorClassUnauthorized      = "unauthorized"
+	ErrorClassCanceled          = "canceled"
+	ErrorClassConflict          = "conflict"
+	ErrorClassOther             = "other"
+)
+
+// classifyError returns the error class of err.
+func classifyError(err error) string {
+	switch {
+	case apierrors.IsTooManyRequests(err):
+		return ErrorClassThrottled
+	case IsAuthError(err):
+		return ErrorClassUnauthorized
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded), isTimeoutError(err),
+		apierrors.IsTimeout(err), apierrors.IsServerTimeout(err):
+		return ErrorClassTimeout
+	case isConnectionRefused(err):
+		return ErrorClassConnectionRefused
+	case apierrors.IsConflict(err):
+		return ErrorClassConflict
+	default:
+		return ErrorClassOther
+	}
+}
+
+// IsAuthError returns true if err is 401 Unauthorized or 403 Forbidden,
+// which is likely caused by expired credential in long runs.
+func IsAuthError(err error) bool {
+	return apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)
+}
+
 // isTimeoutError returns true if it's related to golang standard library
 // net's timeout error.
 func isTimeoutError(err error) bool {
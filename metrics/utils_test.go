@@ -6,6 +6,8 @@ package metrics
 import (
 	"testing"
 
+	"github.com/Azure/kperf/api/types"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,3 +36,41 @@ func TestBuildPercentileLatencies(t *testing.T) {
 	assert.Equal(t, [2]float64{0.99, 0}, res[4])
 	assert.Equal(t, [2]float64{1, 50}, res[5])
 }
+
+func TestBuildPercentileLatenciesWithObjectives(t *testing.T) {
+	ls := make([]float64, 0, 1000)
+	for i := 1; i <= 1000; i++ {
+		ls = append(ls, float64(i))
+	}
+
+	res := BuildPercentileLatenciesWithObjectives(ls, []float64{0.5, 0.999, 1})
+	assert.Equal(t, [][2]float64{{0.5, 500}, {0.999, 999}, {1, 1000}}, res)
+
+	res = BuildPercentileLatenciesWithObjectives(ls, nil)
+	assert.Len(t, res, len(DefaultPercentiles))
+
+	assert.Nil(t, BuildPercentileLatenciesWithObjectives(nil, []float64{0.5}))
+}
+
+func TestBuildFailureThresholdViolations(t *testing.T) {
+	totalByMethod := map[string]int{"GET": 100, "PATCH": 100, "DELETE": 10}
+	failuresByMethod := map[string]int{"GET": 2, "PATCH": 90, "DELETE": 10}
+
+	res := BuildFailureThresholdViolations(totalByMethod, failuresByMethod, map[string]float64{
+		"get":   0.01,
+		"PATCH": 0.95,
+		"POST":  0,
+	})
+	assert.Equal(t, []types.FailureThresholdViolation{
+		{Method: "GET", FailureRate: 0.02, MaxFailureRate: 0.01},
+	}, res)
+
+	res = BuildFailureThresholdViolations(totalByMethod, failuresByMethod, nil)
+	assert.Empty(t, res)
+}
+
+func TestBuildAmplificationFactor(t *testing.T) {
+	assert.Equal(t, float64(0), BuildAmplificationFactor(10, nil))
+	assert.Equal(t, float64(1), BuildAmplificationFactor(30, map[string]int{"GET": 20, "LIST": 10}))
+	assert.Equal(t, 2.5, BuildAmplificationFactor(25, map[string]int{"GET": 10}))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+// jitterBackoff implements rest.BackoffManager with exponential backoff and
+// full jitter for retries of one logical request. The delay before the n-th
+// retry is picked randomly in [0, min(max, base * 2^(n-1))).
+//
+// NOTE: client-go still waits for Retry-After if it's longer than the
+// backoff.
+type jitterBackoff struct {
+	base time.Duration
+	// max caps the backoff. Zero means no cap.
+	max time.Duration
+	rnd randSource
+
+	// failures is the number of consecutive failed attempts.
+	failures int
+}
+
+var _ rest.BackoffManager = (*jitterBackoff)(nil)
+
+func newJitterBackoff(base, max time.Duration, rnd randSource) *jitterBackoff {
+	return &jitterBackoff{base: base, max: max, rnd: rnd}
+}
+
+// UpdateBackoff implements rest.BackoffManager.
+func (b *jitterBackoff) UpdateBackoff(_ *url.URL, err error, responseCode int) {
+	if err != nil || responseCode == http.StatusTooManyRequests || responseCode >= http.StatusInternalServerError {
+		b.failures++
+		return
+	}
+	b.failures = 0
+}
+
+// CalculateBackoff implements rest.BackoffManager.
+func (b *jitterBackoff) CalculateBackoff(_ *url.URL) time.Duration {
+	if b.failures == 0 {
+		return 0
+	}
+
+	d := b.base
+	for i := 1; i < b.failures; i++ {
+		// NOTE: Stop doubling before overflow.
+		if (b.max > 0 && d >= b.max) || d > time.Duration(1<<62) {
+			break
+		}
+		d *= 2
+	}
+	if b.max > 0 && d > b.max {
+		d = b.max
+	}
+	return time.Duration(b.rnd.Int63n(int64(d)))
+}
+
+// Sleep implements rest.BackoffManager.
+func (b *jitterBackoff) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
@@ -0,0 +1,47 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// maxRandSource always returns the largest number.
+type maxRandSource struct{}
+
+func (maxRandSource) Int63n(n int64) int64 { return n - 1 }
+
+func TestJitterBackoff(t *testing.T) {
+	b := newJitterBackoff(100*time.Millisecond, time.Second, maxRandSource{})
+	assert.Equal(t, time.Duration(0), b.CalculateBackoff(nil))
+
+	expected := []time.Duration{
+		100*time.Millisecond - 1,
+		200*time.Millisecond - 1,
+		400*time.Millisecond - 1,
+		800*time.Millisecond - 1,
+		time.Second - 1,
+		time.Second - 1,
+	}
+	for _, d := range expected {
+		b.UpdateBackoff(nil, nil, http.StatusTooManyRequests)
+		assert.Equal(t, d, b.CalculateBackoff(nil))
+	}
+
+	b.UpdateBackoff(nil, nil, http.StatusOK)
+	assert.Equal(t, time.Duration(0), b.CalculateBackoff(nil))
+
+	b.UpdateBackoff(nil, errors.New("connection reset"), 0)
+	assert.Equal(t, 100*time.Millisecond-1, b.CalculateBackoff(nil))
+
+	// full jitter
+	b = newJitterBackoff(100*time.Millisecond, 0, fixedRandSource(0))
+	b.UpdateBackoff(nil, nil, http.StatusServiceUnavailable)
+	assert.Equal(t, time.Duration(0), b.CalculateBackoff(nil))
+}
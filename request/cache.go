@@ -1,22 +1,34 @@
 package request
 
 import (
-	"container/list"
 	"sync"
 )
 
 // Cache is a thread-safe cache for storing resource names
 type Cache struct {
 	mu sync.Mutex
-	// TODO: add cap and drop oldest item if needed
-	// https://github.com/Azure/kperf/pull/198#discussion_r2252571111
-	items *list.List
+	// cap is the maximum number of items. The oldest item is dropped if
+	// the cache is full. Zero means no limitation.
+	cap int
+	// items[head:] are the items from the oldest to the newest. The slice
+	// is used instead of list so that Random picks item in O(1).
+	items []string
+	head  int
 }
 
-// InitCache creates a new empty cache
+// InitCache creates a new empty cache without limitation.
 func InitCache() *Cache {
+	return InitCacheWithCap(0)
+}
+
+// InitCacheWithCap creates a new empty cache which holds at most n items.
+// The n <= 0 means no limitation.
+func InitCacheWithCap(n int) *Cache {
+	if n < 0 {
+		n = 0
+	}
 	return &Cache{
-		items: list.New(),
+		cap: n,
 	}
 }
 
@@ -26,29 +38,75 @@ func (c *Cache) Pop() (string, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if c.items.Len() == 0 {
+	if c.len() == 0 {
 		return "", false
 	}
 
 	// Remove from front (FIFO)
-	front := c.items.Front()
-	name := front.Value.(string)
-	c.items.Remove(front)
+	name := c.items[c.head]
+	c.removeFront()
 	return name, true
 }
 
-// Push adds an item to the cache.
+// Push adds an item to the cache. The oldest item is dropped if the cache
+// is full.
 func (c *Cache) Push(name string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cap > 0 && c.len() >= c.cap {
+		c.removeFront()
+	}
+
 	// Add new item to back
-	c.items.PushBack(name)
+	c.items = append(c.items, name)
+}
+
+// Random returns a randomly picked item without removing it.
+// Returns empty string and false if cache is empty.
+func (c *Cache) Random(rnd randSource) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := c.len()
+	if n == 0 {
+		return "", false
+	}
+	return c.items[c.head+int(rnd.Int63n(int64(n)))], true
 }
 
 // Len returns the number of items in the cache.
 func (c *Cache) Len() int {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.items.Len()
+	return c.len()
+}
+
+// Items returns all the items in the cache.
+func (c *Cache) Items() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append(make([]string, 0, c.len()), c.items[c.head:]...)
+}
+
+// len must be called with lock.
+func (c *Cache) len() int {
+	return len(c.items) - c.head
+}
+
+// removeFront drops the oldest item. The removed ones are compacted once
+// they take half of the slice so that both Pop and Push are amortized O(1).
+//
+// removeFront must be called with lock.
+func (c *Cache) removeFront() {
+	c.items[c.head] = ""
+	c.head++
+
+	if c.head*2 >= len(c.items) {
+		n := copy(c.items, c.items[c.head:])
+		clear(c.items[n:])
+		c.items = c.items[:n]
+		c.head = 0
+	}
 }
@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheWithCap(t *testing.T) {
+	c := InitCacheWithCap(3)
+	for i := 0; i < 10; i++ {
+		c.Push(fmt.Sprintf("obj-%d", i))
+		assert.LessOrEqual(t, c.Len(), 3)
+	}
+	assert.Equal(t, []string{"obj-7", "obj-8", "obj-9"}, c.Items())
+
+	name, ok := c.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "obj-7", name)
+
+	c.Push("obj-10")
+	c.Push("obj-11")
+	assert.Equal(t, []string{"obj-9", "obj-10", "obj-11"}, c.Items())
+}
+
+func TestCacheWithoutCap(t *testing.T) {
+	for _, c := range []*Cache{InitCache(), InitCacheWithCap(0), InitCacheWithCap(-1)} {
+		for i := 0; i < 100; i++ {
+			c.Push(fmt.Sprintf("obj-%d", i))
+		}
+		assert.Equal(t, 100, c.Len())
+
+		name, ok := c.Pop()
+		assert.True(t, ok)
+		assert.Equal(t, "obj-0", name)
+	}
+}
+
+// fixedRandSource always returns the same index.
+type fixedRandSource int64
+
+func (s fixedRandSource) Int63n(int64) int64 { return int64(s) }
+
+func TestCacheRandom(t *testing.T) {
+	c := InitCache()
+	_, ok := c.Random(fixedRandSource(0))
+	assert.False(t, ok)
+
+	for i := 0; i < 5; i++ {
+		c.Push(fmt.Sprintf("obj-%d", i))
+	}
+	for i := 0; i < 5; i++ {
+		name, ok := c.Random(fixedRandSource(i))
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("obj-%d", i), name)
+	}
+	assert.Equal(t, 5, c.Len())
+}
+
+func TestCacheRandomAfterPop(t *testing.T) {
+	c := InitCacheWithCap(4)
+	for i := 0; i < 10; i++ {
+		c.Push(fmt.Sprintf("obj-%d", i))
+	}
+	name, ok := c.Pop()
+	assert.True(t, ok)
+	assert.Equal(t, "obj-6", name)
+
+	// NOTE: The index is relative to the oldest item.
+	for i := 0; i < 3; i++ {
+		name, ok := c.Random(fixedRandSource(i))
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("obj-%d", i+7), name)
+	}
+	assert.Equal(t, []string{"obj-7", "obj-8", "obj-9"}, c.Items())
+}
@@ -7,12 +7,14 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"time"
 
 	"github.com/Azure/kperf/api/types"
 	"github.com/Azure/kperf/request/unstructuredscheme"
 
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
 )
 
 // NewClients creates N rest.Interface.
@@ -27,7 +29,7 @@ func NewClients(kubeCfgPath string, connsNum int, opts ...ClientCfgOpt) ([]rest.
 		opt(&cfg)
 	}
 
-	restCfg, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	restCfg, err := buildRESTConfig(kubeCfgPath, cfg.context)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +45,17 @@ func NewClients(kubeCfgPath string, connsNum int, opts ...ClientCfgOpt) ([]rest.
 	// REF: https://github.com/kubernetes/client-go/blob/c5938c6876a62f53c1f4ee55b879ca5c74253ae8/transport/cache.go#L154
 	restCfg.Proxy = http.ProxyFromEnvironment
 
+	// NOTE: It should be the first wrapper so that it can access the
+	// transport built by client-go.
+	if cfg.disableKeepAlives {
+		restCfg.Wrap(disableKeepAlivesWrapper)
+	}
+
+	// Count HTTP round trips, including retries, for amplification factor.
+	restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &attemptsRoundTripper{rt: rt}
+	})
+
 	err = cfg.apply(restCfg)
 	if err != nil {
 		return nil, err
@@ -61,6 +74,19 @@ func NewClients(kubeCfgPath string, connsNum int, opts ...ClientCfgOpt) ([]rest.
 	return restClients, nil
 }
 
+// buildRESTConfig builds rest.Config from kubeconfig with the context. The
+// empty context means current context.
+func buildRESTConfig(kubeCfgPath string, context string) (*rest.Config, error) {
+	if context == "" {
+		return clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	}
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeCfgPath
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		rules, &clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+}
+
 // defaultClientCfg is default setting for http client.
 var defaultClientCfg = clientCfg{
 	qps:         float64(math.MaxInt32),
@@ -72,6 +98,24 @@ type clientCfg struct {
 	qps          float64
 	contentType  types.ContentType
 	disableHTTP2 bool
+
+	// insecureSkipTLSVerify skips server certificate verification.
+	insecureSkipTLSVerify bool
+	// caFile overrides the CA bundle in kubeconfig.
+	caFile string
+
+	// networkDelay is the artificial latency injected into each round trip.
+	networkDelay time.Duration
+	// acceptEncoding is the encoding asked for response. Only gzip is
+	// supported. Empty means the default transparent compression.
+	acceptEncoding string
+	// context is the context in kubeconfig. Empty means current context.
+	context string
+	// instanceHeader is the response header which identifies
+	// kube-apiserver instance. Empty means not to record instance.
+	instanceHeader string
+	// disableKeepAlives forces new connection for each request.
+	disableKeepAlives bool
 }
 
 // apply sets value to k8s.io/client-go/rest.Config.
@@ -86,19 +130,66 @@ func (cfg *clientCfg) apply(restCfg *rest.Config) error {
 	}
 
 	// set the content type
-	switch cfg.contentType {
-	case types.ContentTypeJSON:
-		restCfg.ContentType = "application/json"
-	case types.ContentTypeProtobuffer:
-		restCfg.ContentType = "application/vnd.kubernetes.protobuf"
-	default:
+	//
+	// NOTE: The rest client sets it in Accept header of every request.
+	if err := cfg.contentType.Validate(); err != nil {
 		return fmt.Errorf("invalid content type: %s", cfg.contentType)
 	}
+	restCfg.ContentType = cfg.contentType.MediaType()
 
 	// disable HTTP2
 	if cfg.disableHTTP2 {
 		restCfg.NextProtos = []string{"http/1.1"}
 	}
+
+	// inject network delay
+	if cfg.networkDelay > 0 {
+		delay := cfg.networkDelay
+		restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return newDelayRoundTripper(delay, rt)
+		})
+	}
+
+	// ask for compressed response and count the bytes on the wire
+	switch cfg.acceptEncoding {
+	case "":
+	case "gzip":
+		restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &gzipRoundTripper{rt: rt}
+		})
+	default:
+		return fmt.Errorf("unsupported accept encoding: %s", cfg.acceptEncoding)
+	}
+
+	// record which kube-apiserver instance served the request
+	if cfg.instanceHeader != "" {
+		header := cfg.instanceHeader
+		restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &instanceRoundTripper{header: header, rt: rt}
+		})
+	}
+	return cfg.applyTLS(restCfg)
+}
+
+// applyTLS overrides TLS setting in k8s.io/client-go/rest.Config.
+func (cfg *clientCfg) applyTLS(restCfg *rest.Config) error {
+	if cfg.insecureSkipTLSVerify && cfg.caFile != "" {
+		return fmt.Errorf("certificate authority file can't be used with insecure-skip-tls-verify")
+	}
+
+	if cfg.caFile != "" {
+		restCfg.TLSClientConfig.CAFile = cfg.caFile
+		restCfg.TLSClientConfig.CAData = nil
+	}
+
+	// NOTE: client-go doesn't allow root certificates with insecure flag.
+	if cfg.insecureSkipTLSVerify {
+		klog.Warningf("TLS verification is disabled for %s. The connection is insecure", restCfg.Host)
+
+		restCfg.TLSClientConfig.Insecure = true
+		restCfg.TLSClientConfig.CAFile = ""
+		restCfg.TLSClientConfig.CAData = nil
+	}
 	return nil
 }
 
@@ -134,3 +225,79 @@ func WithClientDisableHTTP2Opt(b bool) ClientCfgOpt {
 		cfg.disableHTTP2 = b
 	}
 }
+
+// WithClientInsecureSkipTLSVerifyOpt skips server certificate verification.
+func WithClientInsecureSkipTLSVerifyOpt(b bool) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.insecureSkipTLSVerify = b
+	}
+}
+
+// WithClientCAFileOpt uses the CA bundle file instead of the one in kubeconfig.
+func WithClientCAFileOpt(path string) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.caFile = path
+	}
+}
+
+// WithClientAcceptEncodingOpt asks for response in the encoding, like gzip.
+func WithClientAcceptEncodingOpt(encoding string) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.acceptEncoding = encoding
+	}
+}
+
+// WithClientContextOpt uses the context in kubeconfig instead of current
+// context.
+func WithClientContextOpt(context string) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.context = context
+	}
+}
+
+// WithClientNetworkDelayOpt injects artificial latency into each round trip
+// to simulate high-RTT clients.
+func WithClientNetworkDelayOpt(delay time.Duration) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.networkDelay = delay
+	}
+}
+
+// WithClientInstanceHeaderOpt records the value of response header, which
+// identifies kube-apiserver instance behind load balancer, for each request.
+func WithClientInstanceHeaderOpt(header string) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.instanceHeader = header
+	}
+}
+
+// WithClientDisableKeepAlivesOpt forces new TCP and TLS connection for each
+// request instead of reusing pooled connections.
+func WithClientDisableKeepAlivesOpt(b bool) ClientCfgOpt {
+	return func(cfg *clientCfg) {
+		cfg.disableKeepAlives = b
+	}
+}
+
+// disableKeepAlivesWrapper disables keep-alives of transport built by
+// client-go. It falls back to closing connection after each request if the
+// transport is unknown or shared, like http.DefaultTransport.
+func disableKeepAlivesWrapper(rt http.RoundTripper) http.RoundTripper {
+	if t, ok := rt.(*http.Transport); ok && rt != http.DefaultTransport {
+		t.DisableKeepAlives = true
+		return t
+	}
+	return &closeRoundTripper{rt: rt}
+}
+
+// closeRoundTripper asks to close connection after each request.
+type closeRoundTripper struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *closeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Close = true
+	return c.rt.RoundTrip(req)
+}
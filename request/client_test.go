@@ -4,10 +4,19 @@
 package request
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/metrics"
 )
 
@@ -36,3 +45,43 @@ func TestNewClientShouldNotPanic(t *testing.T) {
 	_, err := NewClients("testdata/dummy_nonexistent_kubeconfig.yaml", 10)
 	assert.NoError(t, err)
 }
+
+func TestDisableKeepAlivesWrapper(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		var conns int64
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+			if state == http.StateNew {
+				atomic.AddInt64(&conns, 1)
+			}
+		}
+		srv.Start()
+
+		restCfg := &rest.Config{
+			Host:    srv.URL,
+			Proxy:   http.ProxyFromEnvironment,
+			APIPath: "/api",
+		}
+		restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+		if disabled {
+			restCfg.Wrap(disableKeepAlivesWrapper)
+		}
+
+		cli, err := rest.UnversionedRESTClientFor(restCfg)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := cli.Get().AbsPath("/api/v1/configmaps").DoRaw(context.Background())
+			require.NoError(t, err)
+		}
+		srv.Close()
+
+		expected := int64(1)
+		if disabled {
+			expected = 3
+		}
+		assert.Equal(t, expected, atomic.LoadInt64(&conns), "disableKeepAlives=%v", disabled)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+// clientPool holds the rest clients used by Schedule. The clients can be
+// rebuilt during the run, like when the credential in kubeconfig expires.
+type clientPool struct {
+	mu      sync.RWMutex
+	clients []rest.Interface
+
+	// rebuildFn creates new clients. It's nil if clients can't be rebuilt.
+	rebuildFn func() ([]rest.Interface, error)
+	// minInterval is the minimum interval between two rebuilds so that a
+	// wave of auth failures triggers one rebuild.
+	minInterval time.Duration
+	// lastRebuildAt is the time of last rebuild attempt. It's zero if
+	// there is no rebuild yet.
+	lastRebuildAt time.Time
+	// rebuilding is 1 if one rebuild is in progress.
+	rebuilding int32
+	// rebuilds is the number of successful rebuilds.
+	rebuilds int64
+}
+
+func newClientPool(clients []rest.Interface, rebuildFn func() ([]rest.Interface, error), minInterval time.Duration) *clientPool {
+	return &clientPool{
+		clients:     clients,
+		rebuildFn:   rebuildFn,
+		minInterval: minInterval,
+	}
+}
+
+// get returns the client for idx in round-robin and the generation of
+// clients, which is the number of successful rebuilds.
+func (p *clientPool) get(idx int) (rest.Interface, int64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.clients[idx%len(p.clients)], atomic.LoadInt64(&p.rebuilds)
+}
+
+// handleAuthFailure rebuilds clients after the request sent by the clients
+// of generation gen fails with 401 or 403. It returns false if the failure
+// should be counted as failure, which means that the clients can't be
+// rebuilt or a rebuild was already attempted for them within minInterval,
+// like when the rebuilt clients still fail.
+func (p *clientPool) handleAuthFailure(gen int64) bool {
+	if p.rebuildFn == nil {
+		return false
+	}
+
+	p.mu.RLock()
+	// NOTE: The request was sent by the replaced clients.
+	stale := gen < atomic.LoadInt64(&p.rebuilds)
+	elapsed := time.Since(p.lastRebuildAt)
+	p.mu.RUnlock()
+
+	switch {
+	case stale, atomic.LoadInt32(&p.rebuilding) == 1:
+		return true
+	case elapsed < p.minInterval:
+		return false
+	default:
+		p.rebuild()
+		return true
+	}
+}
+
+// rebuild creates new clients if minInterval has elapsed since the last
+// rebuild and there is no rebuild in progress. The in-flight requests keep
+// using the old clients.
+func (p *clientPool) rebuild() {
+	if p.rebuildFn == nil || !atomic.CompareAndSwapInt32(&p.rebuilding, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&p.rebuilding, 0)
+
+	p.mu.RLock()
+	elapsed := time.Since(p.lastRebuildAt)
+	p.mu.RUnlock()
+	if elapsed < p.minInterval {
+		return
+	}
+
+	clients, err := p.rebuildFn()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastRebuildAt = time.Now()
+	if err != nil {
+		klog.Warningf("Failed to rebuild clients: %v", err)
+		return
+	}
+	if len(clients) != len(p.clients) {
+		klog.Warningf("Failed to rebuild clients: expected %d clients, but got %d", len(p.clients), len(clients))
+		return
+	}
+	p.clients = clients
+	atomic.AddInt64(&p.rebuilds, 1)
+	klog.V(2).InfoS("Rebuilt clients after auth failure", "clients", len(clients))
+}
+
+// Rebuilds returns the number of successful rebuilds.
+func (p *clientPool) Rebuilds() int64 {
+	return atomic.LoadInt64(&p.rebuilds)
+}
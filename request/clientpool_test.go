@@ -0,0 +1,116 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestScheduleRebuildClientsOnAuthFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Unauthorized","code":401}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	newClient := func(token string) rest.Interface {
+		restCfg := &rest.Config{
+			Host:        srv.URL,
+			Proxy:       http.ProxyFromEnvironment,
+			QPS:         1000,
+			Burst:       1000,
+			APIPath:     "/api",
+			BearerToken: token,
+		}
+		restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+
+		cli, err := rest.UnversionedRESTClientFor(restCfg)
+		require.NoError(t, err)
+		return cli
+	}
+
+	var rebuilds int64
+	rebuildFn := func() ([]rest.Interface, error) {
+		atomic.AddInt64(&rebuilds, 1)
+		return []rest.Interface{newClient("fresh")}, nil
+	}
+
+	spec := newScheduleTestSpec()
+	spec.Total = 10
+
+	t.Run("disabled", func(t *testing.T) {
+		res, err := Schedule(context.Background(), spec, []rest.Interface{newClient("expired")},
+			WithScheduleClientRebuilderOpt(rebuildFn))
+		require.NoError(t, err)
+		assert.Equal(t, 10, res.Total)
+		assert.Equal(t, map[string]int{"GET": 10}, res.FailuresByMethod)
+		assert.Equal(t, int64(0), res.AuthFailures)
+		assert.Equal(t, int64(0), atomic.LoadInt64(&rebuilds))
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		spec.AuthRefreshIntervalSeconds = 60
+
+		res, err := Schedule(context.Background(), spec, []rest.Interface{newClient("expired")},
+			WithScheduleClientRebuilderOpt(rebuildFn))
+		require.NoError(t, err)
+		assert.Equal(t, 9, res.Total)
+		assert.Empty(t, res.FailuresByMethod)
+		assert.Equal(t, int64(1), res.AuthFailures)
+		assert.Equal(t, int64(1), res.ClientRebuilds)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&rebuilds))
+	})
+
+	t.Run("rebuilt clients still fail", func(t *testing.T) {
+		spec.AuthRefreshIntervalSeconds = 60
+		atomic.StoreInt64(&rebuilds, 0)
+
+		res, err := Schedule(context.Background(), spec, []rest.Interface{newClient("expired")},
+			WithScheduleClientRebuilderOpt(func() ([]rest.Interface, error) {
+				atomic.AddInt64(&rebuilds, 1)
+				return []rest.Interface{newClient("revoked")}, nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, 9, res.Total)
+		assert.Equal(t, map[string]int{"GET": 9}, res.FailuresByMethod)
+		assert.Equal(t, int64(1), res.AuthFailures)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&rebuilds))
+	})
+
+	t.Run("target", func(t *testing.T) {
+		spec.AuthRefreshIntervalSeconds = 60
+		spec.Targets = []*types.Target{{Name: "a", Shares: 1}}
+		defer func() { spec.Targets = nil }()
+
+		var targetRebuilds int64
+		res, err := Schedule(context.Background(), spec, []rest.Interface{newClient("fresh")},
+			WithScheduleTargetClientsOpt(map[string][]rest.Interface{"a": {newClient("expired")}}),
+			WithScheduleTargetClientRebuilderOpt(func(target string) ([]rest.Interface, error) {
+				assert.Equal(t, "a", target)
+				atomic.AddInt64(&targetRebuilds, 1)
+				return []rest.Interface{newClient("fresh")}, nil
+			}))
+		require.NoError(t, err)
+		assert.Equal(t, 9, res.Total)
+		assert.Empty(t, res.FailuresByMethod)
+		assert.Equal(t, int64(1), res.AuthFailures)
+		assert.Equal(t, int64(1), res.ClientRebuilds)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&targetRebuilds))
+	})
+}
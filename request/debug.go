@@ -0,0 +1,187 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+)
+
+// debugQPSWindow is the window over which DebugStats.QPS is computed.
+const debugQPSWindow = 10 * time.Second
+
+// DebugStats is the snapshot of running benchmark.
+type DebugStats struct {
+	// QPS is the number of completed requests per second in the last
+	// debugQPSWindow.
+	QPS float64 `json:"qps"`
+	// Clients is the number of concurrent clients sending requests.
+	Clients int `json:"clients"`
+	// Conns is the number of connections shared by clients, including
+	// the ones of targets.
+	Conns int `json:"conns"`
+	// InFlight is the number of requests waiting for responses.
+	InFlight int64 `json:"inFlight"`
+	// Total is the number of completed requests.
+	Total int64 `json:"total"`
+	// Failures is the number of failed requests.
+	Failures int64 `json:"failures"`
+	// ErrorRate is Failures / Total.
+	ErrorRate float64 `json:"errorRate"`
+	// CacheSize is the number of created objects tracked by post-delete
+	// requests.
+	CacheSize int `json:"cacheSize"`
+}
+
+// debugCounters tracks requests for debug endpoint.
+type debugCounters struct {
+	inFlight int64
+	total    int64
+	failures int64
+
+	clients     int
+	conns       int
+	cacheSizeFn func() int
+
+	// samples are the totals recorded by sample in the last
+	// debugQPSWindow, from oldest to newest.
+	mu      sync.Mutex
+	samples []debugSample
+}
+
+// debugSample is the number of completed requests at a point in time.
+type debugSample struct {
+	total int64
+	at    time.Time
+}
+
+func newDebugCounters(clients, conns int, cacheSizeFn func() int) *debugCounters {
+	return &debugCounters{
+		clients:     clients,
+		conns:       conns,
+		cacheSizeFn: cacheSizeFn,
+		samples:     []debugSample{{at: time.Now()}},
+	}
+}
+
+// sample records the current total and drops the samples older than
+// debugQPSWindow, except the newest of them which starts the window.
+func (c *debugCounters) sample(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.samples = append(c.samples, debugSample{total: atomic.LoadInt64(&c.total), at: now})
+	idx := 0
+	for idx+1 < len(c.samples) && now.Sub(c.samples[idx+1].at) >= debugQPSWindow {
+		idx++
+	}
+	c.samples = append(c.samples[:0], c.samples[idx:]...)
+}
+
+// qps returns the number of completed requests per second between the
+// oldest sample and now.
+func (c *debugCounters) qps(total int64, now time.Time) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	oldest := c.samples[0]
+	elapsed := now.Sub(oldest.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(total-oldest.total) / elapsed
+}
+
+// snapshot returns current DebugStats.
+func (c *debugCounters) snapshot() DebugStats {
+	total := atomic.LoadInt64(&c.total)
+	failures := atomic.LoadInt64(&c.failures)
+	qps := c.qps(total, time.Now())
+
+	errorRate := float64(0)
+	if total > 0 {
+		errorRate = float64(failures) / float64(total)
+	}
+
+	return DebugStats{
+		QPS:       qps,
+		Clients:   c.clients,
+		Conns:     c.conns,
+		InFlight:  atomic.LoadInt64(&c.inFlight),
+		Total:     total,
+		Failures:  failures,
+		ErrorRate: errorRate,
+		CacheSize: c.cacheSizeFn(),
+	}
+}
+
+// serveDebug serves DebugStats in JSON format on /debug/vars, profiles on
+// /debug/pprof and metrics from gatherer in prometheus format on /metrics
+// until ctx is done.
+func serveDebug(ctx context.Context, addr string, c *debugCounters, gatherer prometheus.Gatherer) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		if err := json.NewEncoder(w).Encode(c.snapshot()); err != nil {
+			klog.V(2).ErrorS(err, "failed to encode debug stats")
+		}
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+
+	// NOTE: Register pprof handlers explicitly because mux isn't
+	// http.DefaultServeMux.
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				c.sample(now)
+			}
+		}
+	}()
+
+	go func() {
+		klog.V(2).InfoS("Serving debug endpoint", "address", lis.Addr().String())
+		if err := srv.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			klog.V(2).ErrorS(err, "debug endpoint stopped")
+		}
+	}()
+	return nil
+}
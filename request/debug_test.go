@@ -0,0 +1,35 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugCountersQPSWindow(t *testing.T) {
+	c := newDebugCounters(2, 1, func() int { return 3 })
+	start := c.samples[0].at
+
+	for i := 1; i <= 20; i++ {
+		atomic.AddInt64(&c.total, int64(i))
+		c.sample(start.Add(time.Duration(i) * time.Second))
+	}
+
+	// The window starts from the 10th second whose total is 55 and
+	// the total of 20th second is 210.
+	now := start.Add(20 * time.Second)
+	assert.InDelta(t, float64(210-55)/10, c.qps(210, now), 1e-9)
+	// Polling doesn't reset the window.
+	assert.InDelta(t, float64(210-55)/10, c.qps(210, now), 1e-9)
+
+	stats := c.snapshot()
+	assert.Equal(t, 2, stats.Clients)
+	assert.Equal(t, 1, stats.Conns)
+	assert.Equal(t, 3, stats.CacheSize)
+	assert.Equal(t, int64(210), stats.Total)
+}
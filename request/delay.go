@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// delayRoundTripper injects artificial latency into each round trip to
+// simulate high-RTT clients, like cross-region clients.
+//
+// Half of delay is injected before the request is passed to the wrapped
+// RoundTripper, when no connection is used yet. The other half is injected
+// after receiving response headers and before returning the response, so
+// the connection, or HTTP2 stream, is held with unread body during that
+// half. Each half is recorded as injected delay once it has elapsed so that
+// it can be reported separately from the measured latency.
+type delayRoundTripper struct {
+	delay time.Duration
+	rt    http.RoundTripper
+}
+
+// newDelayRoundTripper wraps rt with delay. It returns rt if delay <= 0.
+func newDelayRoundTripper(delay time.Duration, rt http.RoundTripper) http.RoundTripper {
+	if delay <= 0 {
+		return rt
+	}
+	return &delayRoundTripper{delay: delay, rt: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (d *delayRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	sendDelay := d.delay / 2
+	if err := sleepWithContext(ctx, sendDelay); err != nil {
+		return nil, err
+	}
+	roundTripRecorderFrom(ctx).addInjectedDelay(sendDelay)
+
+	resp, err := d.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recvDelay := d.delay - sendDelay
+	if err := sleepWithContext(ctx, recvDelay); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	roundTripRecorderFrom(ctx).addInjectedDelay(recvDelay)
+	return resp, nil
+}
+
+// WrappedRoundTripper returns underlying RoundTripper.
+func (d *delayRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return d.rt
+}
+
+// sleepWithContext waits for the duration or returns error if ctx is done.
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(duration)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
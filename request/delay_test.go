@@ -0,0 +1,61 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelayRoundTripper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	delay := 100 * time.Millisecond
+	cli := &http.Client{Transport: newDelayRoundTripper(delay, http.DefaultTransport)}
+
+	var recorder roundTripRecorder
+	ctx := withRoundTripRecorder(context.Background(), &recorder)
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		resp, err := cli.Do(req)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), delay)
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "ok", string(data))
+	}
+	assert.Equal(t, 2*delay, recorder.InjectedDelay())
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+
+		_, err = cli.Do(req)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("no delay", func(t *testing.T) {
+		rt := newDelayRoundTripper(0, http.DefaultTransport)
+		assert.Equal(t, http.DefaultTransport, rt)
+	})
+}
@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"k8s.io/client-go/rest"
+)
+
+// ResolveURLs builds n requests picked by weight from spec without sending
+// them and returns the method and URL of each request, like
+// "GET /api/v1/namespaces/default/configmaps/x". The URLs don't have host
+// because nothing is connected. It's used to sanity-check load profile before running, like
+// resolved group/version/resource and randomized object names.
+//
+// NOTE: The requests specified by kind should be resolved by ResolveKinds
+// first. The nodeNameFromNodepool field selector isn't resolved.
+func ResolveURLs(spec *types.LoadProfileSpec, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("n requires > 0: %v", n)
+	}
+
+	rndReqs, err := newWeightedRandomRequestsForSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, s := range rndReqs.shares {
+		total += s
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("all the requests have zero shares")
+	}
+
+	// NOTE: Make transport uncacheable like NewClients.
+	restCfg := &rest.Config{APIPath: "/api", Proxy: http.ProxyFromEnvironment}
+	restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+	cli, err := rest.UnversionedRESTClientFor(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dry-run rest client: %w", err)
+	}
+
+	res := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		builder := rndReqs.reqBuilders[pickByWeight(rndReqs.rnd, rndReqs.shares)]
+		req := builder.Build(cli)
+		res = append(res, req.Method()+" "+req.URL().RequestURI())
+	}
+	return res, nil
+}
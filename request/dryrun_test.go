@@ -0,0 +1,59 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveURLs(t *testing.T) {
+	spec := newScheduleTestSpec()
+	spec.Total = 1
+	spec.Requests = append(spec.Requests, &types.WeightedRequest{
+		Shares: 100,
+		Patch: &types.RequestPatch{
+			KubeGroupVersionResource: types.KubeGroupVersionResource{
+				Version:  "v1",
+				Resource: "configmaps",
+			},
+			Namespace:    "default",
+			Name:         "cm",
+			KeySpaceSize: 10,
+			PatchType:    "merge",
+			Body:         `{"data":{"a":"1"}}`,
+		},
+	})
+
+	urls, err := ResolveURLs(spec, 50)
+	require.NoError(t, err)
+	require.Len(t, urls, 50)
+
+	verbs := map[string]bool{}
+	for _, u := range urls {
+		method, uri, _ := strings.Cut(u, " ")
+		verbs[method] = true
+		switch method {
+		case "GET":
+			assert.Equal(t, "/api/v1/namespaces/default/configmaps/x?resourceVersion=0", uri)
+		case "PATCH":
+			assert.Regexp(t, `^/api/v1/namespaces/default/configmaps/cm-\d$`, uri)
+		default:
+			t.Fatalf("unexpected request %s", u)
+		}
+	}
+	assert.Len(t, verbs, 2)
+
+	_, err = ResolveURLs(spec, 0)
+	assert.Error(t, err)
+
+	spec.Requests[0].Shares, spec.Requests[1].Shares = 0, 0
+	_, err = ResolveURLs(spec, 1)
+	assert.Error(t, err)
+}
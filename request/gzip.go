@@ -0,0 +1,92 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// gzipRoundTripper asks for gzip-compressed response and decompresses it.
+//
+// The http.Transport does the same thing transparently, but the bytes read
+// from the wire are hidden. The gzipRoundTripper records them into recorder
+// stored in request's context so that compressed and decompressed bytes can
+// be reported separately.
+type gzipRoundTripper struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (g *gzipRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// NOTE: http.Transport doesn't decompress response if the request
+	// has Accept-Encoding header.
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := g.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &countingReader{
+		rc:       resp.Body,
+		recorder: roundTripRecorderFrom(req.Context()),
+	}
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		resp.Body = body
+		return resp, nil
+	}
+
+	resp.Body = &gzipReader{body: body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// WrappedRoundTripper returns underlying RoundTripper.
+func (g *gzipRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return g.rt
+}
+
+// countingReader records the bytes read from the wire.
+type countingReader struct {
+	rc       io.ReadCloser
+	recorder *roundTripRecorder
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.rc.Read(p)
+	c.recorder.addWireBytes(int64(n))
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	return c.rc.Close()
+}
+
+// gzipReader decompresses body lazily so that RoundTrip doesn't wait for
+// the gzip header.
+type gzipReader struct {
+	body *countingReader
+	zr   *gzip.Reader
+	err  error
+}
+
+func (g *gzipReader) Read(p []byte) (int, error) {
+	if g.zr == nil && g.err == nil {
+		g.zr, g.err = gzip.NewReader(g.body)
+	}
+	if g.err != nil {
+		return 0, g.err
+	}
+	return g.zr.Read(p)
+}
+
+func (g *gzipReader) Close() error {
+	return g.body.Close()
+}
@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestGzipRoundTripper(t *testing.T) {
+	body := `{"data":"` + strings.Repeat("a", 64*1024) + `"}`
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, err := zw.Write([]byte(body))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/plain" {
+			_, _ = w.Write([]byte(`{}`))
+			return
+		}
+		assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	defer srv.Close()
+
+	restCfg := &rest.Config{
+		Host:    srv.URL,
+		Proxy:   http.ProxyFromEnvironment,
+		QPS:     1000,
+		Burst:   1000,
+		APIPath: "/api",
+	}
+	restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+	restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &gzipRoundTripper{rt: rt}
+	})
+
+	cli, err := rest.UnversionedRESTClientFor(restCfg)
+	require.NoError(t, err)
+
+	var recorder roundTripRecorder
+	reqr := &DiscardRequester{
+		BaseRequester: BaseRequester{
+			method: "LIST",
+			req:    cli.Get().AbsPath("/api/v1/configmaps"),
+		},
+	}
+	n, err := reqr.Do(withRoundTripRecorder(context.Background(), &recorder))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(body)), n)
+	assert.Equal(t, int64(compressed.Len()), recorder.WireBytes())
+
+	// NOTE: The uncompressed response is counted as it is.
+	recorder = roundTripRecorder{}
+	reqr = &DiscardRequester{
+		BaseRequester: BaseRequester{
+			method: "GET",
+			req:    cli.Get().AbsPath("/api/v1/plain"),
+		},
+	}
+	n, err = reqr.Do(withRoundTripRecorder(context.Background(), &recorder))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.Equal(t, int64(2), recorder.WireBytes())
+}
@@ -0,0 +1,108 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/contrib/utils"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultHealthCheckInterval is the default interval between health
+	// checks for stop condition.
+	defaultHealthCheckInterval = 10 * time.Second
+
+	// apiserverCurrentInflightRequests is the gauge of requests in flight
+	// group by request kind (mutating or readOnly).
+	apiserverCurrentInflightRequests = "apiserver_current_inflight_requests"
+)
+
+// watchHealth checks kube-apiserver's health until ctx is done. It calls
+// halt with the reason once any threshold in cond is crossed.
+func watchHealth(ctx context.Context, cli rest.Interface, cond *types.HealthStopCondition, halt func(reason string)) {
+	interval := defaultHealthCheckInterval
+	if cond.CheckIntervalSeconds > 0 {
+		interval = time.Duration(cond.CheckIntervalSeconds) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	readyzFailures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if cond.MaxReadyzFailures > 0 {
+			_, err := cli.Get().AbsPath("/readyz").Timeout(interval).DoRaw(ctx)
+			switch {
+			case err == nil:
+				readyzFailures = 0
+			case ctx.Err() != nil:
+				return
+			default:
+				readyzFailures++
+				klog.V(2).Infof("/readyz check failed (%d/%d): %v", readyzFailures, cond.MaxReadyzFailures, err)
+
+				if readyzFailures >= cond.MaxReadyzFailures {
+					halt(fmt.Sprintf("/readyz failed %d times in a row: %v", readyzFailures, err))
+					return
+				}
+			}
+		}
+
+		if cond.MaxInflightRequests > 0 {
+			data, err := cli.Get().AbsPath("/metrics").Timeout(interval).DoRaw(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				klog.V(2).Infof("failed to fetch apiserver metrics for stop condition: %v", err)
+				continue
+			}
+
+			inflight, err := parseCurrentInflightRequests(data)
+			if err != nil {
+				klog.V(2).Infof("failed to parse %s: %v", apiserverCurrentInflightRequests, err)
+				continue
+			}
+
+			if inflight > float64(cond.MaxInflightRequests) {
+				halt(fmt.Sprintf("%s %v exceeded %d", apiserverCurrentInflightRequests, inflight, cond.MaxInflightRequests))
+				return
+			}
+		}
+	}
+}
+
+// parseCurrentInflightRequests returns the sum of inflight requests for all
+// the request kinds from kube-apiserver /metrics data.
+func parseCurrentInflightRequests(data []byte) (float64, error) {
+	res := float64(0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, apiserverCurrentInflightRequests) {
+			continue
+		}
+
+		name, _, value, err := utils.ParseMetricSample(line)
+		if err != nil {
+			return 0, err
+		}
+		if name == apiserverCurrentInflightRequests {
+			res += value
+		}
+	}
+	return res, nil
+}
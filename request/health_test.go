@@ -0,0 +1,24 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrentInflightRequests(t *testing.T) {
+	data := []byte(`# HELP apiserver_current_inflight_requests [STABLE] Maximal number of currently used inflight request limit of this apiserver per request kind in last second.
+# TYPE apiserver_current_inflight_requests gauge
+apiserver_current_inflight_requests{request_kind="mutating"} 12
+apiserver_current_inflight_requests{request_kind="readOnly"} 30
+apiserver_current_inflight_requests_total 1
+`)
+
+	inflight, err := parseCurrentInflightRequests(data)
+	require.NoError(t, err)
+	assert.Equal(t, float64(42), inflight)
+}
@@ -0,0 +1,31 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"net/http"
+)
+
+// instanceRoundTripper reads the response header which identifies
+// kube-apiserver instance, like the one set by load balancer, and records
+// it into recorder stored in request's context.
+type instanceRoundTripper struct {
+	header string
+	rt     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (i *instanceRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := i.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	roundTripRecorderFrom(req.Context()).setInstance(resp.Header.Get(i.header))
+	return resp, nil
+}
+
+// WrappedRoundTripper returns underlying RoundTripper.
+func (i *instanceRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return i.rt
+}
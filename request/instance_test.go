@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestScheduleLatenciesByInstance(t *testing.T) {
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		n := atomic.AddInt64(&calls, 1)
+		// NOTE: The third request doesn't carry instance header.
+		if n%3 != 0 {
+			w.Header().Set("X-Instance", fmt.Sprintf("apiserver-%d", n%3))
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	restCfg := &rest.Config{
+		Host:    srv.URL,
+		Proxy:   http.ProxyFromEnvironment,
+		QPS:     1000,
+		Burst:   1000,
+		APIPath: "/api",
+	}
+	restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+	restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &instanceRoundTripper{header: "X-Instance", rt: rt}
+	})
+
+	cli, err := rest.UnversionedRESTClientFor(restCfg)
+	require.NoError(t, err)
+
+	spec := newScheduleTestSpec()
+	spec.Total = 9
+
+	res, err := Schedule(context.Background(), spec, []rest.Interface{cli})
+	require.NoError(t, err)
+	require.Len(t, res.LatenciesByInstance, 2)
+	assert.Len(t, res.LatenciesByInstance["apiserver-1"], 3)
+	assert.Len(t, res.LatenciesByInstance["apiserver-2"], 3)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Azure/kperf/api/types"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// postDelNamePrefix is the name prefix of all the objects created by
+	// post-delete requests.
+	postDelNamePrefix = "kperf-postdel-"
+
+	// leakCheckPageSize is the page size to list objects for leak check.
+	leakCheckPageSize = 500
+)
+
+// newPostDelNamePrefix returns name prefix which is unique for each run,
+// unless the run is seeded.
+func newPostDelNamePrefix(rnd randSource) string {
+	return fmt.Sprintf("%s%08x-", postDelNamePrefix, rnd.Int63n(1<<32))
+}
+
+// leakChecker compares objects created by post-delete requests before and
+// after run.
+type leakChecker struct {
+	cli         rest.Interface
+	builders    []*requestPostDelBuilder
+	preexisting []int
+}
+
+// newLeakChecker lists objects created by previous runs. It returns nil if
+// there is no post-delete request.
+func newLeakChecker(ctx context.Context, cli rest.Interface, reqs *WeightedRandomRequests) *leakChecker {
+	builders := []*requestPostDelBuilder{}
+	for _, b := range reqs.reqBuilders {
+		if pb, ok := b.(*requestPostDelBuilder); ok {
+			builders = append(builders, pb)
+		}
+	}
+	if len(builders) == 0 {
+		return nil
+	}
+
+	lc := &leakChecker{
+		cli:         cli,
+		builders:    builders,
+		preexisting: make([]int, len(builders)),
+	}
+	for idx, b := range builders {
+		names, err := b.listObjectNames(ctx, cli, postDelNamePrefix)
+		if err != nil {
+			klog.Warningf("failed to list %s before run for leak check: %v", b.resourcePath(), err)
+			continue
+		}
+		lc.preexisting[idx] = len(names)
+	}
+	return lc
+}
+
+// check lists objects created by this run and reports the objects which
+// are neither deleted nor tracked by cache. The leaked objects are deleted
+// if cleanup is true.
+func (lc *leakChecker) check(ctx context.Context, cleanup bool) []types.ObjectLeak {
+	res := make([]types.ObjectLeak, 0, len(lc.builders))
+	for idx, b := range lc.builders {
+		names, err := b.listObjectNames(ctx, lc.cli, b.namePrefix)
+		if err != nil {
+			klog.Warningf("failed to list %s after run for leak check: %v", b.resourcePath(), err)
+			continue
+		}
+
+		expected := map[string]struct{}{}
+		for _, name := range b.cache.Items() {
+			expected[name] = struct{}{}
+		}
+
+		leaked := []string{}
+		for _, name := range names {
+			if _, ok := expected[name]; !ok {
+				leaked = append(leaked, name)
+			}
+		}
+
+		leak := types.ObjectLeak{
+			Resource:    b.resourcePath(),
+			Preexisting: lc.preexisting[idx],
+			Remaining:   len(names),
+			Expected:    len(expected),
+			Leaked:      len(leaked),
+		}
+		if len(leaked) > 0 {
+			klog.Warningf("%d objects of %s leaked by post-delete requests", len(leaked), leak.Resource)
+		}
+
+		if cleanup {
+			for _, name := range leaked {
+				if err := b.deleteRequest(lc.cli, name).Do(ctx).Error(); err != nil {
+					klog.Warningf("failed to clean up leaked object %s in %s: %v", name, leak.Resource, err)
+					continue
+				}
+				leak.CleanedUp++
+			}
+		}
+		res = append(res, leak)
+	}
+	return res
+}
+
+// resourcePath returns the URI of resource collection.
+func (b *requestPostDelBuilder) resourcePath() string {
+	return "/" + path.Join(append(b.namespacePath(), b.resource)...)
+}
+
+// listObjectNames lists names of the objects with prefix, except the
+// objects being deleted.
+func (b *requestPostDelBuilder) listObjectNames(ctx context.Context, cli rest.Interface, prefix string) ([]string, error) {
+	comps := append(b.namespacePath(), b.resource)
+
+	res := []string{}
+	continueToken := ""
+	for {
+		// NOTE: Always use JSON so that the response can be decoded
+		// without scheme.
+		data, err := cli.Get().AbsPath(comps...).
+			SetHeader("Accept", "application/json").
+			SpecificallyVersionedParams(
+				&metav1.ListOptions{
+					Limit:    leakCheckPageSize,
+					Continue: continueToken,
+				},
+				scheme.ParameterCodec,
+				schema.GroupVersion{Version: "v1"},
+			).DoRaw(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var list metav1.PartialObjectMetadataList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		for _, item := range list.Items {
+			// NOTE: The terminating object, like namespace, has been
+			// deleted and it isn't leaked.
+			if item.DeletionTimestamp != nil {
+				continue
+			}
+			if strings.HasPrefix(item.Name, prefix) {
+				res = append(res, item.Name)
+			}
+		}
+
+		continueToken = list.Continue
+		if continueToken == "" {
+			return res, nil
+		}
+	}
+}
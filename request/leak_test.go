@@ -0,0 +1,98 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestLeakChecker(t *testing.T) {
+	b, err := newRequestPostDelBuilder(&types.RequestPostDel{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Namespace:   "default",
+		DeleteRatio: 0.5,
+		Template:    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Values.namePattern }}\n",
+	}, "", 0, cryptoRandSource{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	objects := []string{"kperf-postdel-00000000-1", "other"}
+	deleted := []string{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			// NOTE: one object per page to verify pagination.
+			idx := 0
+			if token := r.URL.Query().Get("continue"); token != "" {
+				_, _ = fmt.Sscanf(token, "%d", &idx)
+			}
+
+			items, continueToken := "", ""
+			if idx < len(objects) {
+				items = fmt.Sprintf(`{"metadata":{"name":%q}}`, objects[idx])
+				if idx+1 < len(objects) {
+					continueToken = fmt.Sprintf("%d", idx+1)
+				}
+			}
+			fmt.Fprintf(w, `{"kind":"ConfigMapList","apiVersion":"v1","metadata":{"continue":%q},"items":[%s]}`, continueToken, items)
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+
+	cli, err := rest.RESTClientFor(&rest.Config{
+		Host:  srv.URL,
+		Proxy: http.ProxyFromEnvironment,
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &schema.GroupVersion{Version: "v1"},
+			NegotiatedSerializer: unstructuredscheme.NewNegotiatedSerializer(),
+		},
+	})
+	require.NoError(t, err)
+
+	reqs := &WeightedRandomRequests{reqBuilders: []RESTRequestBuilder{b}}
+	lc := newLeakChecker(context.Background(), cli, reqs)
+	require.NotNil(t, lc)
+
+	// Two objects are created by this run and only one is tracked.
+	mu.Lock()
+	objects = append(objects, b.namePrefix+"1", b.namePrefix+"2")
+	mu.Unlock()
+	b.cache.Push(b.namePrefix + "1")
+
+	leaks := lc.check(context.Background(), true)
+	assert.Equal(t, []types.ObjectLeak{
+		{
+			Resource:    "/api/v1/namespaces/default/configmaps",
+			Preexisting: 1,
+			Remaining:   2,
+			Expected:    1,
+			Leaked:      1,
+			CleanedUp:   1,
+		},
+	}, leaks)
+	assert.Equal(t, []string{"/api/v1/namespaces/default/configmaps/" + b.namePrefix + "2"}, deleted)
+}
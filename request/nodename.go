@@ -0,0 +1,81 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// nodepoolLabel is the node label key whose value is the virtual node pool
+// name.
+//
+// NOTE: Please align with ../manifests/virtualcluster/nodes/templates/nodes.tpl
+const nodepoolLabel = "alpha.kperf.io/nodepool"
+
+// resolveNodeNames lists the nodes in node pool for the LIST requests with
+// nodeNameFromNodepool, so that the requests can rotate field selector
+// across them.
+func (r *WeightedRandomRequests) resolveNodeNames(ctx context.Context, cli rest.Interface) error {
+	for _, b := range r.reqBuilders {
+		lb, ok := b.(*requestListBuilder)
+		if !ok || lb.nodepool == "" {
+			continue
+		}
+
+		names, err := listNodepoolNodeNames(ctx, cli, lb.nodepool)
+		if err != nil {
+			return fmt.Errorf("failed to list nodes in nodepool %s: %w", lb.nodepool, err)
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no nodes found in nodepool %s", lb.nodepool)
+		}
+		lb.nodeNames = names
+	}
+	return nil
+}
+
+// listNodepoolNodeNames returns names of nodes in node pool.
+func listNodepoolNodeNames(ctx context.Context, cli rest.Interface, nodepool string) ([]string, error) {
+	res := []string{}
+	continueToken := ""
+	for {
+		// NOTE: Always use JSON so that the response can be decoded
+		// without scheme.
+		data, err := cli.Get().AbsPath("api", "v1", "nodes").
+			SetHeader("Accept", "application/json").
+			SpecificallyVersionedParams(
+				&metav1.ListOptions{
+					LabelSelector: fmt.Sprintf("%s=%s", nodepoolLabel, nodepool),
+					Limit:         leakCheckPageSize,
+					Continue:      continueToken,
+				},
+				scheme.ParameterCodec,
+				schema.GroupVersion{Version: "v1"},
+			).DoRaw(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var list metav1.PartialObjectMetadataList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("failed to decode list response: %w", err)
+		}
+
+		for _, item := range list.Items {
+			res = append(res, item.Name)
+		}
+
+		continueToken = list.Continue
+		if continueToken == "" {
+			return res, nil
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"crypto/rand"
+	"math/big"
+	mathrand "math/rand"
+	"sync"
+)
+
+// randSource generates random numbers to pick requests and object names.
+type randSource interface {
+	// Int63n returns a random number in [0, n).
+	Int63n(n int64) int64
+}
+
+// cryptoRandSource uses crypto/rand, which is the default.
+type cryptoRandSource struct{}
+
+// Int63n implements randSource.
+func (cryptoRandSource) Int63n(n int64) int64 {
+	rndInt, err := rand.Int(rand.Reader, big.NewInt(n))
+	if err != nil {
+		panic(err)
+	}
+	return rndInt.Int64()
+}
+
+// seededRandSource uses seeded math/rand so that the sequence is
+// reproducible. It's not cryptographically secure.
+type seededRandSource struct {
+	mu  sync.Mutex
+	rnd *mathrand.Rand
+}
+
+func newSeededRandSource(seed int64) *seededRandSource {
+	return &seededRandSource{
+		//nolint:gosec // It's for reproducibility, not security.
+		rnd: mathrand.New(mathrand.NewSource(seed)),
+	}
+}
+
+// Int63n implements randSource.
+func (s *seededRandSource) Int63n(n int64) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Int63n(n)
+}
@@ -6,17 +6,20 @@ package request
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
 	"fmt"
-	"math/big"
 	"sync"
 	"sync/atomic"
+	"text/template"
 	"time"
 
 	"github.com/Azure/kperf/api/types"
 	"github.com/Azure/kperf/contrib/utils"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -25,59 +28,163 @@ import (
 
 // WeightedRandomRequests is used to generate requests based on LoadProfileSpec.
 type WeightedRandomRequests struct {
-	once         sync.Once
-	wg           sync.WaitGroup
+	once sync.Once
+	wg   sync.WaitGroup
+	// inflight tracks the requests received from reqBuilderCh which
+	// aren't finished yet. It's used by StopAndDrain.
+	inflight     sync.WaitGroup
 	ctx          context.Context
 	cancel       context.CancelFunc
 	reqBuilderCh chan RESTRequestBuilder
 
 	shares      []int
 	reqBuilders []RESTRequestBuilder
+
+	// endShares is the shares at the end of run. The current shares are
+	// interpolated between shares and endShares by progress of the run.
+	// It's nil if there is no phased mix.
+	endShares []int
+	// duration is used to calculate progress if total is zero.
+	duration time.Duration
+
+	// labels identify each request in the phased mix report.
+	labels []string
+	// verbs is the verb of each request.
+	verbs []string
+	// minPerVerb is the minimum number of requests issued for each verb
+	// before the remainder is distributed by weight.
+	minPerVerb int
+	// phaseCounts records the number of picked requests in each phase.
+	phaseCounts [][]int
+	// rnd is used to pick requests.
+	rnd randSource
 }
 
+// defaultMixPhases is the default number of phases to report effective
+// request mix.
+const defaultMixPhases = 4
+
 // NewWeightedRandomRequests creates new instance of WeightedRandomRequests.
+// The requests and object names are picked by crypto/rand.
 func NewWeightedRandomRequests(spec *types.LoadProfileSpec) (*WeightedRandomRequests, error) {
+	return newWeightedRandomRequests(spec, cryptoRandSource{})
+}
+
+// NewWeightedRandomRequestsWithSeed is like NewWeightedRandomRequests but
+// picks requests and object names by math/rand with the seed, so that the
+// same sequence can be reproduced. It's not cryptographically secure and
+// it's only intended for reproducibility. The sequence of each request's
+// object names is reproducible only with one client because the order of
+// concurrent clients isn't deterministic.
+func NewWeightedRandomRequestsWithSeed(spec *types.LoadProfileSpec, seed int64) (*WeightedRandomRequests, error) {
+	return newWeightedRandomRequests(spec, newSeededRandSource(seed))
+}
+
+func newWeightedRandomRequests(spec *types.LoadProfileSpec, rnd randSource) (*WeightedRandomRequests, error) {
 	if err := spec.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid load profile spec: %v", err)
 	}
 
 	shares := make([]int, 0, len(spec.Requests))
+	endShares := make([]int, 0, len(spec.Requests))
+	labels := make([]string, 0, len(spec.Requests))
+	verbs := make([]string, 0, len(spec.Requests))
+	phased := false
 	reqBuilders := make([]RESTRequestBuilder, 0, len(spec.Requests))
-	for _, r := range spec.Requests {
+	for idx, r := range spec.Requests {
 		shares = append(shares, r.Shares)
+		labels = append(labels, requestLabel(idx, r))
+		verbs = append(verbs, requestVerb(r))
+
+		endShare := r.Shares
+		if r.EndShares != nil {
+			endShare = *r.EndShares
+			phased = true
+		}
+		endShares = append(endShares, endShare)
 
 		var builder RESTRequestBuilder
+		var err error
 		switch {
 		case r.StaleList != nil:
-			builder = newRequestListBuilder(r.StaleList, "0", spec.MaxRetries)
+			builder, err = newRequestListBuilder(r.StaleList, "0", spec.MaxRetries, spec.ValidateResponse, rnd)
+			if err != nil {
+				return nil, err
+			}
 		case r.QuorumList != nil:
-			builder = newRequestListBuilder(r.QuorumList, "", spec.MaxRetries)
+			builder, err = newRequestListBuilder(r.QuorumList, "", spec.MaxRetries, spec.ValidateResponse, rnd)
+			if err != nil {
+				return nil, err
+			}
 		case r.WatchList != nil:
 			builder = newRequestWatchListBuilder(r.WatchList, spec.MaxRetries)
 		case r.StaleGet != nil:
-			builder = newRequestGetBuilder(r.StaleGet, "0", spec.MaxRetries)
+			builder = newRequestGetBuilder(r.StaleGet, "0", spec.MaxRetries, spec.ValidateResponse)
 		case r.QuorumGet != nil:
-			builder = newRequestGetBuilder(r.QuorumGet, "", spec.MaxRetries)
+			builder = newRequestGetBuilder(r.QuorumGet, "", spec.MaxRetries, spec.ValidateResponse)
 		case r.GetPodLog != nil:
 			builder = newRequestGetPodLogBuilder(r.GetPodLog, spec.MaxRetries)
+		case r.Put != nil:
+			builder, err = newRequestPutBuilder(r.Put, spec.MaxRetries, rnd)
+			if err != nil {
+				return nil, err
+			}
 		case r.Patch != nil:
-			builder = newRequestPatchBuilder(r.Patch, "", spec.MaxRetries)
+			builder, err = newRequestPatchBuilder(r.Patch, "", spec.MaxRetries, rnd)
+			if err != nil {
+				return nil, err
+			}
 		case r.PostDel != nil:
-			builder = newRequestPostDelBuilder(r.PostDel, "", spec.MaxRetries)
+			builder, err = newRequestPostDelBuilder(r.PostDel, "", spec.MaxRetries, rnd)
+			if err != nil {
+				return nil, err
+			}
+		case r.Post != nil:
+			builder, err = newRequestPostBuilder(r.Post, spec.MaxRetries, rnd)
+			if err != nil {
+				return nil, err
+			}
+		case r.DeleteCollection != nil:
+			builder = newRequestDeleteCollectionBuilder(r.DeleteCollection, spec.MaxRetries)
+		case r.CreateNamespace != nil:
+			builder, err = newRequestCreateNamespaceBuilder(r.CreateNamespace, spec.MaxRetries, rnd)
+			if err != nil {
+				return nil, err
+			}
 		default:
-			return nil, fmt.Errorf("not implement for PUT yet")
+			return nil, fmt.Errorf("unknown request type")
 		}
 		reqBuilders = append(reqBuilders, builder)
 	}
+	linkCaches(spec.Requests, reqBuilders, rnd)
 
 	ctx, cancel := context.WithCancel(context.Background())
-	return &WeightedRandomRequests{
+	res := &WeightedRandomRequests{
 		ctx:          ctx,
 		cancel:       cancel,
 		reqBuilderCh: make(chan RESTRequestBuilder),
 		shares:       shares,
 		reqBuilders:  reqBuilders,
-	}, nil
+		duration:     time.Duration(spec.Duration) * time.Second,
+		labels:       labels,
+		verbs:        verbs,
+		minPerVerb:   spec.MinRequestsPerVerb,
+		rnd:          rnd,
+	}
+
+	if phased {
+		phases := spec.MixPhases
+		if phases <= 0 {
+			phases = defaultMixPhases
+		}
+
+		res.endShares = endShares
+		res.phaseCounts = make([][]int, phases)
+		for i := range res.phaseCounts {
+			res.phaseCounts[i] = make([]int, len(shares))
+		}
+	}
+	return res, nil
 }
 
 // Run starts to random pick request.
@@ -85,51 +192,306 @@ func (r *WeightedRandomRequests) Run(ctx context.Context, total int) {
 	defer r.wg.Done()
 	r.wg.Add(1)
 
+	start := time.Now()
+
+	picks := r.guaranteedPicks()
+
 	sum := 0
 	for {
 		if total > 0 && sum >= total {
 			break
 		}
-		builder := r.randomPick()
+
+		progress := float64(0)
+		switch {
+		case total > 0:
+			progress = float64(sum) / float64(total)
+		case r.duration > 0:
+			progress = min(float64(time.Since(start))/float64(r.duration), 1)
+		}
+
+		var idx int
+		if len(picks) > 0 {
+			idx = picks[0]
+		} else {
+			idx = r.randomPick(progress)
+		}
+
+		r.inflight.Add(1)
 		select {
-		case r.reqBuilderCh <- builder:
+		case r.reqBuilderCh <- r.reqBuilders[idx]:
 			sum++
+			r.observePhase(progress, idx)
+			if len(picks) > 0 {
+				picks = picks[1:]
+			}
 		case <-r.ctx.Done():
+			r.inflight.Done()
 			return
 		case <-ctx.Done():
+			r.inflight.Done()
 			return
 		}
 	}
 }
 
-// Chan returns channel to get random request.
+// warmupRequestBuilder marks the request sent during warmup, which isn't
+// recorded.
+type warmupRequestBuilder struct {
+	RESTRequestBuilder
+}
+
+// Warmup generates requests picked by initial weight for the duration d.
+// The requests are wrapped by warmupRequestBuilder.
+func (r *WeightedRandomRequests) Warmup(ctx context.Context, d time.Duration) {
+	defer r.wg.Done()
+	r.wg.Add(1)
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for {
+		idx := r.randomPick(0)
+
+		r.inflight.Add(1)
+		select {
+		case r.reqBuilderCh <- &warmupRequestBuilder{r.reqBuilders[idx]}:
+		case <-timer.C:
+			r.inflight.Done()
+			return
+		case <-r.ctx.Done():
+			r.inflight.Done()
+			return
+		case <-ctx.Done():
+			r.inflight.Done()
+			return
+		}
+	}
+}
+
+// Chan returns channel to get random request. The receiver should call
+// Done when the request is finished so that StopAndDrain can wait for it.
 func (r *WeightedRandomRequests) Chan() chan RESTRequestBuilder {
 	return r.reqBuilderCh
 }
 
-func (r *WeightedRandomRequests) randomPick() RESTRequestBuilder {
-	sum := 0
-	for _, s := range r.shares {
-		sum += s
+// Done marks one request received from Chan as finished.
+func (r *WeightedRandomRequests) Done() {
+	r.inflight.Done()
+}
+
+// randomPick returns index of request picked by weight at the progress
+// (0 to 1) of run.
+func (r *WeightedRandomRequests) randomPick(progress float64) int {
+	return pickByWeight(r.rnd, r.currentShares(progress))
+}
+
+// guaranteedPicks returns indexes of requests which are issued before the
+// weighted random so that each verb is issued at least minPerVerb times.
+// The verbs are interleaved and the request is picked by weight within
+// the verb. The request with zero shares during the run isn't counted.
+func (r *WeightedRandomRequests) guaranteedPicks() []int {
+	if r.minPerVerb <= 0 {
+		return nil
 	}
 
-	rndInt, err := rand.Int(rand.Reader, big.NewInt(int64(sum)))
-	if err != nil {
-		panic(err)
+	verbs := []string{}
+	sharesByVerb := map[string][]int{}
+	for idx, verb := range r.verbs {
+		share := r.shares[idx]
+		if r.endShares != nil {
+			share = max(share, r.endShares[idx])
+		}
+		if share == 0 {
+			continue
+		}
+
+		shares, ok := sharesByVerb[verb]
+		if !ok {
+			shares = make([]int, len(r.shares))
+			sharesByVerb[verb] = shares
+			verbs = append(verbs, verb)
+		}
+		shares[idx] = share
 	}
 
-	rnd := rndInt.Int64()
-	for i := range r.shares {
-		s := int64(r.shares[i])
+	res := make([]int, 0, r.minPerVerb*len(verbs))
+	for i := 0; i < r.minPerVerb; i++ {
+		for _, verb := range verbs {
+			res = append(res, pickByWeight(r.rnd, sharesByVerb[verb]))
+		}
+	}
+	return res
+}
+
+// pickByWeight returns index picked randomly by weight.
+func pickByWeight(rndSrc randSource, shares []int) int {
+	sum := 0
+	for _, s := range shares {
+		sum += s
+	}
+
+	rnd := rndSrc.Int63n(int64(sum))
+	for i := range shares {
+		s := int64(shares[i])
 		if rnd < s {
-			return r.reqBuilders[i]
+			return i
 		}
 		rnd -= s
 	}
 	panic("unreachable")
 }
 
-// Stop stops request generator.
+// currentShares returns shares at the progress (0 to 1) of run.
+func (r *WeightedRandomRequests) currentShares(progress float64) []int {
+	if r.endShares == nil {
+		return r.shares
+	}
+
+	// NOTE: Scale shares so that the interpolation won't be truncated
+	// into zero for small shares.
+	const scale = 1000
+
+	res := make([]int, len(r.shares))
+	for i := range r.shares {
+		start, end := float64(r.shares[i]), float64(r.endShares[i])
+		res[i] = int((start + (end-start)*progress) * scale)
+	}
+	return res
+}
+
+// observePhase records the picked request in the phase of run.
+func (r *WeightedRandomRequests) observePhase(progress float64, idx int) {
+	if r.phaseCounts == nil {
+		return
+	}
+
+	phase := min(int(progress*float64(len(r.phaseCounts))), len(r.phaseCounts)-1)
+	r.phaseCounts[phase][idx]++
+}
+
+// PhaseMix returns the number of picked requests for each request in each
+// phase. It's nil if there is no phased mix. It should be called after Stop.
+func (r *WeightedRandomRequests) PhaseMix() []map[string]int {
+	if r.phaseCounts == nil {
+		return nil
+	}
+
+	res := make([]map[string]int, 0, len(r.phaseCounts))
+	for _, counts := range r.phaseCounts {
+		mix := make(map[string]int, len(counts))
+		for idx, n := range counts {
+			mix[r.labels[idx]] = n
+		}
+		res = append(res, mix)
+	}
+	return res
+}
+
+// CacheSize returns the number of objects created by post-delete requests
+// and not deleted yet.
+func (r *WeightedRandomRequests) CacheSize() int {
+	total := 0
+	for _, b := range r.reqBuilders {
+		if pb, ok := b.(*requestPostDelBuilder); ok {
+			total += pb.cache.Len()
+		}
+	}
+	return total
+}
+
+// requestVerb returns the verb of request, which is aligned with the
+// method of Requester.
+func requestVerb(r *types.WeightedRequest) string {
+	switch {
+	case r.StaleList != nil, r.QuorumList != nil:
+		return "LIST"
+	case r.WatchList != nil:
+		return "WATCHLIST"
+	case r.StaleGet != nil, r.QuorumGet != nil:
+		return "GET"
+	case r.Put != nil:
+		return "PUT"
+	case r.Patch != nil:
+		if patchType, _ := types.GetPatchType(r.Patch.PatchType); patchType == apitypes.ApplyPatchType {
+			return "APPLY"
+		}
+		return "PATCH"
+	case r.GetPodLog != nil:
+		return "POD_LOG"
+	case r.PostDel != nil, r.CreateNamespace != nil:
+		return "POST/DELETE"
+	case r.Post != nil:
+		return "POST"
+	case r.DeleteCollection != nil:
+		return "DELETECOLLECTION"
+	}
+	return "UNKNOWN"
+}
+
+// linkCaches shares the cache of postDel request with GET requests which
+// read it by fromCache. The spec has been validated.
+func linkCaches(reqs []*types.WeightedRequest, builders []RESTRequestBuilder, rnd randSource) {
+	caches := map[string]*Cache{}
+	for idx, r := range reqs {
+		if r.PostDel != nil && r.PostDel.CacheName != "" {
+			caches[r.PostDel.CacheName] = builders[idx].(*requestPostDelBuilder).cache
+		}
+	}
+
+	for idx, r := range reqs {
+		get := r.StaleGet
+		if get == nil {
+			get = r.QuorumGet
+		}
+		if get == nil || get.FromCache == "" {
+			continue
+		}
+
+		gb := builders[idx].(*requestGetBuilder)
+		gb.cache = caches[get.FromCache]
+		gb.rnd = rnd
+	}
+}
+
+// requestLabel returns readable label for idx-th request.
+func requestLabel(idx int, r *types.WeightedRequest) string {
+	name := "unknown"
+	switch {
+	case r.StaleList != nil:
+		name = "staleList"
+	case r.QuorumList != nil:
+		name = "quorumList"
+	case r.WatchList != nil:
+		name = "watchList"
+	case r.StaleGet != nil:
+		name = "staleGet"
+	case r.QuorumGet != nil:
+		name = "quorumGet"
+	case r.Put != nil:
+		name = "put"
+	case r.Patch != nil:
+		name = "patch"
+	case r.GetPodLog != nil:
+		name = "getPodLog"
+	case r.PostDel != nil:
+		name = "postDel"
+	case r.Post != nil:
+		name = "post"
+	case r.DeleteCollection != nil:
+		name = "deleteCollection"
+	case r.CreateNamespace != nil:
+		name = "createNamespace"
+	}
+
+	if gvr := r.GroupVersionResource(); gvr != nil {
+		name = fmt.Sprintf("%s/%s", name, gvr.Resource)
+	}
+	return fmt.Sprintf("%d:%s", idx, name)
+}
+
+// Stop stops request generator. It doesn't wait for the requests which
+// have been received from Chan.
 func (r *WeightedRandomRequests) Stop() {
 	r.once.Do(func() {
 		r.cancel()
@@ -138,38 +500,72 @@ func (r *WeightedRandomRequests) Stop() {
 	})
 }
 
+// StopAndDrain stops request generator and waits up to timeout for the
+// requests received from Chan to be finished. It returns false if there
+// are still unfinished requests after timeout.
+func (r *WeightedRandomRequests) StopAndDrain(timeout time.Duration) bool {
+	r.Stop()
+
+	drained := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
 // RESTRequestBuilder is used to build rest.Request.
 type RESTRequestBuilder interface {
 	Build(cli rest.Interface) Requester
 }
 
 type requestGetBuilder struct {
-	version         schema.GroupVersion
-	resource        string
-	namespace       string
-	name            string
-	resourceVersion string
-	maxRetries      int
+	version          schema.GroupVersion
+	resource         string
+	kind             string
+	namespace        string
+	name             string
+	subresource      string
+	resourceVersion  string
+	maxRetries       int
+	validateResponse bool
+
+	// cache is shared with the postDel request named by fromCache. It's
+	// nil if the request always gets the static name.
+	cache *Cache
+	rnd   randSource
 }
 
-func newRequestGetBuilder(src *types.RequestGet, resourceVersion string, maxRetries int) *requestGetBuilder {
+func newRequestGetBuilder(src *types.RequestGet, resourceVersion string, maxRetries int, validateResponse bool) *requestGetBuilder {
 	return &requestGetBuilder{
 		version: schema.GroupVersion{
 			Group:   src.Group,
 			Version: src.Version,
 		},
-		resource:        src.Resource,
-		namespace:       src.Namespace,
-		name:            src.Name,
-		resourceVersion: resourceVersion,
-		maxRetries:      maxRetries,
+		resource:         src.Resource,
+		kind:             src.Kind,
+		namespace:        src.Namespace,
+		name:             src.Name,
+		subresource:      src.Subresource,
+		resourceVersion:  resourceVersion,
+		maxRetries:       maxRetries,
+		validateResponse: validateResponse,
 	}
 }
 
 // Build implements RequestBuilder.Build.
 func (b *requestGetBuilder) Build(cli rest.Interface) Requester {
 	// https://kubernetes.io/docs/reference/using-api/#api-groups
-	comps := make([]string, 0, 5)
+	comps := make([]string, 0, 8)
 	if b.version.Group == "" {
 		comps = append(comps, "api", b.version.Version)
 	} else {
@@ -178,48 +574,106 @@ func (b *requestGetBuilder) Build(cli rest.Interface) Requester {
 	if b.namespace != "" {
 		comps = append(comps, "namespaces", b.namespace)
 	}
-	comps = append(comps, b.resource, b.name)
+	name := b.name
+	if b.cache != nil {
+		if cached, ok := b.cache.Random(b.rnd); ok {
+			name = cached
+		}
+	}
+	comps = append(comps, b.resource, name)
+	if b.subresource != "" {
+		comps = append(comps, b.subresource)
+	}
 
-	return &DiscardRequester{
-		BaseRequester: BaseRequester{
-			method: "GET",
-			req: cli.Get().AbsPath(comps...).
-				SpecificallyVersionedParams(
-					&metav1.GetOptions{ResourceVersion: b.resourceVersion},
-					scheme.ParameterCodec,
-					schema.GroupVersion{Version: "v1"},
-				).MaxRetries(b.maxRetries),
-		},
+	baseReqr := BaseRequester{
+		method: "GET",
+		req: cli.Get().AbsPath(comps...).
+			SpecificallyVersionedParams(
+				&metav1.GetOptions{ResourceVersion: b.resourceVersion},
+				scheme.ParameterCodec,
+				schema.GroupVersion{Version: "v1"},
+			).MaxRetries(b.maxRetries),
 	}
+
+	if b.validateResponse {
+		return &ValidateRequester{
+			BaseRequester: baseReqr,
+			expected: expectedObject{
+				version:   b.version,
+				kind:      b.kind,
+				namespace: b.namespace,
+				name:      name,
+			},
+		}
+	}
+	return &DiscardRequester{BaseRequester: baseReqr}
 }
 
 type requestListBuilder struct {
-	version         schema.GroupVersion
-	resource        string
-	namespace       string
-	limit           int64
-	labelSelector   string
-	fieldSelector   string
-	resourceVersion string
-	maxRetries      int
+	version          schema.GroupVersion
+	resource         string
+	kind             string
+	namespace        string
+	limit            int64
+	labelSelector    string
+	fieldSelector    string
+	resourceVersion  string
+	maxRetries       int
+	validateResponse bool
+	asTable          bool
+	metadataOnly     bool
+
+	// selectorTemplate expands labelSelector with random values for each
+	// request. It's nil if labelSelector has no placeholder.
+	selectorTemplate *selectorTemplate
+	rnd              randSource
+
+	// nodepool is the virtual node pool whose nodeNames are rotated in
+	// spec.nodeName field selector. nodeNames is resolved before run.
+	nodepool  string
+	nodeNames []string
+	nodeIdx   uint64
 }
 
-func newRequestListBuilder(src *types.RequestList, resourceVersion string, maxRetries int) *requestListBuilder {
+func newRequestListBuilder(src *types.RequestList, resourceVersion string, maxRetries int, validateResponse bool, rnd randSource) (*requestListBuilder, error) {
+	tmpl, err := parseSelectorTemplate(src.Selector)
+	if err != nil {
+		return nil, err
+	}
+
 	return &requestListBuilder{
 		version: schema.GroupVersion{
 			Group:   src.Group,
 			Version: src.Version,
 		},
-		resource:        src.Resource,
-		namespace:       src.Namespace,
-		limit:           int64(src.Limit),
-		labelSelector:   src.Selector,
-		fieldSelector:   src.FieldSelector,
-		resourceVersion: resourceVersion,
-		maxRetries:      maxRetries,
-	}
+		resource:         src.Resource,
+		kind:             src.Kind,
+		namespace:        src.Namespace,
+		limit:            int64(src.Limit),
+		labelSelector:    src.Selector,
+		fieldSelector:    src.FieldSelector,
+		resourceVersion:  resourceVersion,
+		maxRetries:       maxRetries,
+		validateResponse: validateResponse,
+		asTable:          src.AsTable,
+		metadataOnly:     src.MetadataOnly,
+		selectorTemplate: tmpl,
+		rnd:              rnd,
+		nodepool:         src.NodeNameFromNodepool,
+	}, nil
 }
 
+const (
+	// tableAcceptHeader asks for Table like kubectl.
+	tableAcceptHeader = "application/json;as=Table;g=meta.k8s.io;v=v1"
+	// metadataListAcceptHeader asks for PartialObjectMetadataList like
+	// metadata-only informers.
+	metadataListAcceptHeader = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1"
+	// metadataWatchAcceptHeader asks for PartialObjectMetadata in watch
+	// events like metadata-only informers.
+	metadataWatchAcceptHeader = "application/json;as=PartialObjectMetadata;g=meta.k8s.io;v=v1"
+)
+
 // Build implements RequestBuilder.Build.
 func (b *requestListBuilder) Build(cli rest.Interface) Requester {
 	// https://kubernetes.io/docs/reference/using-api/#api-groups
@@ -234,16 +688,115 @@ func (b *requestListBuilder) Build(cli rest.Interface) Requester {
 	}
 	comps = append(comps, b.resource)
 
+	labelSelector := b.labelSelector
+	if b.selectorTemplate != nil {
+		labelSelector = b.selectorTemplate.expand(b.rnd)
+	}
+
+	fieldSelector := b.fieldSelector
+	if len(b.nodeNames) > 0 {
+		idx := (atomic.AddUint64(&b.nodeIdx, 1) - 1) % uint64(len(b.nodeNames))
+		fieldSelector = "spec.nodeName=" + b.nodeNames[idx]
+	}
+
+	baseReqr := BaseRequester{
+		method: "LIST",
+		req: cli.Get().AbsPath(comps...).
+			SpecificallyVersionedParams(
+				&metav1.ListOptions{
+					LabelSelector:   labelSelector,
+					FieldSelector:   fieldSelector,
+					ResourceVersion: b.resourceVersion,
+					Limit:           b.limit,
+				},
+				scheme.ParameterCodec,
+				schema.GroupVersion{Version: "v1"},
+			).MaxRetries(b.maxRetries),
+	}
+
+	switch {
+	case b.asTable:
+		baseReqr.req.SetHeader("Accept", tableAcceptHeader)
+	case b.metadataOnly:
+		baseReqr.req.SetHeader("Accept", metadataListAcceptHeader)
+	}
+
+	if b.validateResponse {
+		return &ValidateRequester{
+			BaseRequester: baseReqr,
+			expected: expectedObject{
+				version: b.version,
+				kind:    b.kind,
+				list:    true,
+			},
+		}
+	}
+	return &DiscardRequester{BaseRequester: baseReqr}
+}
+
+type requestDeleteCollectionBuilder struct {
+	version       schema.GroupVersion
+	resource      string
+	namespace     string
+	labelSelector string
+	fieldSelector string
+	maxRetries    int
+
+	gracePeriodSeconds *int64
+	propagationPolicy  *metav1.DeletionPropagation
+}
+
+func newRequestDeleteCollectionBuilder(src *types.RequestDeleteCollection, maxRetries int) *requestDeleteCollectionBuilder {
+	var propagationPolicy *metav1.DeletionPropagation
+	if src.PropagationPolicy != "" {
+		propagationPolicy = toPtr(metav1.DeletionPropagation(src.PropagationPolicy))
+	}
+
+	return &requestDeleteCollectionBuilder{
+		version: schema.GroupVersion{
+			Group:   src.Group,
+			Version: src.Version,
+		},
+		resource:           src.Resource,
+		namespace:          src.Namespace,
+		labelSelector:      src.Selector,
+		fieldSelector:      src.FieldSelector,
+		maxRetries:         maxRetries,
+		gracePeriodSeconds: src.GracePeriodSeconds,
+		propagationPolicy:  propagationPolicy,
+	}
+}
+
+// Build implements RequestBuilder.Build.
+func (b *requestDeleteCollectionBuilder) Build(cli rest.Interface) Requester {
+	// https://kubernetes.io/docs/reference/using-api/#api-groups
+	comps := make([]string, 0, 5)
+	if b.version.Group == "" {
+		comps = append(comps, "api", b.version.Version)
+	} else {
+		comps = append(comps, "apis", b.version.Group, b.version.Version)
+	}
+	if b.namespace != "" {
+		comps = append(comps, "namespaces", b.namespace)
+	}
+	comps = append(comps, b.resource)
+
 	return &DiscardRequester{
 		BaseRequester: BaseRequester{
-			method: "LIST",
-			req: cli.Get().AbsPath(comps...).
+			method: "DELETECOLLECTION",
+			req: cli.Delete().AbsPath(comps...).
 				SpecificallyVersionedParams(
 					&metav1.ListOptions{
-						LabelSelector:   b.labelSelector,
-						FieldSelector:   b.fieldSelector,
-						ResourceVersion: b.resourceVersion,
-						Limit:           b.limit,
+						LabelSelector: b.labelSelector,
+						FieldSelector: b.fieldSelector,
+					},
+					scheme.ParameterCodec,
+					schema.GroupVersion{Version: "v1"},
+				).
+				SpecificallyVersionedParams(
+					&metav1.DeleteOptions{
+						GracePeriodSeconds: b.gracePeriodSeconds,
+						PropagationPolicy:  b.propagationPolicy,
 					},
 					scheme.ParameterCodec,
 					schema.GroupVersion{Version: "v1"},
@@ -259,6 +812,8 @@ type requestWatchListBuilder struct {
 	labelSelector string
 	fieldSelector string
 	maxRetries    int
+	metadataOnly  bool
+	resume        bool
 }
 
 func newRequestWatchListBuilder(src *types.RequestWatchList, maxRetries int) *requestWatchListBuilder {
@@ -272,11 +827,30 @@ func newRequestWatchListBuilder(src *types.RequestWatchList, maxRetries int) *re
 		labelSelector: src.Selector,
 		fieldSelector: src.FieldSelector,
 		maxRetries:    maxRetries,
+		metadataOnly:  src.MetadataOnly,
+		resume:        src.Resume,
 	}
 }
 
 // Build implements RequestBuilder.Build.
 func (b *requestWatchListBuilder) Build(cli rest.Interface) Requester {
+	reqr := &WatchListRequester{
+		BaseRequester: BaseRequester{
+			method: "WATCHLIST",
+			req:    b.newRequest(cli, ""),
+		},
+	}
+	if b.resume {
+		reqr.resumeFn = func(rv string) *rest.Request {
+			return b.newRequest(cli, rv)
+		}
+	}
+	return reqr
+}
+
+// newRequest returns watch request with initial events if rv is empty.
+// Otherwise, it resumes watch from rv without initial events.
+func (b *requestWatchListBuilder) newRequest(cli rest.Interface, rv string) *rest.Request {
 	// https://kubernetes.io/docs/reference/using-api/#api-groups
 	comps := make([]string, 0, 5)
 	if b.version.Group == "" {
@@ -289,25 +863,24 @@ func (b *requestWatchListBuilder) Build(cli rest.Interface) Requester {
 	}
 	comps = append(comps, b.resource)
 
-	return &WatchListRequester{
-		BaseRequester: BaseRequester{
-			method: "WATCHLIST",
-			req: cli.Get().AbsPath(comps...).
-				SpecificallyVersionedParams(
-					&metav1.ListOptions{
-						LabelSelector:        b.labelSelector,
-						FieldSelector:        b.fieldSelector,
-						ResourceVersion:      "",
-						Watch:                true,
-						SendInitialEvents:    toPtr(true),
-						ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
-						AllowWatchBookmarks:  true,
-					},
-					scheme.ParameterCodec,
-					schema.GroupVersion{Version: "v1"},
-				).MaxRetries(b.maxRetries),
-		},
+	req := cli.Get().AbsPath(comps...).
+		SpecificallyVersionedParams(
+			&metav1.ListOptions{
+				LabelSelector:        b.labelSelector,
+				FieldSelector:        b.fieldSelector,
+				ResourceVersion:      rv,
+				Watch:                true,
+				SendInitialEvents:    toPtr(rv == ""),
+				ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+				AllowWatchBookmarks:  true,
+			},
+			scheme.ParameterCodec,
+			schema.GroupVersion{Version: "v1"},
+		).MaxRetries(b.maxRetries)
+	if b.metadataOnly {
+		req.SetHeader("Accept", metadataWatchAcceptHeader)
 	}
+	return req
 }
 
 type requestGetPodLogBuilder struct {
@@ -370,13 +943,30 @@ type requestPatchBuilder struct {
 	name            string
 	keySpaceSize    int
 	patchType       apitypes.PatchType
-	body            interface{}
+	fieldManager    string
+	force           bool
+	bodies          [][]byte
+	randomBody      bool
 	maxRetries      int
+	rnd             randSource
+
+	// bodyCounter is used to pick up body in round-robin.
+	bodyCounter uint64
 }
 
-func newRequestPatchBuilder(src *types.RequestPatch, resourceVersion string, maxRetries int) *requestPatchBuilder {
+func newRequestPatchBuilder(src *types.RequestPatch, resourceVersion string, maxRetries int, rnd randSource) (*requestPatchBuilder, error) {
 	patchType, _ := types.GetPatchType(src.PatchType)
 
+	normalized, err := src.NormalizedBodies()
+	if err != nil {
+		return nil, fmt.Errorf("invalid patch body: %w", err)
+	}
+
+	bodies := make([][]byte, 0, len(normalized))
+	for _, body := range normalized {
+		bodies = append(bodies, []byte(body))
+	}
+
 	return &requestPatchBuilder{
 		version: schema.GroupVersion{
 			Group:   src.Group,
@@ -388,9 +978,26 @@ func newRequestPatchBuilder(src *types.RequestPatch, resourceVersion string, max
 		name:            src.Name,
 		keySpaceSize:    src.KeySpaceSize,
 		patchType:       patchType,
-		body:            []byte(src.Body),
+		fieldManager:    src.FieldManager,
+		force:           src.Force,
+		bodies:          bodies,
+		randomBody:      src.BodyOrder == types.PatchBodyOrderRandom,
 		maxRetries:      maxRetries,
+		rnd:             rnd,
+	}, nil
+}
+
+// nextBody returns body to send based on body order.
+func (b *requestPatchBuilder) nextBody() []byte {
+	if len(b.bodies) == 1 {
+		return b.bodies[0]
 	}
+
+	if b.randomBody {
+		return b.bodies[b.rnd.Int63n(int64(len(b.bodies)))]
+	}
+	idx := (atomic.AddUint64(&b.bodyCounter, 1) - 1) % uint64(len(b.bodies))
+	return b.bodies[idx]
 }
 
 // Build implements RequestBuilder.Build.
@@ -406,23 +1013,149 @@ func (b *requestPatchBuilder) Build(cli rest.Interface) Requester {
 		comps = append(comps, "namespaces", b.namespace)
 	}
 	// Generate random suffix based on keySpaceSize
-	randomInt, _ := rand.Int(rand.Reader, big.NewInt(int64(b.keySpaceSize)))
-	suffix := randomInt.Int64()
+	suffix := b.rnd.Int63n(int64(b.keySpaceSize))
 
 	// Create final resource name: name-{suffix}
 	finalName := fmt.Sprintf("%s-%d", b.name, suffix)
 	comps = append(comps, b.resource, finalName)
 
+	body := b.nextBody()
+	req := cli.Patch(b.patchType).AbsPath(comps...).
+		Body(body).
+		MaxRetries(b.maxRetries)
+	if b.fieldManager != "" {
+		req.Param("fieldManager", b.fieldManager)
+	}
+	if b.force {
+		req.Param("force", "true")
+	}
+
+	// NOTE: Server-side apply is reported separately since field
+	// management makes it much more expensive than the other patches.
+	method := "PATCH"
+	if b.patchType == apitypes.ApplyPatchType {
+		method = "APPLY"
+	}
 	return &DiscardRequester{
 		BaseRequester: BaseRequester{
-			method: "PATCH",
-			req: cli.Patch(b.patchType).AbsPath(comps...).
-				Body(b.body).
+			method:    method,
+			req:       req,
+			sentBytes: int64(len(body)),
+		},
+	}
+}
+
+type requestPutBuilder struct {
+	version      schema.GroupVersion
+	resource     string
+	namespace    string
+	name         string
+	keySpaceSize int
+	valueSize    int
+	maxRetries   int
+	rnd          randSource
+
+	// object is the template of body. It's nil if body is generated
+	// randomly.
+	object *unstructured.Unstructured
+}
+
+func newRequestPutBuilder(src *types.RequestPut, maxRetries int, rnd randSource) (*requestPutBuilder, error) {
+	b := &requestPutBuilder{
+		version: schema.GroupVersion{
+			Group:   src.Group,
+			Version: src.Version,
+		},
+		resource:     src.Resource,
+		namespace:    src.Namespace,
+		name:         src.Name,
+		keySpaceSize: src.KeySpaceSize,
+		valueSize:    src.ValueSize,
+		maxRetries:   maxRetries,
+		rnd:          rnd,
+	}
+
+	if src.Body != "" {
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON([]byte(src.Body)); err != nil {
+			return nil, fmt.Errorf("invalid put body: %w", err)
+		}
+		b.object = obj
+	}
+	return b, nil
+}
+
+// Build implements RequestBuilder.Build.
+func (b *requestPutBuilder) Build(cli rest.Interface) Requester {
+	// https://kubernetes.io/docs/reference/using-api/#api-groups
+	comps := make([]string, 0, 5)
+	if b.version.Group == "" {
+		comps = append(comps, "api", b.version.Version)
+	} else {
+		comps = append(comps, "apis", b.version.Group, b.version.Version)
+	}
+	if b.namespace != "" {
+		comps = append(comps, "namespaces", b.namespace)
+	}
+	// Generate random suffix based on keySpaceSize
+	suffix := b.rnd.Int63n(int64(b.keySpaceSize))
+
+	// Create final resource name: name-{suffix}
+	finalName := fmt.Sprintf("%s-%d", b.name, suffix)
+	comps = append(comps, b.resource, finalName)
+
+	body := b.body(finalName)
+	return &DiscardRequester{
+		BaseRequester: BaseRequester{
+			method: "PUT",
+			req: cli.Put().AbsPath(comps...).
+				Body(body).
 				MaxRetries(b.maxRetries),
+			sentBytes: int64(len(body)),
 		},
 	}
 }
 
+// body returns the object named by name in JSON format.
+func (b *requestPutBuilder) body(name string) []byte {
+	var obj *unstructured.Unstructured
+	if b.object != nil {
+		obj = b.object.DeepCopy()
+	} else {
+		obj = b.randomObject()
+	}
+	obj.SetName(name)
+	if b.namespace != "" {
+		obj.SetNamespace(b.namespace)
+	}
+
+	data, _ := obj.MarshalJSON()
+	return data
+}
+
+// randomObject generates configmap or secret with random data. Data should
+// be changed by each request so that it's a real update to etcd.
+func (b *requestPutBuilder) randomObject() *unstructured.Unstructured {
+	blob := make([]byte, b.valueSize)
+	_, _ = rand.Read(blob)
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetAPIVersion(b.version.Version)
+	switch b.resource {
+	case "secrets":
+		obj.SetKind("Secret")
+		obj.Object["data"] = map[string]interface{}{
+			"data": base64.StdEncoding.EncodeToString(blob),
+		}
+	default:
+		obj.SetKind("ConfigMap")
+		obj.Object["binaryData"] = map[string]interface{}{
+			"data": base64.StdEncoding.EncodeToString(blob),
+		}
+	}
+	return obj
+}
+
 type requestPostDelBuilder struct {
 	version         schema.GroupVersion
 	resource        string
@@ -430,29 +1163,95 @@ type requestPostDelBuilder struct {
 	namespace       string
 	deleteRatio     float64
 	maxRetries      int
+	rnd             randSource
+
+	gracePeriodSeconds *int64
+	propagationPolicy  *metav1.DeletionPropagation
+
+	// tmpl is the object template for POST.
+	tmpl *template.Template
 
 	// Per-builder cache for created resources
 	cache *Cache
 
 	// Per-builder atomic counter for unique ID generation
 	resourceCounter int64
+
+	// namePrefix is unique for each builder so that objects created by
+	// this run can be identified.
+	namePrefix string
+
+	// tolerateTerminating treats DELETE as success if the object is
+	// gone or still terminating, like namespaces.
+	tolerateTerminating bool
+}
+
+// deleteRequest returns DELETE request for the object.
+func (b *requestPostDelBuilder) deleteRequest(cli rest.Interface, name string) *rest.Request {
+	comps := append(b.namespacePath(), b.resource, name)
+
+	return cli.Delete().AbsPath(comps...).
+		SpecificallyVersionedParams(
+			&metav1.DeleteOptions{
+				GracePeriodSeconds: b.gracePeriodSeconds,
+				PropagationPolicy:  b.propagationPolicy,
+			},
+			scheme.ParameterCodec,
+			schema.GroupVersion{Version: "v1"},
+		).MaxRetries(b.maxRetries)
 }
 
-func newRequestPostDelBuilder(src *types.RequestPostDel, resourceVersion string, maxRetries int) *requestPostDelBuilder {
+func newRequestPostDelBuilder(src *types.RequestPostDel, resourceVersion string, maxRetries int, rnd randSource) (*requestPostDelBuilder, error) {
+	tmpl, err := prepareTemplate(src.Resource, src.Namespace, src.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	var propagationPolicy *metav1.DeletionPropagation
+	if src.PropagationPolicy != "" {
+		propagationPolicy = toPtr(metav1.DeletionPropagation(src.PropagationPolicy))
+	}
+
 	return &requestPostDelBuilder{
-		version:         schema.GroupVersion{Group: src.Group, Version: src.Version},
-		resource:        src.Resource,
-		resourceVersion: resourceVersion,
-		namespace:       src.Namespace,
-		deleteRatio:     src.DeleteRatio,
-		maxRetries:      maxRetries,
-		cache:           InitCache(), // Initialize the cache
+		version:            schema.GroupVersion{Group: src.Group, Version: src.Version},
+		resource:           src.Resource,
+		resourceVersion:    resourceVersion,
+		namespace:          src.Namespace,
+		deleteRatio:        src.DeleteRatio,
+		maxRetries:         maxRetries,
+		rnd:                rnd,
+		gracePeriodSeconds: src.GracePeriodSeconds,
+		propagationPolicy:  propagationPolicy,
+		tmpl:               tmpl,
+		cache:              InitCacheWithCap(src.CacheCap),
+		namePrefix:         newPostDelNamePrefix(rnd),
+	}, nil
+}
+
+// newRequestCreateNamespaceBuilder returns post-delete builder for
+// namespaces.
+func newRequestCreateNamespaceBuilder(src *types.RequestCreateNamespace, maxRetries int, rnd randSource) (*requestPostDelBuilder, error) {
+	tmpl, err := prepareTemplate("namespaces", "", "")
+	if err != nil {
+		return nil, err
 	}
+
+	return &requestPostDelBuilder{
+		version:             schema.GroupVersion{Version: "v1"},
+		resource:            "namespaces",
+		deleteRatio:         src.DeleteRatio,
+		maxRetries:          maxRetries,
+		rnd:                 rnd,
+		tmpl:                tmpl,
+		cache:               InitCacheWithCap(src.CacheCap),
+		namePrefix:          newPostDelNamePrefix(rnd),
+		tolerateTerminating: true,
+	}, nil
 }
 
-// Build implements RequestBuilder.Build.
-func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
-	comps := make([]string, 0, 5)
+// namespacePath returns the path components before resource.
+func (b *requestPostDelBuilder) namespacePath() []string {
+	comps := make([]string, 0, 6)
 	if b.version.Group == "" {
 		comps = append(comps, "api", b.version.Version)
 	} else {
@@ -461,16 +1260,17 @@ func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
 	if b.namespace != "" {
 		comps = append(comps, "namespaces", b.namespace)
 	}
+	return comps
+}
 
+// Build implements RequestBuilder.Build.
+func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
 	// Random pick operation DELETE or CREATE based on deleteRatio weight probability
-	randomInt, _ := rand.Int(rand.Reader, big.NewInt(1000))
-	shouldDelete := float64(randomInt.Int64())/1000.0 < b.deleteRatio
+	shouldDelete := float64(b.rnd.Int63n(1000))/1000.0 < b.deleteRatio
 
 	if shouldDelete {
 		// Try to get a name from cache
 		if name, ok := b.cache.Pop(); ok {
-			comps = append(comps, b.resource, name)
-
 			return &PostDelDiscardRequester{
 				builder:   b,
 				name:      name,
@@ -478,8 +1278,7 @@ func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
 				DiscardRequester: DiscardRequester{
 					BaseRequester: BaseRequester{
 						method: "DELETE",
-						req: cli.Delete().AbsPath(comps...).
-							MaxRetries(b.maxRetries),
+						req:    b.deleteRequest(cli, name),
 					},
 				},
 			}
@@ -488,14 +1287,13 @@ func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
 	}
 
 	// POST logic - create resource and add to cache if successful
-	comps = append(comps, b.resource)
+	comps := append(b.namespacePath(), b.resource)
 
 	// Use builder's atomic counter for synchronized unique ID generation
 	counter := atomic.AddInt64(&b.resourceCounter, 1)
-	timestamp := time.Now().UnixNano()
-	name := fmt.Sprintf("%d-%d", timestamp, counter)
+	name := fmt.Sprintf("%s%d", b.namePrefix, counter)
 
-	body, _ := utils.RenderTemplate(b.resource, map[string]interface{}{
+	body, _ := utils.ExecuteTemplate(b.tmpl, map[string]interface{}{
 		"namePattern": name,
 		"namespace":   b.namespace,
 	})
@@ -506,13 +1304,102 @@ func (b *requestPostDelBuilder) Build(cli rest.Interface) Requester {
 		operation: "POST",
 		DiscardRequester: DiscardRequester{
 			BaseRequester: BaseRequester{
-				method: "POST",
-				req:    cli.Post().AbsPath(comps...).Body(body).MaxRetries(b.maxRetries),
+				method:    "POST",
+				req:       cli.Post().AbsPath(comps...).Body(body).MaxRetries(b.maxRetries),
+				sentBytes: int64(len(body)),
 			},
 		},
 	}
 }
 
+// prepareTemplate returns the template for resource, which is content if
+// it's not empty, and verifies that it can be rendered before running. The
+// template is kept by each builder so that requests for the same resource
+// don't share it.
+func prepareTemplate(resource, namespace, content string) (*template.Template, error) {
+	tmpl, err := utils.LoadTemplate(resource, content)
+	if err != nil {
+		return nil, err
+	}
+
+	err = utils.ValidateTemplate(tmpl, map[string]interface{}{
+		"namePattern": "kperf-template-validation",
+		"namespace":   namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid template for %s: %w", resource, err)
+	}
+	return tmpl, nil
+}
+
+// postNamePrefix is the name prefix of all the objects created by
+// create-only post requests.
+const postNamePrefix = "kperf-post-"
+
+type requestPostBuilder struct {
+	version    schema.GroupVersion
+	resource   string
+	namespace  string
+	maxRetries int
+
+	// tmpl is the object template for POST.
+	tmpl *template.Template
+
+	// Per-builder atomic counter for unique ID generation
+	resourceCounter int64
+
+	// namePrefix is unique for each builder so that names don't conflict
+	// with the objects created by previous runs.
+	namePrefix string
+}
+
+func newRequestPostBuilder(src *types.RequestPost, maxRetries int, rnd randSource) (*requestPostBuilder, error) {
+	tmpl, err := prepareTemplate(src.Resource, src.Namespace, src.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &requestPostBuilder{
+		version:    schema.GroupVersion{Group: src.Group, Version: src.Version},
+		resource:   src.Resource,
+		namespace:  src.Namespace,
+		maxRetries: maxRetries,
+		tmpl:       tmpl,
+		namePrefix: fmt.Sprintf("%s%08x-", postNamePrefix, rnd.Int63n(1<<32)),
+	}, nil
+}
+
+// Build implements RequestBuilder.Build.
+func (b *requestPostBuilder) Build(cli rest.Interface) Requester {
+	// https://kubernetes.io/docs/reference/using-api/#api-groups
+	comps := make([]string, 0, 6)
+	if b.version.Group == "" {
+		comps = append(comps, "api", b.version.Version)
+	} else {
+		comps = append(comps, "apis", b.version.Group, b.version.Version)
+	}
+	if b.namespace != "" {
+		comps = append(comps, "namespaces", b.namespace)
+	}
+	comps = append(comps, b.resource)
+
+	counter := atomic.AddInt64(&b.resourceCounter, 1)
+	name := fmt.Sprintf("%s%d", b.namePrefix, counter)
+
+	body, _ := utils.ExecuteTemplate(b.tmpl, map[string]interface{}{
+		"namePattern": name,
+		"namespace":   b.namespace,
+	})
+
+	return &DiscardRequester{
+		BaseRequester: BaseRequester{
+			method:    "POST",
+			req:       cli.Post().AbsPath(comps...).Body(body).MaxRetries(b.maxRetries),
+			sentBytes: int64(len(body)),
+		},
+	}
+}
+
 // PostDelDiscardRequester handles both POST and DELETE requests with cache management
 type PostDelDiscardRequester struct {
 	builder   *requestPostDelBuilder
@@ -532,6 +1419,12 @@ func (reqr *PostDelDiscardRequester) Do(ctx context.Context) (bytes int64, err e
 			reqr.builder.cache.Push(reqr.name)
 		}
 	case "DELETE":
+		// NOTE: Deleting terminating namespace returns conflict, and
+		// the retried DELETE might find it gone.
+		if err != nil && reqr.builder.tolerateTerminating &&
+			(apierrors.IsNotFound(err) || apierrors.IsConflict(err)) {
+			err = nil
+		}
 		// If DELETE request failed, restore the item back to cache
 		// since the resource still exists in Kubernetes
 		if err != nil {
@@ -0,0 +1,666 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/contrib/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+func TestNewRequestPostDelBuilderTemplate(t *testing.T) {
+	newBuilder := func(namespace, template string) (*requestPostDelBuilder, error) {
+		return newRequestPostDelBuilder(&types.RequestPostDel{
+			KubeGroupVersionResource: types.KubeGroupVersionResource{
+				Version:  "v1",
+				Resource: "configmaps",
+			},
+			Namespace: namespace,
+			Template:  template,
+		}, "", 0, cryptoRandSource{})
+	}
+
+	// built-in template
+	_, err := newRequestPostDelBuilder(&types.RequestPostDel{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		Namespace: "default",
+	}, "", 0, cryptoRandSource{})
+	require.NoError(t, err)
+
+	// no template
+	_, err = newBuilder("default", "")
+	assert.Error(t, err)
+
+	// invalid template
+	_, err = newBuilder("default", "metadata:\n  name: {{ .Values.namePattern }}\n")
+	assert.Error(t, err)
+
+	// NOTE: The templates for the same resource don't override each other.
+	tmpl := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Values.namePattern }}
+  namespace: {{ .Values.namespace }}
+data:
+  key: %s
+`
+	b1, err := newBuilder("ns1", fmt.Sprintf(tmpl, "v1"))
+	require.NoError(t, err)
+	b2, err := newBuilder("ns2", fmt.Sprintf(tmpl, "v2"))
+	require.NoError(t, err)
+
+	for value, b := range map[string]*requestPostDelBuilder{"v1": b1, "v2": b2} {
+		data, err := utils.ExecuteTemplate(b.tmpl, map[string]interface{}{
+			"namePattern": "cm-1",
+			"namespace":   b.namespace,
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, fmt.Sprintf(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm-1","namespace":%q},"data":{"key":%q}}`, b.namespace, value), string(data))
+	}
+
+	// NOTE: The template of request isn't visible to the others.
+	_, err = utils.RenderTemplate("configmaps", nil)
+	assert.Error(t, err)
+}
+
+func TestWeightedRandomRequestsPhasedMix(t *testing.T) {
+	spec := &types.LoadProfileSpec{
+		Total:     100,
+		Conns:     1,
+		Client:    1,
+		MixPhases: 2,
+		Requests: []*types.WeightedRequest{
+			{
+				Shares:    100,
+				EndShares: toPtr(0),
+				StaleGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Name: "test",
+				},
+			},
+			{
+				Shares:    0,
+				EndShares: toPtr(100),
+				QuorumGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Name: "test",
+				},
+			},
+		},
+		ContentType: types.ContentTypeJSON,
+	}
+
+	reqs, err := NewWeightedRandomRequestsWithSeed(spec, 1)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{100000, 0}, reqs.currentShares(0))
+	assert.Equal(t, []int{50000, 50000}, reqs.currentShares(0.5))
+	assert.Equal(t, []int{0, 100000}, reqs.currentShares(1))
+
+	go func() {
+		for range reqs.Chan() {
+		}
+	}()
+	reqs.Run(context.Background(), spec.Total)
+	reqs.Stop()
+
+	mix := reqs.PhaseMix()
+	require.Len(t, mix, 2)
+	for _, m := range mix {
+		assert.Equal(t, 50, m["0:staleGet/pods"]+m["1:quorumGet/pods"])
+	}
+	assert.Greater(t, mix[0]["0:staleGet/pods"], mix[0]["1:quorumGet/pods"])
+	assert.Less(t, mix[1]["0:staleGet/pods"], mix[1]["1:quorumGet/pods"])
+}
+
+func TestRequestPatchBuilderRotatesBodies(t *testing.T) {
+	b, err := newRequestPatchBuilder(&types.RequestPatch{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Name:         "cm",
+		KeySpaceSize: 10,
+		PatchType:    "merge",
+		Body:         ` {"data":{"a":"1"}}` + "\n",
+		Bodies:       []string{`{"data":{"b":"2"}}`},
+	}, "", 0, cryptoRandSource{})
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"data":{"a":"1"}}`, string(b.nextBody()))
+	assert.Equal(t, `{"data":{"b":"2"}}`, string(b.nextBody()))
+	assert.Equal(t, `{"data":{"a":"1"}}`, string(b.nextBody()))
+
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+	assert.Equal(t, int64(len(`{"data":{"b":"2"}}`)), b.Build(cli).SentBytes())
+}
+
+func TestRequestPatchBuilderApply(t *testing.T) {
+	src := &types.RequestPatch{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Namespace:    "default",
+		Name:         "cm",
+		KeySpaceSize: 1,
+		PatchType:    "apply",
+		FieldManager: "kperf",
+		Force:        true,
+		Body:         "apiVersion: v1\nkind: ConfigMap\ndata:\n  a: \"1\"\n",
+	}
+	require.NoError(t, src.Validate())
+	assert.Equal(t, "apiVersion: v1\nkind: ConfigMap\ndata:\n  a: \"1\"\n", src.Body)
+	assert.Equal(t, "APPLY", requestVerb(&types.WeightedRequest{Patch: src}))
+
+	reqs := make(chan *http.Request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqs <- r
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	b, err := newRequestPatchBuilder(src, "", 0, cryptoRandSource{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"apiVersion":"v1","kind":"ConfigMap","data":{"a":"1"}}`, string(b.nextBody()))
+
+	req := b.Build(newScheduleTestClient(t, srv.URL))
+	assert.Equal(t, "APPLY", req.Method())
+	_, err = req.Do(context.Background())
+	require.NoError(t, err)
+
+	r := <-reqs
+	assert.Equal(t, http.MethodPatch, r.Method)
+	assert.Equal(t, "/api/v1/namespaces/default/configmaps/cm-0", r.URL.Path)
+	assert.Equal(t, "kperf", r.URL.Query().Get("fieldManager"))
+	assert.Equal(t, "true", r.URL.Query().Get("force"))
+	assert.Equal(t, "application/apply-patch+yaml", r.Header.Get("Content-Type"))
+}
+
+func TestWeightedRandomRequestsMinRequestsPerVerb(t *testing.T) {
+	spec := &types.LoadProfileSpec{
+		Rate:               0,
+		Total:              10,
+		Conns:              1,
+		Client:             1,
+		MinRequestsPerVerb: 3,
+		Requests: []*types.WeightedRequest{
+			{
+				Shares: 1000,
+				StaleGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Name: "test",
+				},
+			},
+			{
+				Shares: 1,
+				StaleList: &types.RequestList{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+				},
+			},
+			{
+				Shares: 0,
+				QuorumList: &types.RequestList{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+				},
+			},
+		},
+		ContentType: types.ContentTypeJSON,
+	}
+
+	reqs, err := NewWeightedRandomRequests(spec)
+	require.NoError(t, err)
+
+	picks := reqs.guaranteedPicks()
+	assert.Equal(t, []int{0, 1, 0, 1, 0, 1}, picks)
+
+	counts := map[RESTRequestBuilder]int{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for b := range reqs.Chan() {
+			counts[b]++
+		}
+	}()
+	reqs.Run(context.Background(), spec.Total)
+	reqs.Stop()
+	<-done
+
+	assert.GreaterOrEqual(t, counts[reqs.reqBuilders[1]], 3)
+	assert.Equal(t, 0, counts[reqs.reqBuilders[2]])
+}
+
+func TestRequestPutBuilderBody(t *testing.T) {
+	builder, err := newRequestPutBuilder(&types.RequestPut{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Group:    "apps",
+			Version:  "v1",
+			Resource: "deployments",
+		},
+		Namespace:    "default",
+		Name:         "deploy",
+		KeySpaceSize: 10,
+		Body:         "\n" + `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"x","labels":{"app":"x"}},"spec":{"replicas":1}}` + "\n",
+	}, 0, cryptoRandSource{})
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"deploy-1","namespace":"default","labels":{"app":"x"}},"spec":{"replicas":1}}`,
+		string(builder.body("deploy-1")))
+	// template should not be changed
+	assert.Equal(t, "x", builder.object.GetName())
+
+	builder, err = newRequestPutBuilder(&types.RequestPut{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Namespace:    "kperf",
+		Name:         "cm",
+		KeySpaceSize: 10,
+		ValueSize:    64,
+	}, 0, cryptoRandSource{})
+	require.NoError(t, err)
+
+	first, second := map[string]interface{}{}, map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(builder.body("cm-1"), &first))
+	require.NoError(t, json.Unmarshal(builder.body("cm-1"), &second))
+	assert.Equal(t, "ConfigMap", first["kind"])
+	assert.Equal(t, map[string]interface{}{"name": "cm-1", "namespace": "kperf"}, first["metadata"])
+
+	blob, err := base64.StdEncoding.DecodeString(first["binaryData"].(map[string]interface{})["data"].(string))
+	require.NoError(t, err)
+	assert.Len(t, blob, 64)
+	assert.NotEqual(t, first["binaryData"], second["binaryData"])
+}
+
+func TestNewWeightedRandomRequestsWithSeed(t *testing.T) {
+	spec := &types.LoadProfileSpec{
+		Rate:   1,
+		Total:  100,
+		Conns:  1,
+		Client: 1,
+		Requests: []*types.WeightedRequest{
+			{
+				Shares: 100,
+				StaleGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+					Namespace: "default",
+					Name:      "x",
+				},
+			},
+			{
+				Shares: 100,
+				Patch: &types.RequestPatch{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace:    "default",
+					Name:         "cm",
+					KeySpaceSize: 1000,
+					PatchType:    "merge",
+					Body:         `{"data":{"a":"1"}}`,
+				},
+			},
+		},
+		ContentType: types.ContentTypeJSON,
+	}
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+
+	sequence := func(seed int64) []string {
+		reqs, err := NewWeightedRandomRequestsWithSeed(spec, seed)
+		require.NoError(t, err)
+
+		res := make([]string, 0, 50)
+		for i := 0; i < 50; i++ {
+			builder := reqs.reqBuilders[reqs.randomPick(0)]
+			res = append(res, builder.Build(cli).URL().String())
+		}
+		return res
+	}
+
+	assert.Equal(t, sequence(1), sequence(1))
+	assert.NotEqual(t, sequence(1), sequence(2))
+}
+
+func TestRequestPostBuilder(t *testing.T) {
+	var mu sync.Mutex
+	names := []string{}
+	methods := []string{}
+	lengths := []int64{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		obj := map[string]interface{}{}
+		require.NoError(t, yaml.NewYAMLOrJSONDecoder(r.Body, 4096).Decode(&obj))
+
+		mu.Lock()
+		defer mu.Unlock()
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		lengths = append(lengths, r.ContentLength)
+		names = append(names, obj["metadata"].(map[string]interface{})["name"].(string))
+	}))
+	defer srv.Close()
+
+	src := &types.RequestPost{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		Namespace: "default",
+	}
+	b, err := newRequestPostBuilder(src, 0, cryptoRandSource{})
+	require.NoError(t, err)
+	cli := newScheduleTestClient(t, srv.URL)
+	sentBytes := []int64{}
+	for i := 0; i < 3; i++ {
+		req := b.Build(cli)
+		_, err := req.Do(context.Background())
+		require.NoError(t, err)
+		sentBytes = append(sentBytes, req.SentBytes())
+	}
+	assert.Equal(t, lengths, sentBytes)
+
+	assert.Equal(t, []string{
+		"POST /api/v1/namespaces/default/pods",
+		"POST /api/v1/namespaces/default/pods",
+		"POST /api/v1/namespaces/default/pods",
+	}, methods)
+	assert.Equal(t, []string{
+		b.namePrefix + "1",
+		b.namePrefix + "2",
+		b.namePrefix + "3",
+	}, names)
+	assert.True(t, strings.HasPrefix(b.namePrefix, postNamePrefix))
+}
+
+func TestRequestCreateNamespaceBuilder(t *testing.T) {
+	var mu sync.Mutex
+	methods := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodDelete {
+			// NOTE: The namespace is terminating.
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Conflict","code":409}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	b, err := newRequestCreateNamespaceBuilder(&types.RequestCreateNamespace{}, 0, cryptoRandSource{})
+	require.NoError(t, err)
+	cli := newScheduleTestClient(t, srv.URL)
+
+	req := b.Build(cli)
+	assert.Equal(t, "POST", req.Method())
+	_, err = req.Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{b.namePrefix + "1"}, b.cache.Items())
+
+	b.deleteRatio = 1
+	req = b.Build(cli)
+	assert.Equal(t, "DELETE", req.Method())
+	_, err = req.Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, b.cache.Len())
+
+	assert.Equal(t, []string{
+		"POST /api/v1/namespaces",
+		"DELETE /api/v1/namespaces/" + b.namePrefix + "1",
+	}, methods)
+}
+
+func TestRequestDeleteCollectionBuilder(t *testing.T) {
+	b := newRequestDeleteCollectionBuilder(&types.RequestDeleteCollection{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Group:    "apps",
+			Version:  "v1",
+			Resource: "deployments",
+		},
+		Namespace:         "kperf",
+		Selector:          "app=kperf",
+		FieldSelector:     "metadata.name=x",
+		PropagationPolicy: "Background",
+	}, 0)
+
+	req := b.Build(newScheduleTestClient(t, "http://127.0.0.1:0"))
+	assert.Equal(t, "DELETECOLLECTION", req.Method())
+
+	u := req.URL()
+	assert.Equal(t, "/apis/apps/v1/namespaces/kperf/deployments", u.Path)
+	assert.Equal(t, "app=kperf", u.Query().Get("labelSelector"))
+	assert.Equal(t, "metadata.name=x", u.Query().Get("fieldSelector"))
+	assert.Equal(t, "Background", u.Query().Get("propagationPolicy"))
+}
+
+func TestGetFromPostDelCache(t *testing.T) {
+	gvr := types.KubeGroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}
+	spec := &types.LoadProfileSpec{
+		Rate:   1,
+		Total:  10,
+		Conns:  1,
+		Client: 1,
+		Requests: []*types.WeightedRequest{
+			{
+				Shares: 1,
+				PostDel: &types.RequestPostDel{
+					KubeGroupVersionResource: gvr,
+					Namespace:                "default",
+					CacheName:                "pods",
+				},
+			},
+			{
+				Shares: 1,
+				StaleGet: &types.RequestGet{
+					KubeGroupVersionResource: gvr,
+					Namespace:                "default",
+					Name:                     "static",
+					FromCache:                "pods",
+				},
+			},
+		},
+		ContentType: types.ContentTypeJSON,
+	}
+
+	reqs, err := NewWeightedRandomRequests(spec)
+	require.NoError(t, err)
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+
+	// fall back to static name if there is no object yet
+	req := reqs.reqBuilders[1].Build(cli)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/static", req.URL().Path)
+
+	reqs.reqBuilders[0].(*requestPostDelBuilder).cache.Push("created")
+	req = reqs.reqBuilders[1].Build(cli)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/created", req.URL().Path)
+}
+
+func TestRequestGetBuilderSubresource(t *testing.T) {
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+	for subresource, expected := range map[string]string{
+		"":       "/apis/apps/v1/namespaces/kperf/deployments/x",
+		"status": "/apis/apps/v1/namespaces/kperf/deployments/x/status",
+		"scale":  "/apis/apps/v1/namespaces/kperf/deployments/x/scale",
+	} {
+		b := newRequestGetBuilder(&types.RequestGet{
+			KubeGroupVersionResource: types.KubeGroupVersionResource{
+				Group:    "apps",
+				Version:  "v1",
+				Resource: "deployments",
+			},
+			Namespace:   "kperf",
+			Name:        "x",
+			Subresource: subresource,
+		}, "", 0, false)
+
+		req := b.Build(cli)
+		assert.Equal(t, "GET", req.Method())
+		assert.Equal(t, expected, req.URL().Path)
+	}
+}
+
+func TestRequestListBuilderAsTable(t *testing.T) {
+	accepts := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts <- r.Header.Get("Accept")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+	for _, asTable := range []bool{false, true} {
+		b, err := newRequestListBuilder(&types.RequestList{
+			KubeGroupVersionResource: types.KubeGroupVersionResource{
+				Version:  "v1",
+				Resource: "pods",
+			},
+			AsTable: asTable,
+		}, "0", 0, false, cryptoRandSource{})
+		require.NoError(t, err)
+
+		_, err = b.Build(cli).Do(context.Background())
+		require.NoError(t, err)
+	}
+
+	assert.NotContains(t, <-accepts, "as=Table")
+	assert.Equal(t, tableAcceptHeader, <-accepts)
+}
+
+func TestRequestListBuilderNodeNameFromNodepool(t *testing.T) {
+	selectors := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selectors <- r.URL.Query().Get("labelSelector")
+		_, _ = w.Write([]byte(`{"items":[{"metadata":{"name":"node-0"}},{"metadata":{"name":"node-1"}}]}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	b, err := newRequestListBuilder(&types.RequestList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		NodeNameFromNodepool: "pool",
+	}, "0", 0, false, cryptoRandSource{})
+	require.NoError(t, err)
+
+	r := &WeightedRandomRequests{reqBuilders: []RESTRequestBuilder{b}}
+	require.NoError(t, r.resolveNodeNames(context.Background(), cli))
+	assert.Equal(t, nodepoolLabel+"=pool", <-selectors)
+
+	fieldSelectors := []string{}
+	for i := 0; i < 3; i++ {
+		fieldSelectors = append(fieldSelectors, b.Build(cli).URL().Query().Get("fieldSelector"))
+	}
+	assert.Equal(t, []string{
+		"spec.nodeName=node-0",
+		"spec.nodeName=node-1",
+		"spec.nodeName=node-0",
+	}, fieldSelectors)
+}
+
+func TestRequestListBuilderMetadataOnly(t *testing.T) {
+	// NOTE: The fake server drops data from objects like kube-apiserver
+	// if metadata-only list is asked.
+	items := []string{}
+	metadataItems := []string{}
+	for i := 0; i < 10; i++ {
+		meta := fmt.Sprintf(`"metadata":{"name":"cm-%d","namespace":"default"}`, i)
+		items = append(items, fmt.Sprintf(`{"kind":"ConfigMap",%s,"data":{"a":"%s"}}`, meta, strings.Repeat("x", 10*1024)))
+		metadataItems = append(metadataItems, fmt.Sprintf(`{"kind":"PartialObjectMetadata",%s}`, meta))
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Accept") == metadataListAcceptHeader {
+			fmt.Fprintf(w, `{"kind":"PartialObjectMetadataList","items":[%s]}`, strings.Join(metadataItems, ","))
+			return
+		}
+		fmt.Fprintf(w, `{"kind":"ConfigMapList","items":[%s]}`, strings.Join(items, ","))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+	receivedBytes := map[bool]int64{}
+	for _, metadataOnly := range []bool{false, true} {
+		b, err := newRequestListBuilder(&types.RequestList{
+			KubeGroupVersionResource: types.KubeGroupVersionResource{
+				Version:  "v1",
+				Resource: "configmaps",
+			},
+			Namespace:    "default",
+			MetadataOnly: metadataOnly,
+		}, "0", 0, false, cryptoRandSource{})
+		require.NoError(t, err)
+
+		n, err := b.Build(cli).Do(context.Background())
+		require.NoError(t, err)
+		receivedBytes[metadataOnly] = n
+	}
+	assert.Less(t, receivedBytes[true]*10, receivedBytes[false])
+}
+
+func TestRequestWatchListBuilderMetadataOnly(t *testing.T) {
+	accepts := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepts <- r.Header.Get("Accept")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `{"type":"BOOKMARK","object":{"apiVersion":"meta.k8s.io/v1","kind":"PartialObjectMetadata","metadata":{"resourceVersion":"1","annotations":{"k8s.io/initial-events-end":"true"}}}}`)
+	}))
+	defer srv.Close()
+
+	b := newRequestWatchListBuilder(&types.RequestWatchList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		MetadataOnly: true,
+	}, 0)
+
+	_, err := b.Build(newScheduleTestClient(t, srv.URL)).Do(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, metadataWatchAcceptHeader, <-accepts)
+}
@@ -2,30 +2,34 @@ package request
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net/url"
-	"reflect"
 	"time"
-	_ "unsafe" // unsafe to use internal function from client-go
 
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/cache"
-	"k8s.io/utils/clock"
 )
 
 type Requester interface {
 	Method() string
 	URL() *url.URL
 	Timeout(time.Duration)
+	// Backoff sets the backoff manager used between retries.
+	Backoff(rest.BackoffManager)
 	Do(context.Context) (bytes int64, err error)
+	// SentBytes returns the size of request body.
+	SentBytes() int64
 }
 
 type BaseRequester struct {
 	method string
 	req    *rest.Request
+	// sentBytes is the size of request body.
+	sentBytes int64
 }
 
 func (reqr *BaseRequester) Method() string {
@@ -40,6 +44,14 @@ func (reqr *BaseRequester) Timeout(timeout time.Duration) {
 	reqr.req.Timeout(timeout)
 }
 
+func (reqr *BaseRequester) Backoff(manager rest.BackoffManager) {
+	reqr.req.BackOff(manager)
+}
+
+func (reqr *BaseRequester) SentBytes() int64 {
+	return reqr.sentBytes
+}
+
 type DiscardRequester struct {
 	BaseRequester
 }
@@ -50,17 +62,72 @@ func (reqr *DiscardRequester) Do(ctx context.Context) (bytes int64, err error) {
 		return 0, err
 	}
 	defer respBody.Close()
+	roundTripRecorderFrom(ctx).markFirstByte()
 
 	return io.Copy(io.Discard, respBody)
 }
 
+// WatchStats is the summary of one watch stream.
+type WatchStats struct {
+	// EventsByType is the number of received events for each type, like
+	// ADDED or BOOKMARK.
+	EventsByType map[string]int64
+	// TimeToFirstEvent is the time from sending request to receiving the
+	// first event. It's zero if there is no event.
+	TimeToFirstEvent time.Duration
+	// Partial means that the server closed the stream before the initial
+	// events end.
+	Partial bool
+	// ReconnectLatencies is the time from stream close to the reconnected
+	// stream for each reconnect. It's empty if the watch isn't resumed.
+	ReconnectLatencies []time.Duration
+}
+
 type WatchListRequester struct {
 	BaseRequester
+
+	// resumeFn returns the request which resumes watch from the
+	// resourceVersion. It's nil if the watch ends with initial events.
+	resumeFn func(rv string) *rest.Request
+	timeout  time.Duration
+	backoff  rest.BackoffManager
+
+	stats WatchStats
+}
+
+// Timeout implements Requester.Timeout. It's also the lifetime of resumed
+// watch.
+func (reqr *WatchListRequester) Timeout(timeout time.Duration) {
+	reqr.timeout = timeout
+	reqr.BaseRequester.Timeout(timeout)
+}
+
+// Backoff implements Requester.Backoff.
+func (reqr *WatchListRequester) Backoff(manager rest.BackoffManager) {
+	reqr.backoff = manager
+	reqr.BaseRequester.Backoff(manager)
 }
 
+// Stats returns the summary of watch stream after Do.
+func (reqr *WatchListRequester) Stats() WatchStats {
+	return reqr.stats
+}
+
+// Do receives events until the bookmark which marks the end of initial
+// events. If the server closes the stream early, it returns the partial
+// count without error.
+//
+// If the watch is resumed, it keeps receiving events until timeout. When
+// the server closes the stream, it reconnects from the latest bookmark's
+// resourceVersion without initial events.
 func (reqr *WatchListRequester) Do(ctx context.Context) (zero int64, _ error) {
-	cl := clock.RealClock{}
-	temporaryStore := cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc)
+	reqr.stats = WatchStats{EventsByType: map[string]int64{}}
+
+	if reqr.resumeFn != nil && reqr.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, reqr.timeout)
+		defer cancel()
+	}
 
 	start := time.Now()
 
@@ -68,29 +135,77 @@ func (reqr *WatchListRequester) Do(ctx context.Context) (zero int64, _ error) {
 	if err != nil {
 		return zero, err
 	}
-	watchListBookmarkReceived, err := handleAnyWatch(start, w, temporaryStore, nil, nil, "", "", func(_ string) {}, true, cl, make(chan error), ctx.Done())
-	w.Stop()
-	if err != nil {
-		return zero, err
+	defer func() { w.Stop() }()
+
+	initialEventsEnd := false
+	bookmarkRV := ""
+	for {
+		select {
+		case <-ctx.Done():
+			if initialEventsEnd {
+				return zero, nil
+			}
+			return zero, ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				if !initialEventsEnd {
+					reqr.stats.Partial = true
+					return zero, nil
+				}
+
+				closedAt := time.Now()
+				w.Stop()
+				w, err = reqr.resumeRequest(bookmarkRV).Watch(ctx)
+				if err != nil {
+					w = watch.NewEmptyWatch()
+					if ctx.Err() != nil {
+						return zero, nil
+					}
+					return zero, err
+				}
+				reqr.stats.ReconnectLatencies = append(reqr.stats.ReconnectLatencies, time.Since(closedAt))
+				continue
+			}
+
+			if reqr.stats.TimeToFirstEvent == 0 {
+				reqr.stats.TimeToFirstEvent = time.Since(start)
+			}
+			reqr.stats.EventsByType[string(event.Type)]++
+
+			switch event.Type {
+			case watch.Error:
+				return zero, apierrors.FromObject(event.Object)
+			case watch.Bookmark:
+				if accessor, err := meta.Accessor(event.Object); err == nil {
+					bookmarkRV = accessor.GetResourceVersion()
+				}
+				if isInitialEventsEnd(event.Object) {
+					if reqr.resumeFn == nil {
+						return zero, nil
+					}
+					initialEventsEnd = true
+				}
+			}
+		}
 	}
+}
+
+// resumeRequest returns the request which resumes watch from rv with the
+// same backoff.
+func (reqr *WatchListRequester) resumeRequest(rv string) *rest.Request {
+	req := reqr.resumeFn(rv)
+	if reqr.backoff != nil {
+		req.BackOff(reqr.backoff)
+	}
+	return req
+}
 
-	if watchListBookmarkReceived {
-		return zero, nil
+// isInitialEventsEnd returns true if the bookmark marks the end of initial
+// events.
+func isInitialEventsEnd(obj runtime.Object) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
 	}
-	return zero, fmt.Errorf("don't receive bookmark")
-}
-
-//go:linkname handleAnyWatch k8s.io/client-go/tools/cache.handleAnyWatch
-func handleAnyWatch(start time.Time,
-	w watch.Interface,
-	store cache.Store,
-	expectedType reflect.Type,
-	expectedGVK *schema.GroupVersionKind,
-	name string,
-	expectedTypeName string,
-	setLastSyncResourceVersion func(string),
-	exitOnWatchListBookmarkReceived bool,
-	clock clock.Clock,
-	errCh chan error,
-	stopCh <-chan struct{},
-) (bool, error)
+	return accessor.GetAnnotations()[metav1.InitialEventsAnnotationKey] == "true"
+}
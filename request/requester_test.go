@@ -0,0 +1,132 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchListRequesterStats(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		events       []string
+		eventsByType map[string]int64
+		partial      bool
+	}{
+		{
+			name: "initial events end",
+			events: []string{
+				`{"type":"ADDED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"1"}}}`,
+				`{"type":"ADDED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b","resourceVersion":"2"}}}`,
+				`{"type":"BOOKMARK","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"resourceVersion":"2","annotations":{"k8s.io/initial-events-end":"true"}}}}`,
+				`{"type":"MODIFIED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"3"}}}`,
+			},
+			eventsByType: map[string]int64{"ADDED": 2, "BOOKMARK": 1},
+		},
+		{
+			name: "closed early",
+			events: []string{
+				`{"type":"ADDED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"1"}}}`,
+				`{"type":"DELETED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"2"}}}`,
+			},
+			eventsByType: map[string]int64{"ADDED": 1, "DELETED": 1},
+			partial:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				for _, e := range tc.events {
+					fmt.Fprintln(w, e)
+				}
+			}))
+			defer srv.Close()
+
+			builder := newRequestWatchListBuilder(&types.RequestWatchList{
+				KubeGroupVersionResource: types.KubeGroupVersionResource{
+					Version:  "v1",
+					Resource: "configmaps",
+				},
+			}, 0)
+			req := builder.Build(newScheduleTestClient(t, srv.URL)).(*WatchListRequester)
+
+			_, err := req.Do(context.Background())
+			require.NoError(t, err)
+
+			stats := req.Stats()
+			assert.Equal(t, tc.eventsByType, stats.EventsByType)
+			assert.Equal(t, tc.partial, stats.Partial)
+			assert.Greater(t, stats.TimeToFirstEvent.Seconds(), 0.0)
+		})
+	}
+}
+
+func TestWatchListRequesterResume(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []url.Values
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.URL.Query())
+		n := len(calls)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch n {
+		case 1:
+			fmt.Fprintln(w, `{"type":"ADDED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"1"}}}`)
+			fmt.Fprintln(w, `{"type":"BOOKMARK","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"resourceVersion":"1","annotations":{"k8s.io/initial-events-end":"true"}}}}`)
+		case 2:
+			fmt.Fprintln(w, `{"type":"MODIFIED","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"2"}}}`)
+			fmt.Fprintln(w, `{"type":"BOOKMARK","object":{"apiVersion":"v1","kind":"ConfigMap","metadata":{"resourceVersion":"3"}}}`)
+		default:
+			// Hold the stream until the watch times out.
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	builder := newRequestWatchListBuilder(&types.RequestWatchList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Resume: true,
+	}, 0)
+	req := builder.Build(newScheduleTestClient(t, srv.URL)).(*WatchListRequester)
+	req.Timeout(time.Second)
+
+	_, err := req.Do(context.Background())
+	require.NoError(t, err)
+
+	stats := req.Stats()
+	assert.Equal(t, map[string]int64{"ADDED": 1, "MODIFIED": 1, "BOOKMARK": 2}, stats.EventsByType)
+	assert.False(t, stats.Partial)
+	assert.Len(t, stats.ReconnectLatencies, 2)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "true", calls[0].Get("sendInitialEvents"))
+	assert.Equal(t, "", calls[0].Get("resourceVersion"))
+	assert.Equal(t, "false", calls[1].Get("sendInitialEvents"))
+	assert.Equal(t, "1", calls[1].Get("resourceVersion"))
+	assert.Equal(t, "false", calls[2].Get("sendInitialEvents"))
+	assert.Equal(t, "3", calls[2].Get("resourceVersion"))
+}
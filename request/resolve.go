@@ -0,0 +1,97 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"fmt"
+
+	"github.com/Azure/kperf/api/types"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// ResolveKinds resolves requests which are specified by kind into group,
+// version and resource by kube-apiserver's discovery API, and validates
+// spec again. It's no-op if there is no such request. Only TLS setting in
+// opts is used.
+func ResolveKinds(kubeCfgPath string, spec *types.LoadProfileSpec, opts ...ClientCfgOpt) error {
+	if !requiresKindResolution(spec) {
+		return nil
+	}
+
+	var cfg = defaultClientCfg
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.applyTLS(restCfg); err != nil {
+		return err
+	}
+
+	discoveryCli, err := discovery.NewDiscoveryClientForConfig(restCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %w", err)
+	}
+
+	groupResources, err := restmapper.GetAPIGroupResources(discoveryCli)
+	if err != nil {
+		return fmt.Errorf("failed to discover api group resources: %w", err)
+	}
+	if err := resolveKinds(restmapper.NewDiscoveryRESTMapper(groupResources), spec); err != nil {
+		return err
+	}
+
+	// NOTE: The checks depending on resource and group are skipped for
+	// the requests specified by kind until they're resolved.
+	if err := spec.Validate(); err != nil {
+		return fmt.Errorf("invalid load profile after resolving kinds: %w", err)
+	}
+	return nil
+}
+
+// requiresKindResolution returns true if there is any request specified
+// by kind without resource.
+func requiresKindResolution(spec *types.LoadProfileSpec) bool {
+	for _, r := range spec.Requests {
+		if gvr := r.GroupVersionResource(); gvr != nil && gvr.Resource == "" && gvr.Kind != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveKinds fills group, version and resource for requests specified
+// by kind with the given RESTMapper.
+func resolveKinds(mapper meta.RESTMapper, spec *types.LoadProfileSpec) error {
+	for idx, r := range spec.Requests {
+		gvr := r.GroupVersionResource()
+		if gvr == nil || gvr.Resource != "" || gvr.Kind == "" {
+			continue
+		}
+
+		versions := []string{}
+		if gvr.Version != "" {
+			versions = append(versions, gvr.Version)
+		}
+
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gvr.Group, Kind: gvr.Kind}, versions...)
+		if err != nil {
+			return fmt.Errorf("idx: %v request: failed to resolve kind %s: %w", idx, gvr.Kind, err)
+		}
+
+		gvr.Group = mapping.Resource.Group
+		gvr.Version = mapping.Resource.Version
+		gvr.Resource = mapping.Resource.Resource
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResolveKinds(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{
+		{Group: "apps", Version: "v1"},
+		{Version: "v1"},
+	})
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+
+	spec := &types.LoadProfileSpec{
+		Requests: []*types.WeightedRequest{
+			{
+				Shares: 100,
+				StaleList: &types.RequestList{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Group: "apps",
+						Kind:  "Deployment",
+					},
+				},
+			},
+			{
+				Shares: 100,
+				QuorumGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Kind: "ConfigMap",
+					},
+					Name: "test",
+				},
+			},
+			{
+				Shares: 100,
+				QuorumList: &types.RequestList{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "pods",
+					},
+				},
+			},
+		},
+	}
+	assert.True(t, requiresKindResolution(spec))
+
+	require.NoError(t, resolveKinds(mapper, spec))
+	assert.False(t, requiresKindResolution(spec))
+	assert.Equal(t, types.KubeGroupVersionResource{
+		Group:    "apps",
+		Version:  "v1",
+		Resource: "deployments",
+		Kind:     "Deployment",
+	}, spec.Requests[0].StaleList.KubeGroupVersionResource)
+	assert.Equal(t, types.KubeGroupVersionResource{
+		Version:  "v1",
+		Resource: "configmaps",
+		Kind:     "ConfigMap",
+	}, spec.Requests[1].QuorumGet.KubeGroupVersionResource)
+	assert.Equal(t, types.KubeGroupVersionResource{
+		Version:  "v1",
+		Resource: "pods",
+	}, spec.Requests[2].QuorumList.KubeGroupVersionResource)
+
+	spec.Requests[0].StaleList.Resource = ""
+	spec.Requests[0].StaleList.Kind = "Unknown"
+	assert.Error(t, resolveKinds(mapper, spec))
+}
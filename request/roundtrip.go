@@ -0,0 +1,125 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// roundTripRecorder accumulates stats of all the round trips made by one
+// logical request, including retries.
+type roundTripRecorder struct {
+	// attempts is the number of HTTP round trips.
+	attempts int64
+	// injectedDelay is the artificial network delay in nanoseconds.
+	injectedDelay int64
+	// wireBytes is the bytes of response body read from the wire, which
+	// is only recorded if the client asks for gzip-compressed response.
+	wireBytes int64
+	// firstByteAt is the unix time in nanoseconds when the response
+	// headers are received. It's zero if there is no response yet.
+	firstByteAt int64
+	// instance identifies kube-apiserver instance which served the last
+	// round trip. It stores string.
+	instance atomic.Value
+}
+
+type roundTripRecorderKey struct{}
+
+// withRoundTripRecorder returns a context which carries recorder.
+func withRoundTripRecorder(ctx context.Context, recorder *roundTripRecorder) context.Context {
+	return context.WithValue(ctx, roundTripRecorderKey{}, recorder)
+}
+
+// roundTripRecorderFrom returns recorder stored in ctx. It returns nil if
+// there is no recorder, which is safe to use.
+func roundTripRecorderFrom(ctx context.Context) *roundTripRecorder {
+	recorder, _ := ctx.Value(roundTripRecorderKey{}).(*roundTripRecorder)
+	return recorder
+}
+
+func (r *roundTripRecorder) addAttempt() {
+	if r != nil {
+		atomic.AddInt64(&r.attempts, 1)
+	}
+}
+
+func (r *roundTripRecorder) addInjectedDelay(delay time.Duration) {
+	if r != nil {
+		atomic.AddInt64(&r.injectedDelay, int64(delay))
+	}
+}
+
+func (r *roundTripRecorder) addWireBytes(n int64) {
+	if r != nil {
+		atomic.AddInt64(&r.wireBytes, n)
+	}
+}
+
+// markFirstByte records now as the time when the response headers are
+// received.
+func (r *roundTripRecorder) markFirstByte() {
+	if r != nil {
+		atomic.StoreInt64(&r.firstByteAt, time.Now().UnixNano())
+	}
+}
+
+// FirstByteAt returns the time when the response headers are received. It's
+// zero if there is no response.
+func (r *roundTripRecorder) FirstByteAt() time.Time {
+	if at := atomic.LoadInt64(&r.firstByteAt); at != 0 {
+		return time.Unix(0, at)
+	}
+	return time.Time{}
+}
+
+// setInstance records the kube-apiserver instance which served the round
+// trip. The empty value is ignored.
+func (r *roundTripRecorder) setInstance(instance string) {
+	if r != nil && instance != "" {
+		r.instance.Store(instance)
+	}
+}
+
+// Instance returns the kube-apiserver instance which served the last round
+// trip. It's empty if it's unknown.
+func (r *roundTripRecorder) Instance() string {
+	instance, _ := r.instance.Load().(string)
+	return instance
+}
+
+// WireBytes returns the bytes of response body read from the wire.
+func (r *roundTripRecorder) WireBytes() int64 {
+	return atomic.LoadInt64(&r.wireBytes)
+}
+
+// Attempts returns the number of HTTP round trips.
+func (r *roundTripRecorder) Attempts() int64 {
+	return atomic.LoadInt64(&r.attempts)
+}
+
+// InjectedDelay returns the total artificial network delay.
+func (r *roundTripRecorder) InjectedDelay() time.Duration {
+	return time.Duration(atomic.LoadInt64(&r.injectedDelay))
+}
+
+// attemptsRoundTripper counts HTTP round trips, including the retries made
+// by client-go, into recorder stored in request's context.
+type attemptsRoundTripper struct {
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (a *attemptsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	roundTripRecorderFrom(req.Context()).addAttempt()
+	return a.rt.RoundTrip(req)
+}
+
+// WrappedRoundTripper returns underlying RoundTripper.
+func (a *attemptsRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return a.rt
+}
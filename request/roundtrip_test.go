@@ -0,0 +1,57 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestAttemptsRoundTripperCountsRetries(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	restCfg := &rest.Config{
+		Host:    srv.URL,
+		Proxy:   http.ProxyFromEnvironment,
+		QPS:     1000,
+		Burst:   1000,
+		APIPath: "/api",
+	}
+	restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+	restCfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &attemptsRoundTripper{rt: rt}
+	})
+
+	cli, err := rest.UnversionedRESTClientFor(restCfg)
+	require.NoError(t, err)
+
+	var recorder roundTripRecorder
+	ctx := withRoundTripRecorder(context.Background(), &recorder)
+
+	err = cli.Get().AbsPath("/api/v1/configmaps").MaxRetries(3).Do(ctx).Error()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), recorder.Attempts())
+
+	// NOTE: The request without recorder should work.
+	err = cli.Get().AbsPath("/api/v1/configmaps").Do(context.Background()).Error()
+	require.NoError(t, err)
+}
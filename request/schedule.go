@@ -6,13 +6,18 @@ package request
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Azure/kperf/api/types"
 	"github.com/Azure/kperf/metrics"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"golang.org/x/net/http2"
 	"golang.org/x/time/rate"
 	"k8s.io/client-go/rest"
@@ -26,20 +31,141 @@ type Result struct {
 	types.ResponseStats
 	// Duration means the time of benchmark.
 	Duration time.Duration
-	// Total means the total number of requests.
+	// WarmupDuration is the time of warmup before Duration. The requests
+	// sent during warmup aren't recorded.
+	WarmupDuration time.Duration
+	// Total means the total number of completed requests, including
+	// failures. It might be less than the expected one if the run is
+	// canceled.
 	Total int
+	// MixByPhase is the effective request mix in each phase. It's nil if
+	// there is no phased mix.
+	MixByPhase []map[string]int
+	// HaltReason is why the run was halted by stop condition. It's empty
+	// if the run completed.
+	HaltReason string
+	// ObjectLeaks reports objects leaked by post-delete requests. It's nil
+	// if there is no post-delete request.
+	ObjectLeaks []types.ObjectLeak
+	// InjectedDelay is the total artificial network delay injected into
+	// requests. It's excluded from latencies.
+	InjectedDelay time.Duration
+	// Attempts is the total number of HTTP round trips, including retries.
+	Attempts int64
+	// TargetStats is the stats of requests sent to each target. It's nil
+	// if there is no target.
+	TargetStats map[string]types.ResponseStats
+	// AuthFailures is the number of requests which failed with 401 or 403
+	// and triggered client rebuild instead of being counted as failures.
+	// The ones failed again by the rebuilt clients within
+	// AuthRefreshIntervalSeconds are counted as failures.
+	AuthFailures int64
+	// ClientRebuilds is the number of times the clients, including the
+	// targets' clients, were rebuilt after auth failures.
+	ClientRebuilds int64
+
+	metric metrics.ResponseMetric
+}
+
+// ExportLatencyDistribution writes the latency distribution of the run in
+// csv or json format. See metrics.ResponseMetric.ExportDistribution.
+func (r *Result) ExportLatencyDistribution(w io.Writer, format string) error {
+	if r.metric == nil {
+		return fmt.Errorf("no latency distribution")
+	}
+	return r.metric.ExportDistribution(w, format)
+}
+
+// ScheduleOpt is used to update default Schedule setting.
+type ScheduleOpt func(*scheduleCfg)
+
+type scheduleCfg struct {
+	debugAddr      string
+	targetClients  map[string][]rest.Interface
+	reservoirSize  int
+	rebuildClients func() ([]rest.Interface, error)
+	// rebuildTargetClients creates new clients for target by name.
+	rebuildTargetClients func(target string) ([]rest.Interface, error)
+}
+
+// WithScheduleDebugAddrOpt serves DebugStats in JSON format on
+// http://addr/debug/vars, profiles on http://addr/debug/pprof and live
+// metrics in prometheus format on http://addr/metrics during the run. Empty
+// means disabled.
+func WithScheduleDebugAddrOpt(addr string) ScheduleOpt {
+	return func(cfg *scheduleCfg) {
+		cfg.debugAddr = addr
+	}
+}
+
+// WithScheduleLatencyReservoirOpt keeps a random sample of at most size
+// latencies to export latency distribution with bounded memory. The value
+// <= 0 means all the latencies.
+func WithScheduleLatencyReservoirOpt(size int) ScheduleOpt {
+	return func(cfg *scheduleCfg) {
+		cfg.reservoirSize = size
+	}
+}
+
+// WithScheduleTargetClientsOpt sets the rest clients of each target in
+// LoadProfileSpec.Targets by name. The requests picking a target are sent
+// by its clients instead of the default ones.
+func WithScheduleTargetClientsOpt(clients map[string][]rest.Interface) ScheduleOpt {
+	return func(cfg *scheduleCfg) {
+		cfg.targetClients = clients
+	}
+}
+
+// WithScheduleClientRebuilderOpt sets the function to create new clients,
+// which replace the ones passed to Schedule when requests fail with 401 or
+// 403, like when the token in kubeconfig expires. It only works with
+// LoadProfileSpec.AuthRefreshIntervalSeconds.
+func WithScheduleClientRebuilderOpt(fn func() ([]rest.Interface, error)) ScheduleOpt {
+	return func(cfg *scheduleCfg) {
+		cfg.rebuildClients = fn
+	}
+}
+
+// WithScheduleTargetClientRebuilderOpt sets the function to create new
+// clients of target by name, which replace the ones set by
+// WithScheduleTargetClientsOpt when requests fail with 401 or 403, like
+// WithScheduleClientRebuilderOpt.
+func WithScheduleTargetClientRebuilderOpt(fn func(target string) ([]rest.Interface, error)) ScheduleOpt {
+	return func(cfg *scheduleCfg) {
+		cfg.rebuildTargetClients = fn
+	}
 }
 
 // Schedule files requests to apiserver based on LoadProfileSpec.
-func Schedule(ctx context.Context, spec *types.LoadProfileSpec, restCli []rest.Interface) (*Result, error) {
+func Schedule(ctx context.Context, spec *types.LoadProfileSpec, restCli []rest.Interface, opts ...ScheduleOpt) (*Result, error) {
+	var cfg scheduleCfg
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	rndReqs, err := NewWeightedRandomRequests(spec)
+	rndReqs, err := newWeightedRandomRequestsForSpec(spec)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := rndReqs.resolveNodeNames(ctx, restCli[0]); err != nil {
+		return nil, err
+	}
+
+	leaks := newLeakChecker(ctx, restCli[0], rndReqs)
+
+	var haltReason atomic.Value
+	if spec.StopCondition != nil {
+		go watchHealth(ctx, restCli[0], spec.StopCondition, func(reason string) {
+			klog.Warningf("Halting the run: %s", reason)
+			haltReason.Store(reason)
+			cancel()
+		})
+	}
+
 	qps := spec.Rate
 	if qps == 0 {
 		qps = float64(math.MaxInt32)
@@ -50,35 +176,132 @@ func Schedule(ctx context.Context, spec *types.LoadProfileSpec, restCli []rest.I
 	if clients == 0 {
 		clients = spec.Conns
 	}
+	if spec.ConnPerClient && len(restCli) < clients {
+		return nil, fmt.Errorf("connPerClient requires %d rest clients, but got %d", clients, len(restCli))
+	}
+	if clients < len(restCli) {
+		klog.Warningf("Only %d of %d connections are used because there are only %d clients",
+			clients, len(restCli), clients)
+	}
+
+	conns := min(clients, len(restCli))
+	for _, t := range spec.Targets {
+		conns += len(cfg.targetClients[t.Name])
+	}
+	counters := newDebugCounters(clients, conns, rndReqs.CacheSize)
+	var registry *prometheus.Registry
+	if cfg.debugAddr != "" {
+		registry = prometheus.NewRegistry()
+		registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+		if err := serveDebug(ctx, cfg.debugAddr, counters, registry); err != nil {
+			return nil, fmt.Errorf("failed to serve debug endpoint on %s: %w", cfg.debugAddr, err)
+		}
+	}
+
+	var rebuildClients func() ([]rest.Interface, error)
+	var rebuildTargetClients func(string) ([]rest.Interface, error)
+	if spec.AuthRefreshIntervalSeconds > 0 {
+		rebuildClients = cfg.rebuildClients
+		rebuildTargetClients = cfg.rebuildTargetClients
+	}
+	rebuildInterval := time.Duration(spec.AuthRefreshIntervalSeconds) * time.Second
+	pool := newClientPool(restCli, rebuildClients, rebuildInterval)
 
 	reqBuilderCh := rndReqs.Chan()
 	var wg sync.WaitGroup
 
-	respMetric := metrics.NewResponseMetric()
+	var injectedDelay, attempts, wireBytes, authFailures int64
+
+	metricOpts := []metrics.ResponseMetricOpt{
+		metrics.WithMaxFailureSamplesOpt(spec.MaxFailureSamples),
+		metrics.WithLatencyReservoirOpt(cfg.reservoirSize, rndReqs.rnd),
+	}
+	if spec.LatencyHistogram {
+		metricOpts = append(metricOpts, metrics.WithLatencyHistogramOpt(spec.LatencyBuckets))
+	}
+
+	// NOTE: The prometheus collectors are registered only once so the
+	// target metrics aren't exported.
+	picker, err := newTargetPicker(spec.Targets, cfg.targetClients,
+		rebuildTargetClients, rebuildInterval, rndReqs.rnd, metricOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if registry != nil {
+		metricOpts = append(metricOpts, metrics.WithPrometheusRegistererOpt(registry))
+	}
+	respMetric := metrics.NewResponseMetric(metricOpts...)
+
+	// NOTE: runCtx is canceled when the run is done, canceled or halted,
+	// so that in-flight requests are aborted promptly. It doesn't inherit
+	// ctx's deadline because client-go's rate limiter fails requests which
+	// would exceed the deadline before sending them.
+	runCtx, runCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer runCancel()
+	stopRunOnCancel := context.AfterFunc(ctx, runCancel)
+	defer stopRunOnCancel()
+
 	for i := 0; i < clients; i++ {
-		// reuse connection if clients > conns
-		cli := restCli[i%len(restCli)]
+		// NOTE: Each rest.Interface has individual transport, which is
+		// one connection with HTTP/2. The clients share connections in
+		// round-robin if clients > conns. Multiple clients on the same
+		// HTTP/2 connection are multiplexed as streams. With
+		// connPerClient, there is one rest.Interface for each client.
 		wg.Add(1)
-		go func(cli rest.Interface) {
+		go func(idx int) {
 			defer wg.Done()
 
 			for builder := range reqBuilderCh {
-				req := builder.Build(cli)
+				_, warmup := builder.(*warmupRequestBuilder)
 
-				if err := limiter.Wait(ctx); err != nil {
+				var target *scheduleTarget
+				reqPool := pool
+				if picker != nil {
+					target = picker.pick(idx)
+					reqPool = target.pool
+				}
+				reqCli, gen := reqPool.get(idx)
+				req := builder.Build(reqCli)
+
+				if err := limiter.Wait(runCtx); err != nil {
 					klog.V(5).Infof("Rate limiter wait failed: %v", err)
+					rndReqs.Done()
 					cancel()
 					return
 				}
 
 				klog.V(5).Infof("Request URL: %s", req.URL())
 
-				req.Timeout(defaultTimeout)
+				req.Timeout(requestTimeout(spec, req))
+				if spec.RetryBackoffBaseMs > 0 {
+					req.Backoff(newJitterBackoff(
+						time.Duration(spec.RetryBackoffBaseMs)*time.Millisecond,
+						time.Duration(spec.RetryBackoffMaxMs)*time.Millisecond,
+						rndReqs.rnd,
+					))
+				}
 				func() {
 					start := time.Now()
 
+					var recorder roundTripRecorder
+					reqCtx := withRoundTripRecorder(runCtx, &recorder)
+
+					atomic.AddInt64(&counters.inFlight, 1)
 					var bytes int64
-					bytes, err := req.Do(context.Background())
+					bytes, err := req.Do(reqCtx)
+					atomic.AddInt64(&counters.inFlight, -1)
+					if warmup {
+						klog.V(5).Infof("Warmup request done: %v", err)
+						return
+					}
+					// NOTE: The request aborted by shutdown isn't a failure.
+					if err != nil && runCtx.Err() != nil {
+						klog.V(5).Infof("Request aborted by shutdown: %v", err)
+						return
+					}
 					// Based on HTTP2 Spec Section 8.1 [1],
 					//
 					// A server can send a complete response prior to the client
@@ -99,53 +322,220 @@ func Schedule(ctx context.Context, spec *types.LoadProfileSpec, restCli []rest.I
 						err = nil
 					}
 
+					// NOTE: The injected delay is reported separately.
+					delay := recorder.InjectedDelay()
+					atomic.AddInt64(&injectedDelay, int64(delay))
+
+					// NOTE: The auth failure is likely caused by expired
+					// credential instead of cluster failure, unless the
+					// rebuilt clients still fail. Its attempts and bytes
+					// aren't counted, like the request itself, so that
+					// they don't skew the amplification factor.
+					if err != nil && metrics.IsAuthError(err) && reqPool.handleAuthFailure(gen) {
+						atomic.AddInt64(&authFailures, 1)
+						klog.V(5).Infof("Request failed with auth error, rebuilding clients: %v", err)
+						return
+					}
+					atomic.AddInt64(&attempts, recorder.Attempts())
+					atomic.AddInt64(&wireBytes, recorder.WireBytes())
+
 					end := time.Now()
-					latency := end.Sub(start).Seconds()
+					latency := (end.Sub(start) - delay).Seconds()
 
-					respMetric.ObserveReceivedBytes(bytes)
+					respMetric.ObserveReceivedBytes(req.Method(), bytes)
+					respMetric.ObserveSentBytes(req.SentBytes())
+					atomic.AddInt64(&counters.total, 1)
 					if err != nil {
-						respMetric.ObserveFailure(req.URL().String(), end, latency, err)
+						atomic.AddInt64(&counters.failures, 1)
+						respMetric.ObserveFailure(req.Method(), req.URL().String(), end, latency, err)
+						if target != nil {
+							target.metric.ObserveFailure(req.Method(), req.URL().String(), end, latency, err)
+						}
 						klog.V(5).Infof("Request stream failed: %v", err)
 						return
 					}
-					respMetric.ObserveLatency(req.URL().String(), latency)
+					respMetric.ObserveLatency(req.Method(), req.URL().String(), latency)
+					if target != nil {
+						target.metric.ObserveLatency(req.Method(), req.URL().String(), latency)
+					}
+					if instance := recorder.Instance(); instance != "" {
+						respMetric.ObserveInstanceLatency(instance, latency)
+					}
+					if at := recorder.FirstByteAt(); !at.IsZero() {
+						respMetric.ObserveTimeToFirstByte((at.Sub(start) - delay).Seconds())
+					}
+
+					if wreq, ok := req.(*WatchListRequester); ok {
+						ws := wreq.Stats()
+						respMetric.ObserveWatchEvents(ws.EventsByType, ws.TimeToFirstEvent.Seconds(), ws.Partial)
+						if len(ws.ReconnectLatencies) > 0 {
+							latencies := make([]float64, 0, len(ws.ReconnectLatencies))
+							for _, d := range ws.ReconnectLatencies {
+								latencies = append(latencies, d.Seconds())
+							}
+							respMetric.ObserveWatchReconnects(latencies)
+						}
+					}
 				}()
+				rndReqs.Done()
 			}
-		}(cli)
+		}(i)
 	}
 
 	klog.V(2).InfoS("Setting",
 		"clients", clients,
 		"connections", len(restCli),
+		"conn-per-client", spec.ConnPerClient,
 		"rate", qps,
 		"total", spec.Total,
 		"duration", spec.Duration,
 		"http2", !spec.DisableHTTP2,
 		"content-type", spec.ContentType,
+		"network-delay-ms", spec.NetworkDelayMs,
+		"request-timeout-seconds", spec.RequestTimeoutSeconds,
+		"watch-timeout-seconds", spec.WatchTimeoutSeconds,
+		"drain-timeout-seconds", spec.DrainTimeoutSeconds,
+		"accept-encoding", spec.AcceptEncoding,
+		"warmup-seconds", spec.WarmupSeconds,
+		"ramp-up-seconds", spec.RampUpSeconds,
 	)
 
+	// NOTE: There is nothing to ramp up if rate is unlimited.
+	if spec.RampUpSeconds > 0 && spec.Rate > 0 {
+		startRampUp(ctx, limiter, spec.Rate, time.Duration(spec.RampUpSeconds)*time.Second)
+	}
+
+	var warmupDuration time.Duration
+	if spec.WarmupSeconds > 0 {
+		warmupStart := time.Now()
+		klog.V(2).InfoS("Warming up", "duration", time.Duration(spec.WarmupSeconds)*time.Second)
+		rndReqs.Warmup(ctx, time.Duration(spec.WarmupSeconds)*time.Second)
+		warmupDuration = time.Since(warmupStart)
+	}
+
 	start := time.Now()
 
+	// NOTE: genCtx stops generating new requests. With drain timeout,
+	// in-flight requests are allowed to finish when duration elapses.
+	genCtx, genCancel := context.WithCancel(runCtx)
+	defer genCancel()
+	stopRun := runCancel
+	drainTimeout := time.Duration(spec.DrainTimeoutSeconds) * time.Second
+	if drainTimeout > 0 {
+		stopRun = genCancel
+	}
+
 	if spec.Duration > 0 {
 		// If duration is set, we will run for duration.
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, time.Duration(spec.Duration)*time.Second)
-		defer cancel()
+		timer := time.AfterFunc(time.Duration(spec.Duration)*time.Second, stopRun)
+		defer timer.Stop()
 	}
-	rndReqs.Run(ctx, spec.Total)
+	rndReqs.Run(genCtx, spec.Total)
 
-	rndReqs.Stop()
+	if drainTimeout > 0 {
+		if !rndReqs.StopAndDrain(drainTimeout) {
+			klog.Warningf("Aborting in-flight requests which aren't finished in %v", drainTimeout)
+			runCancel()
+		}
+	} else {
+		rndReqs.Stop()
+	}
 	wg.Wait()
 
 	totalDuration := time.Since(start)
 	responseStats := respMetric.Gather()
+
+	// NOTE: Count completed requests instead of using spec.Total because
+	// the run might be canceled or halted.
+	responseStats.TotalWireBytes = atomic.LoadInt64(&wireBytes)
+	completed := atomic.LoadInt64(&counters.total)
+	if completed > 0 {
+		responseStats.ActualQPS = float64(completed) / totalDuration.Seconds()
+		responseStats.ErrorRate = float64(atomic.LoadInt64(&counters.failures)) / float64(completed)
+	}
+	reason, _ := haltReason.Load().(string)
+
+	var targetStats map[string]types.ResponseStats
+	clientRebuilds := pool.Rebuilds()
+	if picker != nil {
+		targetStats = picker.gather()
+		clientRebuilds += picker.rebuilds()
+	}
+
+	var objectLeaks []types.ObjectLeak
+	if leaks != nil {
+		// NOTE: ctx might be canceled by stop condition.
+		objectLeaks = leaks.check(context.Background(), spec.CleanupLeakedObjects)
+	}
 	return &Result{
 		ResponseStats: responseStats,
 		Duration:      totalDuration,
-		Total:         spec.Total,
+
+		WarmupDuration: warmupDuration,
+		Total:          int(completed),
+		MixByPhase:     rndReqs.PhaseMix(),
+		HaltReason:     reason,
+		ObjectLeaks:    objectLeaks,
+		InjectedDelay:  time.Duration(atomic.LoadInt64(&injectedDelay)),
+		Attempts:       atomic.LoadInt64(&attempts),
+		TargetStats:    targetStats,
+		AuthFailures:   atomic.LoadInt64(&authFailures),
+		ClientRebuilds: clientRebuilds,
+
+		metric: respMetric,
 	}, nil
 }
 
+// newWeightedRandomRequestsForSpec creates WeightedRandomRequests with the
+// random seed in spec if it's set.
+func newWeightedRandomRequestsForSpec(spec *types.LoadProfileSpec) (*WeightedRandomRequests, error) {
+	if spec.RandomSeed != nil {
+		return NewWeightedRandomRequestsWithSeed(spec, *spec.RandomSeed)
+	}
+	return NewWeightedRandomRequests(spec)
+}
+
+// requestTimeout returns the timeout of req based on spec.
+func requestTimeout(spec *types.LoadProfileSpec, req Requester) time.Duration {
+	timeout := defaultTimeout
+	if spec.RequestTimeoutSeconds > 0 {
+		timeout = time.Duration(spec.RequestTimeoutSeconds) * time.Second
+	}
+
+	if _, ok := req.(*WatchListRequester); ok && spec.WatchTimeoutSeconds > 0 {
+		timeout = time.Duration(spec.WatchTimeoutSeconds) * time.Second
+	}
+	return timeout
+}
+
+// rampUpInterval is the interval to raise limit during ramp-up.
+const rampUpInterval = 100 * time.Millisecond
+
+// startRampUp sets limiter's limit to the first step and raises it linearly
+// to target over d in background.
+func startRampUp(ctx context.Context, limiter *rate.Limiter, target float64, d time.Duration) {
+	steps := int(d / rampUpInterval)
+	if steps <= 1 {
+		limiter.SetLimit(rate.Limit(target))
+		return
+	}
+
+	limiter.SetLimit(rate.Limit(target / float64(steps)))
+	go func() {
+		ticker := time.NewTicker(rampUpInterval)
+		defer ticker.Stop()
+
+		for step := 2; step <= steps; step++ {
+			select {
+			case <-ticker.C:
+				limiter.SetLimit(rate.Limit(target * float64(step) / float64(steps)))
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
 // isHTTP2StreamNoError returns true if it's NO_ERROR.
 func isHTTP2StreamNoError(err error) bool {
 	if err == nil {
@@ -0,0 +1,447 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/rest"
+)
+
+// newScheduleTestClient returns rest client without client-side rate limit.
+func newScheduleTestClient(t *testing.T, host string) rest.Interface {
+	restCfg := &rest.Config{
+		Host:    host,
+		Proxy:   http.ProxyFromEnvironment,
+		QPS:     1000,
+		Burst:   1000,
+		APIPath: "/api",
+	}
+	restCfg.NegotiatedSerializer = unstructuredscheme.NewNegotiatedSerializer()
+
+	cli, err := rest.UnversionedRESTClientFor(restCfg)
+	require.NoError(t, err)
+	return cli
+}
+
+// newScheduleTestSpec returns load profile spec to get one configmap.
+func newScheduleTestSpec() *types.LoadProfileSpec {
+	return &types.LoadProfileSpec{
+		Conns:       1,
+		Client:      1,
+		ContentType: types.ContentTypeJSON,
+		Requests: []*types.WeightedRequest{
+			{
+				Shares: 100,
+				StaleGet: &types.RequestGet{
+					KubeGroupVersionResource: types.KubeGroupVersionResource{
+						Version:  "v1",
+						Resource: "configmaps",
+					},
+					Namespace: "default",
+					Name:      "x",
+				},
+			},
+		},
+	}
+}
+
+func TestScheduleCountsCompletedRequests(t *testing.T) {
+	var calls, failures int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&calls, 1)%3 == 0 {
+			atomic.AddInt64(&failures, 1)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	spec := newScheduleTestSpec()
+	spec.Total = 1000000
+	spec.Client = 4
+
+	// NOTE: Cancel in the middle of run.
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	res, err := Schedule(ctx, spec, []rest.Interface{cli})
+	require.NoError(t, err)
+
+	total := atomic.LoadInt64(&calls)
+	require.Greater(t, total, int64(0))
+	require.Less(t, total, int64(spec.Total))
+
+	// NOTE: The in-flight requests aborted by cancellation aren't counted.
+	assert.LessOrEqual(t, res.Total, int(total))
+	assert.GreaterOrEqual(t, res.Total, int(total)-spec.Client)
+	assert.LessOrEqual(t, res.FailuresByMethod["GET"], int(atomic.LoadInt64(&failures)))
+	assert.GreaterOrEqual(t, res.FailuresByMethod["GET"], int(atomic.LoadInt64(&failures))-spec.Client)
+	assert.InDelta(t, float64(res.FailuresByMethod["GET"])/float64(res.Total), res.ErrorRate, 1e-9)
+	assert.InDelta(t, float64(res.Total)/res.Duration.Seconds(), res.ActualQPS, 1e-6)
+}
+
+func TestScheduleWarmup(t *testing.T) {
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	spec := newScheduleTestSpec()
+	spec.Rate = 100
+	spec.Total = 10
+	spec.WarmupSeconds = 1
+
+	res, err := Schedule(context.Background(), spec, []rest.Interface{cli})
+	require.NoError(t, err)
+
+	// Warmup requests are sent but neither counted nor recorded.
+	assert.Greater(t, atomic.LoadInt64(&calls), int64(spec.Total))
+	assert.Equal(t, spec.Total, res.Total)
+	assert.Equal(t, map[string]int{"GET": spec.Total}, res.TotalByMethod)
+	assert.GreaterOrEqual(t, res.WarmupDuration, time.Second)
+	assert.Less(t, res.Duration, time.Second)
+}
+
+func TestScheduleRampUp(t *testing.T) {
+	start := time.Now()
+	var earlyCalls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if time.Since(start) < 300*time.Millisecond {
+			atomic.AddInt64(&earlyCalls, 1)
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	spec := newScheduleTestSpec()
+	spec.Rate = 200
+	spec.Total = 100
+	spec.RampUpSeconds = 1
+
+	start = time.Now()
+	res, err := Schedule(context.Background(), spec, []rest.Interface{cli})
+	require.NoError(t, err)
+	assert.Equal(t, spec.Total, res.Total)
+
+	// NOTE: It's about 60 requests in the first 300ms at full rate. It's
+	// about 9 requests with ramp-up.
+	assert.Less(t, atomic.LoadInt64(&earlyCalls), int64(30))
+}
+
+func TestStartRampUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limiter := rate.NewLimiter(rate.Limit(100), 1)
+	startRampUp(ctx, limiter, 100, 500*time.Millisecond)
+	assert.Equal(t, rate.Limit(20), limiter.Limit())
+
+	assert.Eventually(t, func() bool {
+		return limiter.Limit() == rate.Limit(100)
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// too short to ramp up
+	limiter = rate.NewLimiter(rate.Limit(1), 1)
+	startRampUp(ctx, limiter, 100, 50*time.Millisecond)
+	assert.Equal(t, rate.Limit(100), limiter.Limit())
+}
+
+func TestRequestTimeout(t *testing.T) {
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+	get := newRequestGetBuilder(&types.RequestGet{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+		Namespace: "default",
+		Name:      "x",
+	}, "0", 0, false)
+	watch := newRequestWatchListBuilder(&types.RequestWatchList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "configmaps",
+		},
+	}, 0)
+
+	for _, tc := range []struct {
+		requestTimeoutSeconds int
+		watchTimeoutSeconds   int
+		getTimeout            string
+		watchTimeout          string
+	}{
+		{getTimeout: "1m0s", watchTimeout: "1m0s"},
+		{requestTimeoutSeconds: 5, getTimeout: "5s", watchTimeout: "5s"},
+		{requestTimeoutSeconds: 5, watchTimeoutSeconds: 600, getTimeout: "5s", watchTimeout: "10m0s"},
+	} {
+		spec := &types.LoadProfileSpec{
+			RequestTimeoutSeconds: tc.requestTimeoutSeconds,
+			WatchTimeoutSeconds:   tc.watchTimeoutSeconds,
+		}
+
+		req := get.Build(cli)
+		req.Timeout(requestTimeout(spec, req))
+		assert.Equal(t, tc.getTimeout, req.URL().Query().Get("timeout"))
+
+		req = watch.Build(cli)
+		req.Timeout(requestTimeout(spec, req))
+		assert.Equal(t, tc.watchTimeout, req.URL().Query().Get("timeout"))
+	}
+}
+
+func TestScheduleAbortsInflightRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		// NOTE: Block until client aborts the request.
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	spec := newScheduleTestSpec()
+	spec.Total = 0
+	spec.Duration = 1
+
+	start := time.Now()
+	res, err := Schedule(context.Background(), spec, []rest.Interface{cli})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, 0, res.Total)
+	assert.Empty(t, res.FailuresByMethod)
+
+	// canceled by caller
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(500*time.Millisecond, cancel)
+
+	spec.Duration = 60
+	start = time.Now()
+	res, err = Schedule(ctx, spec, []rest.Interface{cli})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, 0, res.Total)
+	assert.Empty(t, res.FailuresByMethod)
+}
+
+func TestScheduleReportsPartialResultsWhenCanceled(t *testing.T) {
+	const cancelAt = 5
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		// NOTE: There is only one client, so the previous responses
+		// have been observed before the cancel.
+		if atomic.AddInt64(&calls, 1) == cancelAt {
+			cancel()
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	spec := newScheduleTestSpec()
+	spec.Total = 0
+	spec.Duration = 60
+
+	res, err := Schedule(ctx, spec, []rest.Interface{newScheduleTestClient(t, srv.URL)})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, res.Total, cancelAt-1)
+	assert.LessOrEqual(t, res.Total, cancelAt)
+	assert.Equal(t, res.Total, res.TotalByMethod["GET"])
+	assert.Empty(t, res.FailuresByMethod)
+	assert.Greater(t, res.Duration, time.Duration(0))
+	assert.Less(t, res.Duration, 10*time.Second)
+}
+
+func TestScheduleDrainsInflightRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	cli := newScheduleTestClient(t, srv.URL)
+
+	spec := newScheduleTestSpec()
+	spec.Total = 0
+	spec.Duration = 1
+	spec.DrainTimeoutSeconds = 5
+
+	res, err := Schedule(context.Background(), spec, []rest.Interface{cli})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Total)
+	assert.Empty(t, res.FailuresByMethod)
+
+	// aborted after drain timeout
+	blockSrv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer blockSrv.Close()
+
+	blockCli := newScheduleTestClient(t, blockSrv.URL)
+	spec.DrainTimeoutSeconds = 1
+
+	start := time.Now()
+	res, err = Schedule(context.Background(), spec, []rest.Interface{blockCli})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.Equal(t, 0, res.Total)
+	assert.Empty(t, res.FailuresByMethod)
+}
+
+func TestScheduleTimeToFirstByte(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	spec := newScheduleTestSpec()
+	spec.Total = 2
+
+	res, err := Schedule(context.Background(), spec, []rest.Interface{newScheduleTestClient(t, srv.URL)})
+	require.NoError(t, err)
+	require.Len(t, res.TimesToFirstByte, 2)
+	for _, ttfb := range res.TimesToFirstByte {
+		assert.Less(t, ttfb, 0.2)
+	}
+	for _, latencies := range res.LatenciesByURL {
+		for _, latency := range latencies {
+			assert.GreaterOrEqual(t, latency, 0.2)
+		}
+	}
+}
+
+func TestScheduleTargets(t *testing.T) {
+	newServer := func(calls *int64) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt64(calls, 1)
+			_, _ = w.Write([]byte(`{}`))
+		}))
+	}
+
+	var defaultCalls, aCalls, bCalls int64
+	defaultSrv, aSrv, bSrv := newServer(&defaultCalls), newServer(&aCalls), newServer(&bCalls)
+	defer defaultSrv.Close()
+	defer aSrv.Close()
+	defer bSrv.Close()
+
+	spec := newScheduleTestSpec()
+	spec.Total = 100
+	spec.Targets = []*types.Target{
+		{Name: "a", Shares: 1},
+		{Name: "b", Shares: 1},
+	}
+
+	res, err := Schedule(context.Background(), spec,
+		[]rest.Interface{newScheduleTestClient(t, defaultSrv.URL)},
+		WithScheduleTargetClientsOpt(map[string][]rest.Interface{
+			"a": {newScheduleTestClient(t, aSrv.URL)},
+			"b": {newScheduleTestClient(t, bSrv.URL)},
+		}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 100, res.Total)
+	assert.Equal(t, int64(0), atomic.LoadInt64(&defaultCalls))
+	assert.Equal(t, int64(100), atomic.LoadInt64(&aCalls)+atomic.LoadInt64(&bCalls))
+	assert.Greater(t, atomic.LoadInt64(&aCalls), int64(0))
+	assert.Greater(t, atomic.LoadInt64(&bCalls), int64(0))
+
+	require.Len(t, res.TargetStats, 2)
+	assert.Equal(t, int(atomic.LoadInt64(&aCalls)), res.TargetStats["a"].TotalByMethod["GET"])
+	assert.Equal(t, int(atomic.LoadInt64(&bCalls)), res.TargetStats["b"].TotalByMethod["GET"])
+
+	// missing clients
+	_, err = Schedule(context.Background(), spec, []rest.Interface{newScheduleTestClient(t, defaultSrv.URL)})
+	assert.Error(t, err)
+}
+
+func TestScheduleConnPerClient(t *testing.T) {
+	var mu sync.Mutex
+	conns := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		conns[r.RemoteAddr] = true
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	spec := newScheduleTestSpec()
+	spec.Total = 100
+	spec.Client = 4
+	spec.ConnPerClient = true
+
+	_, err := Schedule(context.Background(), spec, []rest.Interface{newScheduleTestClient(t, srv.URL)})
+	require.Error(t, err)
+
+	clis := []rest.Interface{}
+	for i := 0; i < spec.Client; i++ {
+		clis = append(clis, newScheduleTestClient(t, srv.URL))
+	}
+	res, err := Schedule(context.Background(), spec, clis)
+	require.NoError(t, err)
+	assert.Equal(t, spec.Total, res.Total)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, len(conns), spec.Client)
+}
+
+func TestScheduleMapsClientsOntoConns(t *testing.T) {
+	const conns = 3
+
+	var calls [conns]int64
+	clis := make([]rest.Interface, 0, conns)
+	for i := 0; i < conns; i++ {
+		i := i
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt64(&calls[i], 1)
+			time.Sleep(5 * time.Millisecond)
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer srv.Close()
+
+		clis = append(clis, newScheduleTestClient(t, srv.URL))
+	}
+
+	spec := newScheduleTestSpec()
+	spec.Conns = conns
+	spec.Client = 2 * conns
+	spec.Total = 60
+
+	res, err := Schedule(context.Background(), spec, clis)
+	require.NoError(t, err)
+	assert.Equal(t, 60, res.Total)
+
+	var total int64
+	for i := range calls {
+		assert.Greater(t, atomic.LoadInt64(&calls[i]), int64(0), "conn #%d", i)
+		total += atomic.LoadInt64(&calls[i])
+	}
+	assert.Equal(t, int64(60), total)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// selectorTemplate is the label selector with placeholders which are
+// expanded with random values for each request. The placeholders are
+//
+//   - {{rand MIN MAX}}: a random integer in [MIN, MAX).
+//   - {{pick A B ...}}: one of the values picked randomly.
+//
+// For instance, shard={{rand 0 16}},tier in ({{pick web db}}).
+type selectorTemplate struct {
+	parts []selectorPart
+}
+
+// selectorPart is either literal or placeholder.
+type selectorPart struct {
+	literal string
+
+	// values is for pick placeholder.
+	values []string
+	// min and max is for rand placeholder if values is nil.
+	min, max int64
+}
+
+// parseSelectorTemplate parses label selector with placeholders. It returns
+// nil if there is no placeholder.
+func parseSelectorTemplate(selector string) (*selectorTemplate, error) {
+	if !strings.Contains(selector, "{{") {
+		return nil, nil
+	}
+
+	tmpl := &selectorTemplate{}
+	rest := selector
+	for {
+		before, after, found := strings.Cut(rest, "{{")
+		if before != "" {
+			tmpl.parts = append(tmpl.parts, selectorPart{literal: before})
+		}
+		if !found {
+			break
+		}
+
+		placeholder, remaining, found := strings.Cut(after, "}}")
+		if !found {
+			return nil, fmt.Errorf("unclosed placeholder in selector %q", selector)
+		}
+
+		part, err := parseSelectorPlaceholder(placeholder)
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder {{%s}} in selector %q: %w", placeholder, selector, err)
+		}
+		tmpl.parts = append(tmpl.parts, part)
+		rest = remaining
+	}
+	return tmpl, nil
+}
+
+// parseSelectorPlaceholder parses the content of {{...}}.
+func parseSelectorPlaceholder(placeholder string) (selectorPart, error) {
+	fields := strings.Fields(placeholder)
+	if len(fields) == 0 {
+		return selectorPart{}, fmt.Errorf("empty placeholder")
+	}
+
+	switch fields[0] {
+	case "rand":
+		if len(fields) != 3 {
+			return selectorPart{}, fmt.Errorf("rand requires MIN and MAX")
+		}
+
+		min, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return selectorPart{}, fmt.Errorf("invalid MIN: %w", err)
+		}
+		max, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return selectorPart{}, fmt.Errorf("invalid MAX: %w", err)
+		}
+		if min >= max {
+			return selectorPart{}, fmt.Errorf("MIN %d requires < MAX %d", min, max)
+		}
+		return selectorPart{min: min, max: max}, nil
+	case "pick":
+		if len(fields) < 2 {
+			return selectorPart{}, fmt.Errorf("pick requires at least one value")
+		}
+		return selectorPart{values: fields[1:]}, nil
+	default:
+		return selectorPart{}, fmt.Errorf("unknown function %s", fields[0])
+	}
+}
+
+// expand returns label selector with random values.
+func (t *selectorTemplate) expand(rnd randSource) string {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		switch {
+		case part.literal != "":
+			sb.WriteString(part.literal)
+		case part.values != nil:
+			sb.WriteString(part.values[rnd.Int63n(int64(len(part.values)))])
+		default:
+			sb.WriteString(strconv.FormatInt(part.min+rnd.Int63n(part.max-part.min), 10))
+		}
+	}
+	return sb.String()
+}
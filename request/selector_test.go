@@ -0,0 +1,74 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"testing"
+
+	"github.com/Azure/kperf/api/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectorTemplate(t *testing.T) {
+	tmpl, err := parseSelectorTemplate("app=kperf")
+	require.NoError(t, err)
+	assert.Nil(t, tmpl)
+
+	for _, tc := range []struct {
+		selector string
+		rnd      fixedRandSource
+		expected string
+	}{
+		{selector: "shard={{rand 0 16}}", rnd: 3, expected: "shard=3"},
+		{selector: "shard={{ rand 10 20 }},app=kperf", rnd: 5, expected: "shard=15,app=kperf"},
+		{selector: "tier in ({{pick web db cache}})", rnd: 1, expected: "tier in (db)"},
+		{selector: "{{pick a b}}={{rand 1 3}}", rnd: 0, expected: "a=1"},
+	} {
+		tmpl, err := parseSelectorTemplate(tc.selector)
+		require.NoError(t, err, tc.selector)
+		assert.Equal(t, tc.expected, tmpl.expand(tc.rnd), tc.selector)
+	}
+
+	for _, selector := range []string{
+		"shard={{rand 0 16",
+		"shard={{}}",
+		"shard={{rand 16 0}}",
+		"shard={{rand 0}}",
+		"shard={{rand a b}}",
+		"shard={{pick}}",
+		"shard={{unknown 1}}",
+	} {
+		_, err := parseSelectorTemplate(selector)
+		assert.Error(t, err, selector)
+	}
+}
+
+func TestRequestListBuilderSelectorTemplate(t *testing.T) {
+	b, err := newRequestListBuilder(&types.RequestList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		Selector: "shard={{rand 0 4}}",
+	}, "0", 0, false, cryptoRandSource{})
+	require.NoError(t, err)
+
+	cli := newScheduleTestClient(t, "http://127.0.0.1:0")
+	seen := map[string]bool{}
+	for i := 0; i < 200; i++ {
+		seen[b.Build(cli).URL().Query().Get("labelSelector")] = true
+	}
+	assert.Equal(t, map[string]bool{"shard=0": true, "shard=1": true, "shard=2": true, "shard=3": true}, seen)
+
+	_, err = newRequestListBuilder(&types.RequestList{
+		KubeGroupVersionResource: types.KubeGroupVersionResource{
+			Version:  "v1",
+			Resource: "pods",
+		},
+		Selector: "shard={{rand 4 0}}",
+	}, "0", 0, false, cryptoRandSource{})
+	assert.Error(t, err)
+}
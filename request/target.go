@@ -0,0 +1,90 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/kperf/api/types"
+	"github.com/Azure/kperf/metrics"
+
+	"k8s.io/client-go/rest"
+)
+
+// scheduleTarget is a cluster which requests are distributed to.
+type scheduleTarget struct {
+	name string
+	pool *clientPool
+	// metric observes the requests sent to this target.
+	metric metrics.ResponseMetric
+}
+
+// targetPicker picks target by weight for each request.
+type targetPicker struct {
+	targets []*scheduleTarget
+	shares  []int
+	rnd     randSource
+}
+
+// newTargetPicker returns nil if there is no target so that all the
+// requests are sent to the default clients. The clients of each target are
+// rebuilt by rebuildFn, if not nil, like the default clients.
+func newTargetPicker(targets []*types.Target, clients map[string][]rest.Interface,
+	rebuildFn func(target string) ([]rest.Interface, error), rebuildInterval time.Duration,
+	rnd randSource, metricOpts ...metrics.ResponseMetricOpt) (*targetPicker, error) {
+
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	p := &targetPicker{rnd: rnd}
+	for _, t := range targets {
+		if len(clients[t.Name]) == 0 {
+			return nil, fmt.Errorf("no rest client for target %s", t.Name)
+		}
+
+		var targetRebuildFn func() ([]rest.Interface, error)
+		if rebuildFn != nil {
+			name := t.Name
+			targetRebuildFn = func() ([]rest.Interface, error) {
+				return rebuildFn(name)
+			}
+		}
+
+		p.targets = append(p.targets, &scheduleTarget{
+			name:   t.Name,
+			pool:   newClientPool(clients[t.Name], targetRebuildFn, rebuildInterval),
+			metric: metrics.NewResponseMetric(metricOpts...),
+		})
+		p.shares = append(p.shares, t.Shares)
+	}
+	return p, nil
+}
+
+// pick returns the target picked by weight and its rest client for the
+// clientIdx-th client. The clients share the target's connections in
+// round-robin like the default clients.
+func (p *targetPicker) pick(clientIdx int) *scheduleTarget {
+	return p.targets[pickByWeight(p.rnd, p.shares)]
+}
+
+// rebuilds returns the number of successful rebuilds of all the targets'
+// clients.
+func (p *targetPicker) rebuilds() int64 {
+	var res int64
+	for _, t := range p.targets {
+		res += t.pool.Rebuilds()
+	}
+	return res
+}
+
+// gather returns the stats of requests for each target.
+func (p *targetPicker) gather() map[string]types.ResponseStats {
+	res := make(map[string]types.ResponseStats, len(p.targets))
+	for _, t := range p.targets {
+		res[t.name] = t.metric.Gather()
+	}
+	return res
+}
@@ -0,0 +1,110 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/kperf/metrics"
+	"github.com/Azure/kperf/request/unstructuredscheme"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// responseDecoder decodes json response into unstructured object.
+var responseDecoder = unstructuredscheme.NewNegotiatedSerializer().SupportedMediaTypes()[0].Serializer
+
+// ValidateRequester decodes the response and validates that the object
+// matches the request. It's used to catch serialization bugs or truncated
+// responses, which are missed by DiscardRequester.
+type ValidateRequester struct {
+	BaseRequester
+	expected expectedObject
+}
+
+func (reqr *ValidateRequester) Do(ctx context.Context) (bytes int64, err error) {
+	respBody, err := reqr.req.Stream(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer respBody.Close()
+	roundTripRecorderFrom(ctx).markFirstByte()
+
+	data, err := io.ReadAll(respBody)
+	if err != nil {
+		return int64(len(data)), err
+	}
+	return int64(len(data)), reqr.expected.validate(data)
+}
+
+// expectedObject describes the object returned by GET or LIST request.
+type expectedObject struct {
+	// version is the group/version of request.
+	version schema.GroupVersion
+	// kind is the object's kind. It's optional because the request might
+	// be specified by resource.
+	kind string
+	// list means the response should be a list of objects.
+	list bool
+	// namespace is the namespace of object for GET request.
+	namespace string
+	// name is the name of object for GET request.
+	name string
+}
+
+// validate decodes data and validates the object. It returns
+// metrics.DecodeError if the object doesn't match.
+func (e expectedObject) validate(data []byte) error {
+	obj, gvk, err := responseDecoder.Decode(data, nil, nil)
+	if err != nil {
+		return &metrics.DecodeError{Reason: "invalid object", Err: err}
+	}
+
+	if gvk.GroupVersion() != e.version {
+		return &metrics.DecodeError{
+			Reason: "unexpected apiVersion",
+			Err:    fmt.Errorf("expected %s, got %s", e.version, gvk.GroupVersion()),
+		}
+	}
+
+	expectedKind := e.kind
+	if expectedKind != "" && e.list {
+		expectedKind += "List"
+	}
+	if (expectedKind != "" && gvk.Kind != expectedKind) ||
+		(e.list && !strings.HasSuffix(gvk.Kind, "List")) {
+		return &metrics.DecodeError{
+			Reason: "unexpected kind",
+			Err:    fmt.Errorf("expected %s, got %s", expectedKind, gvk.Kind),
+		}
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return &metrics.DecodeError{
+			Reason: "unexpected kind",
+			Err:    fmt.Errorf("unexpected object %T", obj),
+		}
+	}
+
+	if e.list {
+		if _, _, err := unstructured.NestedSlice(u.Object, "items"); err != nil {
+			return &metrics.DecodeError{Reason: "invalid items", Err: err}
+		}
+		return nil
+	}
+
+	if u.GetName() != e.name || u.GetNamespace() != e.namespace {
+		return &metrics.DecodeError{
+			Reason: "unexpected object",
+			Err: fmt.Errorf("expected %s/%s, got %s/%s",
+				e.namespace, e.name, u.GetNamespace(), u.GetName()),
+		}
+	}
+	return nil
+}
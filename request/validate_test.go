@@ -0,0 +1,94 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package request
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/kperf/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestExpectedObjectValidate(t *testing.T) {
+	podGet := expectedObject{
+		version:   schema.GroupVersion{Version: "v1"},
+		kind:      "Pod",
+		namespace: "default",
+		name:      "x1",
+	}
+	deployList := expectedObject{
+		version: schema.GroupVersion{Group: "apps", Version: "v1"},
+		list:    true,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		expected expectedObject
+		data     string
+		reason   string
+	}{
+		{
+			name:     "get",
+			expected: podGet,
+			data:     `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"x1","namespace":"default"}}`,
+		},
+		{
+			name:     "get truncated",
+			expected: podGet,
+			data:     `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"x1"`,
+			reason:   "invalid object",
+		},
+		{
+			name:     "get unexpected apiVersion",
+			expected: podGet,
+			data:     `{"apiVersion":"v2","kind":"Pod","metadata":{"name":"x1","namespace":"default"}}`,
+			reason:   "unexpected apiVersion",
+		},
+		{
+			name:     "get unexpected kind",
+			expected: podGet,
+			data:     `{"apiVersion":"v1","kind":"Node","metadata":{"name":"x1","namespace":"default"}}`,
+			reason:   "unexpected kind",
+		},
+		{
+			name:     "get unexpected name",
+			expected: podGet,
+			data:     `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"x2","namespace":"default"}}`,
+			reason:   "unexpected object",
+		},
+		{
+			name:     "list",
+			expected: deployList,
+			data:     `{"apiVersion":"apps/v1","kind":"DeploymentList","metadata":{},"items":[{"metadata":{"name":"x1"}}]}`,
+		},
+		{
+			name:     "list not list kind",
+			expected: deployList,
+			data:     `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"x1"}}`,
+			reason:   "unexpected kind",
+		},
+		{
+			name:     "list invalid items",
+			expected: deployList,
+			data:     `{"apiVersion":"apps/v1","kind":"DeploymentList","metadata":{},"items":{}}`,
+			reason:   "invalid items",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.expected.validate([]byte(tc.data))
+			if tc.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var decodeErr *metrics.DecodeError
+			if assert.True(t, errors.As(err, &decodeErr), "unexpected error: %v", err) {
+				assert.Equal(t, tc.reason, decodeErr.Reason)
+			}
+		})
+	}
+}
@@ -473,6 +473,20 @@ func (h *Handler) buildBatchJobObject(uploadURL string) *batchv1.Job {
 		}
 	}
 
+	if len(h.spec.NodeSelector) > 0 {
+		job.Spec.Template.Spec.NodeSelector = h.spec.NodeSelector
+	}
+
+	for _, t := range h.spec.Tolerations {
+		job.Spec.Template.Spec.Tolerations = append(job.Spec.Template.Spec.Tolerations, corev1.Toleration{
+			Key:               t.Key,
+			Operator:          corev1.TolerationOperator(t.Operator),
+			Value:             t.Value,
+			Effect:            corev1.TaintEffect(t.Effect),
+			TolerationSeconds: t.TolerationSeconds,
+		})
+	}
+
 	if sa := h.spec.ServiceAccount; sa != nil {
 		job.Spec.Template.Spec.ServiceAccountName = *sa
 	}
@@ -99,7 +99,7 @@ func parseRunnerGroupSpecFromConfigMap(clientset kubernetes.Interface, namespace
 func parseRunnerGroupSpecFromBinary(data []byte) (*types.RunnerGroupSpec, error) {
 	var spec types.RunnerGroupSpec
 
-	if err := yaml.Unmarshal(data, &spec); err != nil {
+	if err := yaml.UnmarshalStrict(data, &spec); err != nil {
 		return nil, fmt.Errorf("failed to parse RunnerGroupSpec from YAML: %s\nerror: %w", string(data), err)
 	}
 	return &spec, nil
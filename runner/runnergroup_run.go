@@ -49,6 +49,10 @@ func CreateRunnerGroupServer(ctx context.Context,
 		opt(&cfg)
 	}
 
+	// NOTE: Server tolerates runners' taints as well so that it can be
+	// deployed into the nodes dedicated for load generation.
+	cfg.serverTolerations = append(append([]types.Toleration{}, cfg.serverTolerations...), rgSpec.Tolerations...)
+
 	appiler, err := cfg.toServerHelmValuesAppiler()
 	if err != nil {
 		return err
@@ -109,6 +113,8 @@ func tweakAndMarshalSpec(spec *types.RunnerGroupSpec) (string, error) {
 type runCmdConfig struct {
 	// serverNodeSelectors forces to schedule server to nodes with that specific labels.
 	serverNodeSelectors map[string][]string
+	// serverTolerations allows server to be scheduled to tainted nodes.
+	serverTolerations []types.Toleration
 	// runnerGroupFlowcontrol applies flowcontrol settings to runners.
 	//
 	// NOTE: Please align with ../manifests/runnergroup/server/values.yaml
@@ -132,6 +138,13 @@ func WithRunCmdServerNodeSelectorsOpt(labels map[string][]string) RunCmdOpt {
 	}
 }
 
+// WithRunCmdServerTolerationsOpt updates server's tolerations.
+func WithRunCmdServerTolerationsOpt(tolerations []types.Toleration) RunCmdOpt {
+	return func(cfg *runCmdConfig) {
+		cfg.serverTolerations = tolerations
+	}
+}
+
 // WithRunCmdRunnerGroupFlowControl updates runner groups' flowcontrol.
 func WithRunCmdRunnerGroupFlowControl(priorityLevel string, matchingPrecedence int) RunCmdOpt {
 	return func(cfg *runCmdConfig) {
@@ -146,6 +159,7 @@ func WithRunCmdRunnerGroupFlowControl(priorityLevel string, matchingPrecedence i
 func (cfg *runCmdConfig) toServerHelmValuesAppiler() (helmcli.ValuesApplier, error) {
 	values := map[string]interface{}{
 		"nodeSelectors": cfg.serverNodeSelectors,
+		"tolerations":   cfg.serverTolerations,
 		"flowcontrol": map[string]interface{}{
 			"priorityLevelConfiguration": cfg.runnerGroupFlowcontrol.priorityLevel,
 			"matchingPrecedence":         cfg.runnerGroupFlowcontrol.matchingPrecedence,
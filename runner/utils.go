@@ -61,15 +61,55 @@ func buildNetListeners(addrs []string) (_ []net.Listener, retErr error) {
 // buildRunnerGroupSummary returns aggrecated summary from runner groups' report.
 func buildRunnerGroupSummary(s *localstore.Store, groups []*group.Handler) *types.RunnerMetricReport {
 	totalBytes := int64(0)
+	totalWireBytes := int64(0)
+	totalSentBytes := int64(0)
 	totalResp := 0
 	latenciesByURL := map[string]*list.List{}
+	latenciesByMethod := map[string]*list.List{}
+	timesToFirstByte := []float64{}
+	latenciesByInstance := map[string][]float64{}
+	reportsByTarget := map[string]*types.TargetMetricReport{}
+	histogramsByURL := map[string]*types.LatencyHistogram{}
+	histogramsByMethod := map[string]*types.LatencyHistogram{}
+	receivedBytesByMethod := map[string]int64{}
+	watchEventsByType := map[string]int64{}
+	partialWatches := 0
+	watchReconnects := 0
+	watchReconnectLatencies := []float64{}
 	errs := []types.ResponseError{}
 	errStats := map[string]int32{}
+	errClasses := map[string]int{}
+	totalByMethod := map[string]int{}
+	failuresByMethod := map[string]int{}
+	failuresByCode := map[int]int{}
+	maxFailureRateByVerb := map[string]float64{}
+	var percentiles []float64
+	mixByPhase := []map[string]int{}
+	haltReasons := []string{}
+	objectLeaks := []types.ObjectLeak{}
 	maxDuration := 0 * time.Second
+	totalInjectedDelay := 0 * time.Second
+	totalAttempts := int64(0)
+	authFailures, clientRebuilds := int64(0), int64(0)
 
 	for idx := range groups {
 		g := groups[idx]
 
+		// NOTE: Use the strictest threshold if runner groups use
+		// different thresholds for same verb.
+		if spec := g.Info(context.TODO()).Spec; spec != nil && spec.Profile != nil {
+			for verb, rate := range spec.Profile.Spec.MaxFailureRateByVerb {
+				if v, ok := maxFailureRateByVerb[verb]; !ok || rate < v {
+					maxFailureRateByVerb[verb] = rate
+				}
+			}
+
+			// NOTE: Use the first runner group's percentiles.
+			if percentiles == nil {
+				percentiles = spec.Profile.Spec.Percentiles
+			}
+		}
+
 		pods, err := g.Pods(context.TODO())
 		if err != nil {
 			klog.V(2).ErrorS(err, "failed to list runners", "runner-group", g.Name())
@@ -93,6 +133,8 @@ func buildRunnerGroupSummary(s *localstore.Store, groups []*group.Handler) *type
 
 			// update totalReceivedBytes
 			totalBytes += report.TotalReceivedBytes
+			totalWireBytes += report.TotalWireBytes
+			totalSentBytes += report.TotalSentBytes
 
 			// update latencies
 			for u, l := range report.LatenciesByURL {
@@ -107,11 +149,115 @@ func buildRunnerGroupSummary(s *localstore.Store, groups []*group.Handler) *type
 				}
 			}
 
+			for method, l := range report.LatenciesByMethod {
+				latencies, ok := latenciesByMethod[method]
+				if !ok {
+					latencies = list.New()
+					latenciesByMethod[method] = latencies
+				}
+				for _, v := range l {
+					latencies.PushBack(v)
+				}
+			}
+
+			timesToFirstByte = append(timesToFirstByte, report.TimesToFirstByte...)
+			for instance, l := range report.LatenciesByInstance {
+				latenciesByInstance[instance] = append(latenciesByInstance[instance], l...)
+			}
+
+			// update latency histograms
+			for u, h := range report.LatencyHistogramsByURL {
+				dst, ok := histogramsByURL[u]
+				if !ok {
+					dst = &types.LatencyHistogram{}
+					histogramsByURL[u] = dst
+				}
+				if err := metrics.MergeLatencyHistogram(dst, h); err != nil {
+					klog.V(2).ErrorS(err, "failed to merge latency histogram", "runner", pod.Name, "url", u)
+					continue
+				}
+				totalResp += int(metrics.LatencyHistogramTotal(h))
+			}
+			for method, h := range report.LatencyHistogramsByMethod {
+				dst, ok := histogramsByMethod[method]
+				if !ok {
+					dst = &types.LatencyHistogram{}
+					histogramsByMethod[method] = dst
+				}
+				if err := metrics.MergeLatencyHistogram(dst, h); err != nil {
+					klog.V(2).ErrorS(err, "failed to merge latency histogram", "runner", pod.Name, "method", method)
+				}
+			}
+
+			// update reports by target
+			for name, tr := range report.ReportsByTarget {
+				dst, ok := reportsByTarget[name]
+				if !ok {
+					dst = &types.TargetMetricReport{FailuresByMethod: map[string]int{}}
+					reportsByTarget[name] = dst
+				}
+				dst.Total += tr.Total
+				mergeCounts(dst.FailuresByMethod, tr.FailuresByMethod)
+				dst.Latencies = append(dst.Latencies, tr.Latencies...)
+				if tr.LatencyHistogram != nil {
+					if dst.LatencyHistogram == nil {
+						dst.LatencyHistogram = &types.LatencyHistogram{}
+					}
+					if err := metrics.MergeLatencyHistogram(dst.LatencyHistogram, *tr.LatencyHistogram); err != nil {
+						klog.V(2).ErrorS(err, "failed to merge latency histogram", "runner", pod.Name, "target", name)
+					}
+				}
+			}
+
 			// update error stats
 			mergeErrorStat(errStats, report.ErrorStats)
+			mergeCounts(errClasses, report.ErrorClasses)
 			errs = append(errs, report.Errors...)
 			report.Errors = nil
 
+			// update counts by verb
+			mergeCounts(totalByMethod, report.TotalByMethod)
+			mergeCounts(failuresByMethod, report.FailuresByMethod)
+			mergeCounts(failuresByCode, report.FailuresByStatusCode)
+			mergeCounts(receivedBytesByMethod, report.ReceivedBytesByMethod)
+
+			// update watch events
+			mergeCounts(watchEventsByType, report.WatchEventsByType)
+			partialWatches += report.PartialWatches
+			watchReconnects += report.WatchReconnects
+			watchReconnectLatencies = append(watchReconnectLatencies, report.WatchReconnectLatencies...)
+
+			// update request mix by phase
+			for i, mix := range report.MixByPhase {
+				if i >= len(mixByPhase) {
+					mixByPhase = append(mixByPhase, map[string]int{})
+				}
+				mergeCounts(mixByPhase[i], mix)
+			}
+
+			objectLeaks = append(objectLeaks, report.ObjectLeaks...)
+
+			if report.HaltReason != "" {
+				haltReasons = append(haltReasons, fmt.Sprintf("%s: %s", pod.Name, report.HaltReason))
+			}
+
+			// update HTTP attempts
+			totalAttempts += report.TotalAttempts
+
+			// update auth failures
+			authFailures += report.AuthFailures
+			clientRebuilds += report.ClientRebuilds
+
+			// update injected network delay
+			if report.TotalInjectedDelay != "" {
+				delay, err := time.ParseDuration(report.TotalInjectedDelay)
+				if err != nil {
+					klog.V(2).ErrorS(err, "failed to parse injected delay", "runner",
+						pod.Name, "totalInjectedDelay", report.TotalInjectedDelay)
+				}
+				totalInjectedDelay += delay
+			}
+
 			// update max duration
 			rDur, err := time.ParseDuration(report.Duration)
 			if err != nil {
@@ -126,25 +272,186 @@ func buildRunnerGroupSummary(s *localstore.Store, groups []*group.Handler) *type
 
 	percentileLatenciesByURL := map[string][][2]float64{}
 
-	latencies := make([]float64, 0, totalResp)
-	for u, l := range latenciesByURL {
-		lInSlice := listToSliceFloat64(l)
+	injectedDelay := ""
+	if totalInjectedDelay > 0 {
+		injectedDelay = totalInjectedDelay.String()
+	}
+
+	var percentileLatencies [][2]float64
+	var latencyHistogramsByURL map[string]types.LatencyHistogram
+	var percentileLatenciesByMethod map[string][][2]float64
+	if len(latenciesByMethod) > 0 || len(histogramsByMethod) > 0 {
+		percentileLatenciesByMethod = make(map[string][][2]float64, max(len(latenciesByMethod), len(histogramsByMethod)))
+	}
+	if len(histogramsByURL) == 0 {
+		latencies := make([]float64, 0, totalResp)
+		for u, l := range latenciesByURL {
+			lInSlice := listToSliceFloat64(l)
+
+			latencies = append(latencies, lInSlice...)
+			percentileLatenciesByURL[u] = metrics.BuildPercentileLatenciesWithObjectives(lInSlice, percentiles)
+		}
+		percentileLatencies = metrics.BuildPercentileLatenciesWithObjectives(latencies, percentiles)
+
+		for method, l := range latenciesByMethod {
+			percentileLatenciesByMethod[method] = metrics.BuildPercentileLatenciesWithObjectives(listToSliceFloat64(l), percentiles)
+		}
+	} else {
+		// NOTE: Some runners might report raw latencies. Put them into
+		// histogram so that all the latencies are counted.
+		var buckets []float64
+		for _, h := range histogramsByURL {
+			buckets = h.Buckets
+			break
+		}
+
+		for u, l := range latenciesByURL {
+			dst, ok := histogramsByURL[u]
+			if !ok {
+				dst = &types.LatencyHistogram{}
+				histogramsByURL[u] = dst
+			}
+			_ = metrics.MergeLatencyHistogram(dst, metrics.BuildLatencyHistogram(buckets, listToSliceFloat64(l)))
+		}
+
+		all := types.LatencyHistogram{}
+		latencyHistogramsByURL = make(map[string]types.LatencyHistogram, len(histogramsByURL))
+		for u, h := range histogramsByURL {
+			if err := metrics.MergeLatencyHistogram(&all, *h); err != nil {
+				klog.V(2).ErrorS(err, "failed to merge latency histogram", "url", u)
+			}
+			latencyHistogramsByURL[u] = *h
+			percentileLatenciesByURL[u] = metrics.BuildPercentileLatenciesFromHistogram(*h, percentiles)
+		}
+		percentileLatencies = metrics.BuildPercentileLatenciesFromHistogram(all, percentiles)
+
+		for method, l := range latenciesByMethod {
+			dst, ok := histogramsByMethod[method]
+			if !ok {
+				dst = &types.LatencyHistogram{}
+				histogramsByMethod[method] = dst
+			}
+			_ = metrics.MergeLatencyHistogram(dst, metrics.BuildLatencyHistogram(buckets, listToSliceFloat64(l)))
+		}
+		for method, h := range histogramsByMethod {
+			percentileLatenciesByMethod[method] = metrics.BuildPercentileLatenciesFromHistogram(*h, percentiles)
+		}
+	}
+
+	// NOTE: Runners run in parallel so that the actual QPS is based on
+	// the longest duration.
+	completed, failures := 0, 0
+	for _, n := range totalByMethod {
+		completed += n
+	}
+	for _, n := range failuresByMethod {
+		failures += n
+	}
+	actualQPS, errorRate := 0.0, 0.0
+	if completed > 0 {
+		if maxDuration > 0 {
+			actualQPS = float64(completed) / maxDuration.Seconds()
+		}
+		errorRate = float64(failures) / float64(completed)
+	}
+
+	if len(watchEventsByType) == 0 {
+		watchEventsByType = nil
+	}
 
-		latencies = append(latencies, lInSlice...)
-		percentileLatenciesByURL[u] = metrics.BuildPercentileLatencies(lInSlice)
+	var percentileTimesToFirstByte [][2]float64
+	if len(timesToFirstByte) > 0 {
+		percentileTimesToFirstByte = metrics.BuildPercentileLatenciesWithObjectives(timesToFirstByte, percentiles)
+	}
+
+	var percentileWatchReconnectLatencies [][2]float64
+	if len(watchReconnectLatencies) > 0 {
+		percentileWatchReconnectLatencies = metrics.BuildPercentileLatenciesWithObjectives(watchReconnectLatencies, percentiles)
+	}
+
+	var targetReports map[string]types.TargetMetricReport
+	if len(reportsByTarget) > 0 {
+		targetReports = make(map[string]types.TargetMetricReport, len(reportsByTarget))
+		for name, tr := range reportsByTarget {
+			targetReports[name] = buildTargetMetricSummary(tr, percentiles)
+		}
+	}
+
+	var percentileLatenciesByInstance map[string][][2]float64
+	if len(latenciesByInstance) > 0 {
+		percentileLatenciesByInstance = make(map[string][][2]float64, len(latenciesByInstance))
+		for instance, l := range latenciesByInstance {
+			percentileLatenciesByInstance[instance] = metrics.BuildPercentileLatenciesWithObjectives(l, percentiles)
+		}
 	}
 
 	return &types.RunnerMetricReport{
 		Total:                    totalResp,
 		Errors:                   errs,
 		ErrorStats:               errStats,
+		ErrorClasses:             errClasses,
 		Duration:                 maxDuration.String(),
+		ActualQPS:                actualQPS,
+		ErrorRate:                errorRate,
 		TotalReceivedBytes:       totalBytes,
-		PercentileLatencies:      metrics.BuildPercentileLatencies(latencies),
+		TotalWireBytes:           totalWireBytes,
+		TotalSentBytes:           totalSentBytes,
+		PercentileLatencies:      percentileLatencies,
 		PercentileLatenciesByURL: percentileLatenciesByURL,
+		LatencyHistogramsByURL:   latencyHistogramsByURL,
+
+		PercentileLatenciesByMethod: percentileLatenciesByMethod,
+		ReceivedBytesByMethod:       receivedBytesByMethod,
+		TotalByMethod:               totalByMethod,
+		FailuresByMethod:            failuresByMethod,
+		FailuresByStatusCode:        failuresByCode,
+		PercentileTimesToFirstByte:  percentileTimesToFirstByte,
+
+		PercentileLatenciesByInstance: percentileLatenciesByInstance,
+
+		// NOTE: The time to first watch event is reported in
+		// percentiles, which can't be merged across runners.
+		WatchEventsByType: watchEventsByType,
+		PartialWatches:    partialWatches,
+		WatchReconnects:   watchReconnects,
+
+		PercentileWatchReconnectLatencies: percentileWatchReconnectLatencies,
+		FailureThresholdViolations: metrics.BuildFailureThresholdViolations(
+			totalByMethod, failuresByMethod, maxFailureRateByVerb),
+		MixByPhase:  mixByPhase,
+		HaltReason:  strings.Join(haltReasons, "; "),
+		ObjectLeaks: objectLeaks,
+
+		TotalInjectedDelay:  injectedDelay,
+		TotalAttempts:       totalAttempts,
+		AmplificationFactor: metrics.BuildAmplificationFactor(totalAttempts, totalByMethod),
+		AuthFailures:        authFailures,
+		ClientRebuilds:      clientRebuilds,
+		ReportsByTarget:     targetReports,
 	}
 }
 
+// buildTargetMetricSummary returns the summary of one target merged from
+// runners' reports.
+func buildTargetMetricSummary(tr *types.TargetMetricReport, percentiles []float64) types.TargetMetricReport {
+	res := types.TargetMetricReport{
+		Total:            tr.Total,
+		FailuresByMethod: tr.FailuresByMethod,
+	}
+
+	if tr.LatencyHistogram == nil {
+		res.PercentileLatencies = metrics.BuildPercentileLatenciesWithObjectives(tr.Latencies, percentiles)
+		return res
+	}
+
+	// NOTE: Some runners might report raw latencies. Put them into
+	// histogram so that all the latencies are counted.
+	h := tr.LatencyHistogram
+	_ = metrics.MergeLatencyHistogram(h, metrics.BuildLatencyHistogram(h.Buckets, tr.Latencies))
+	res.PercentileLatencies = metrics.BuildPercentileLatenciesFromHistogram(*h, percentiles)
+	return res
+}
+
 // listToSliceFloat64 converts list.List into []float64.
 func listToSliceFloat64(l *list.List) []float64 {
 	res := make([]float64, 0, l.Len())
@@ -161,6 +468,13 @@ func mergeErrorStat(s, d map[string]int32) {
 	}
 }
 
+// mergeCounts merges two counts group by key.
+func mergeCounts[K comparable, V int | int64](s, d map[K]V) {
+	for m, n := range d {
+		s[m] += n
+	}
+}
+
 // readBlob reads blob data from localstore.
 func readBlob(s *localstore.Store, ref string) ([]byte, error) {
 	r, err := s.OpenReader(ref)
@@ -5,19 +5,27 @@ package virtualcluster
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Azure/kperf/helmcli"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/yaml"
 )
 
 var (
 	defaultNodepoolCfg = nodepoolConfig{
 		count:   10,
-		cpu:     8,
-		memory:  16, // GiB
+		cpu:     "8",
+		memory:  "16", // GiB
 		maxPods: 110,
+
+		deployTimeout: 30 * time.Minute,
+		atomic:        true,
 	}
 
 	// virtualnodeReleaseLabels is used to mark that helm chart release
@@ -67,15 +75,23 @@ type nodepoolConfig struct {
 	name string
 	// count represents the desired number of node.
 	count int
-	// cpu represents a logical CPU resource provided by virtual node.
-	cpu int
-	// memory represents a logical memory resource provided by virtual node.
-	// The unit is GiB.
-	memory int
+	// cpu represents a logical CPU resource provided by virtual node
+	// in resource quantity format, like 3500m. The integer means cores.
+	cpu string
+	// memory represents a logical memory resource provided by virtual node
+	// in resource quantity format, like 12Gi. The number without unit
+	// means GiB.
+	memory string
 	// maxPods represents maximum Pods per node.
 	maxPods int
 	// labels is to be applied to each virtual node.
 	labels map[string]string
+	// extendedResources is the allocatable and capacity of extended
+	// resources, like nvidia.com/gpu, provided by virtual node.
+	extendedResources map[string]string
+	// taints is to be applied to each virtual node, in addition to the
+	// default one which avoids scheduling actual running pods.
+	taints []corev1.Taint
 	// sharedProviderID is to force all the virtual nodes sharing one providerID.
 	//
 	// FIXME(weifu):
@@ -87,18 +103,35 @@ type nodepoolConfig struct {
 	sharedProviderID string
 	// nodeSelectors forces virtual node's controller to nodes with that specific labels.
 	nodeSelectors map[string][]string
+	// deployTimeout is the timeout to wait for each helm release to be ready.
+	deployTimeout time.Duration
+	// atomic is to clean up the partially failed releases.
+	atomic bool
 }
 
 func (cfg *nodepoolConfig) validate() error {
-	if cfg.count <= 0 || cfg.cpu <= 0 || cfg.memory <= 0 {
-		return fmt.Errorf("invalid count=%d or cpu=%d or memory=%d",
-			cfg.count, cfg.cpu, cfg.memory)
+	if cfg.count <= 0 {
+		return fmt.Errorf("invalid count=%d", cfg.count)
+	}
+
+	cpu, err := cfg.cpuQuantity()
+	if err != nil || cpu.Sign() <= 0 {
+		return fmt.Errorf("invalid cpu=%s", cfg.cpu)
+	}
+
+	memory, err := cfg.memoryQuantity()
+	if err != nil || memory.Sign() <= 0 {
+		return fmt.Errorf("invalid memory=%s", cfg.memory)
 	}
 
 	if cfg.maxPods <= 0 {
 		return fmt.Errorf("required max pods > 0, but got %d", cfg.maxPods)
 	}
 
+	if cfg.deployTimeout <= 0 {
+		return fmt.Errorf("required deploy timeout > 0, but got %v", cfg.deployTimeout)
+	}
+
 	if cfg.name == "" {
 		return fmt.Errorf("required non-empty name")
 	}
@@ -106,9 +139,69 @@ func (cfg *nodepoolConfig) validate() error {
 	if strings.HasSuffix(cfg.name, reservedNodepoolSuffixName) {
 		return fmt.Errorf("name can't contain %s as suffix", reservedNodepoolSuffixName)
 	}
+
+	for name, quantity := range cfg.extendedResources {
+		if err := validateExtendedResourceName(name); err != nil {
+			return err
+		}
+
+		if _, err := resource.ParseQuantity(quantity); err != nil {
+			return fmt.Errorf("invalid quantity %s of extended resource %s: %w", quantity, name, err)
+		}
+	}
+
+	for _, taint := range cfg.taints {
+		if taint.Key == "" {
+			return fmt.Errorf("required non-empty taint key")
+		}
+
+		switch taint.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			return fmt.Errorf("invalid taint effect %s for key %s", taint.Effect, taint.Key)
+		}
+	}
 	return nil
 }
 
+// validateExtendedResourceName returns error if name isn't extended
+// resource name, which is domain-qualified, like nvidia.com/gpu, and not
+// native resource, like cpu or kubernetes.io/foo.
+//
+// NOTE: It's aligned with IsExtendedResourceName of kubernetes.
+func validateExtendedResourceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("required non-empty extended resource name")
+	}
+	if !strings.Contains(name, "/") || strings.Contains(name, corev1.ResourceDefaultNamespacePrefix) {
+		return fmt.Errorf("extended resource name %s must be domain-qualified and not native resource", name)
+	}
+	if strings.HasPrefix(name, corev1.DefaultResourceRequestsPrefix) {
+		return fmt.Errorf("extended resource name %s can't start with %s", name, corev1.DefaultResourceRequestsPrefix)
+	}
+	// NOTE: The quota's resource name is requests.<name>, which should
+	// be qualified name as well.
+	if errs := validation.IsQualifiedName(corev1.DefaultResourceRequestsPrefix + name); len(errs) > 0 {
+		return fmt.Errorf("invalid extended resource name %s: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// cpuQuantity returns CPU resource quantity.
+func (cfg *nodepoolConfig) cpuQuantity() (resource.Quantity, error) {
+	return resource.ParseQuantity(cfg.cpu)
+}
+
+// memoryQuantity returns memory resource quantity. The number without unit
+// is treated as GiB for backward compatibility.
+func (cfg *nodepoolConfig) memoryQuantity() (resource.Quantity, error) {
+	memory := cfg.memory
+	if _, err := strconv.ParseFloat(memory, 64); err == nil {
+		memory += "Gi"
+	}
+	return resource.ParseQuantity(memory)
+}
+
 func (cfg *nodepoolConfig) nodeHelmReleaseName() string {
 	return cfg.name
 }
@@ -127,15 +220,17 @@ func WithNodepoolCountOpt(count int) NodepoolOpt {
 	}
 }
 
-// WithNodepoolCPUOpt updates CPU resource.
-func WithNodepoolCPUOpt(cpu int) NodepoolOpt {
+// WithNodepoolCPUOpt updates CPU resource in resource quantity format, like
+// 3500m. The integer means cores.
+func WithNodepoolCPUOpt(cpu string) NodepoolOpt {
 	return func(cfg *nodepoolConfig) {
 		cfg.cpu = cpu
 	}
 }
 
-// WithNodepoolMemoryOpt updates Memory resource.
-func WithNodepoolMemoryOpt(memory int) NodepoolOpt {
+// WithNodepoolMemoryOpt updates Memory resource in resource quantity format,
+// like 12Gi. The number without unit means GiB.
+func WithNodepoolMemoryOpt(memory string) NodepoolOpt {
 	return func(cfg *nodepoolConfig) {
 		cfg.memory = memory
 	}
@@ -155,6 +250,21 @@ func WithNodepoolLabelsOpt(labels map[string]string) NodepoolOpt {
 	}
 }
 
+// WithNodepoolExtendedResources updates extended resources, like
+// nvidia.com/gpu.
+func WithNodepoolExtendedResources(resources map[string]string) NodepoolOpt {
+	return func(cfg *nodepoolConfig) {
+		cfg.extendedResources = resources
+	}
+}
+
+// WithNodepoolTaints updates node's taints.
+func WithNodepoolTaints(taints []corev1.Taint) NodepoolOpt {
+	return func(cfg *nodepoolConfig) {
+		cfg.taints = taints
+	}
+}
+
 // WithNodepoolNodeControllerAffinity forces virtual node's controller to
 // nodes with that specific labels.
 func WithNodepoolNodeControllerAffinity(nodeSelectors map[string][]string) NodepoolOpt {
@@ -170,15 +280,42 @@ func WithNodepoolSharedProviderID(providerID string) NodepoolOpt {
 	}
 }
 
+// WithNodepoolDeployTimeout updates the timeout to wait for each helm release
+// to be ready. It's 30 minutes by default.
+func WithNodepoolDeployTimeout(timeout time.Duration) NodepoolOpt {
+	return func(cfg *nodepoolConfig) {
+		cfg.deployTimeout = timeout
+	}
+}
+
+// WithNodepoolAtomicOpt updates whether to clean up the partially failed
+// releases. It's true by default. Disable it to keep the failed releases
+// for inspection.
+func WithNodepoolAtomicOpt(atomic bool) NodepoolOpt {
+	return func(cfg *nodepoolConfig) {
+		cfg.atomic = atomic
+	}
+}
+
 // toNodeHelmValuesAppliers creates ValuesAppliers.
 //
 // NOTE: Please align with ../manifests/virtualcluster/nodes/values.yaml
 func (cfg *nodepoolConfig) toNodeHelmValuesAppliers() ([]helmcli.ValuesApplier, error) {
+	cpu, err := cfg.cpuQuantity()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cpu %s: %w", cfg.cpu, err)
+	}
+
+	memory, err := cfg.memoryQuantity()
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory %s: %w", cfg.memory, err)
+	}
+
 	res := make([]string, 0, 6)
 
 	res = append(res, fmt.Sprintf("name=%s", cfg.name))
-	res = append(res, fmt.Sprintf("cpu=%d", cfg.cpu))
-	res = append(res, fmt.Sprintf("memory=%d", cfg.memory))
+	res = append(res, fmt.Sprintf("cpu=%s", cpu.String()))
+	res = append(res, fmt.Sprintf("memory=%s", memory.String()))
 	res = append(res, fmt.Sprintf("replicas=%d", cfg.count))
 	res = append(res, fmt.Sprintf("maxPods=%d", cfg.maxPods))
 	res = append(res, fmt.Sprintf("sharedProviderID=%s", cfg.sharedProviderID))
@@ -193,9 +330,31 @@ func (cfg *nodepoolConfig) toNodeHelmValuesAppliers() ([]helmcli.ValuesApplier,
 		return nil, err
 	}
 
+	nodeTaintsYaml, err := cfg.renderNodeTaints()
+	if err != nil {
+		return nil, err
+	}
+
+	nodeTaintsApplier, err := helmcli.YAMLValuesApplier(nodeTaintsYaml)
+	if err != nil {
+		return nil, err
+	}
+
+	extendedResourcesYaml, err := cfg.renderExtendedResources()
+	if err != nil {
+		return nil, err
+	}
+
+	extendedResourcesApplier, err := helmcli.YAMLValuesApplier(extendedResourcesYaml)
+	if err != nil {
+		return nil, err
+	}
+
 	return []helmcli.ValuesApplier{
 		helmcli.StringPathValuesApplier(res...),
 		nodeLabelsApplier,
+		nodeTaintsApplier,
+		extendedResourcesApplier,
 	}, nil
 }
 
@@ -214,6 +373,37 @@ func (cfg *nodepoolConfig) renderNodeLabels() (string, error) {
 	return string(rawData), nil
 }
 
+// renderNodeTaints renders virtual node's taints into YAML string
+//
+// NOTE: Please align with ../manifests/virtualcluster/nodes/values.yaml
+func (cfg *nodepoolConfig) renderNodeTaints() (string, error) {
+	target := map[string]interface{}{
+		"nodeTaints": cfg.taints,
+	}
+
+	rawData, err := yaml.Marshal(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to render nodeTaints: %w", err)
+	}
+	return string(rawData), nil
+}
+
+// renderExtendedResources renders virtual node's extended resources into
+// YAML string
+//
+// NOTE: Please align with ../manifests/virtualcluster/nodes/values.yaml
+func (cfg *nodepoolConfig) renderExtendedResources() (string, error) {
+	target := map[string]interface{}{
+		"extendedResources": cfg.extendedResources,
+	}
+
+	rawData, err := yaml.Marshal(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to render extendedResources: %w", err)
+	}
+	return string(rawData), nil
+}
+
 // toNodeControllerHelmValuesAppliers creates ValuesAppliers.
 //
 // NOTE: Please align with ../manifests/virtualcluster/nodecontrollers/values.yaml
@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/kperf/manifests"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func TestNodepoolConfigRender(t *testing.T) {
+	cfg := defaultNodepoolCfg
+	cfg.name = "example"
+	cfg.count = 1
+	cfg.taints = []corev1.Taint{
+		{Key: "dedicated", Value: "bench", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "gpu", Effect: corev1.TaintEffectNoExecute},
+	}
+	cfg.extendedResources = map[string]string{"nvidia.com/gpu": "8"}
+	require.NoError(t, cfg.validate())
+
+	ch, err := manifests.LoadChart(virtualnodeChartName)
+	require.NoError(t, err)
+
+	appliers, err := cfg.toNodeHelmValuesAppliers()
+	require.NoError(t, err)
+
+	values := ch.Values
+	for _, applier := range appliers {
+		require.NoError(t, applier(values))
+	}
+
+	rendered, err := engine.Render(ch, chartutil.Values{"Values": values})
+	require.NoError(t, err)
+
+	manifest, _, _ := strings.Cut(rendered["virtualnodes/templates/nodes.tpl"], "---")
+
+	var node corev1.Node
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &node))
+	assert.Equal(t, []corev1.Taint{
+		{Key: "kperf.io/nodepool", Value: "fake", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "bench", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "gpu", Effect: corev1.TaintEffectNoExecute},
+	}, node.Spec.Taints)
+
+	gpu := resource.MustParse("8")
+	assert.Equal(t, gpu, node.Status.Allocatable["nvidia.com/gpu"])
+	assert.Equal(t, gpu, node.Status.Capacity["nvidia.com/gpu"])
+	assert.Equal(t, resource.MustParse("16Gi"), node.Status.Capacity[corev1.ResourceMemory])
+
+	cfg.extendedResources = map[string]string{"nvidia.com/gpu": "eight"}
+	assert.Error(t, cfg.validate())
+	cfg.extendedResources = nil
+
+	for _, name := range []string{"gpu", "cpu", "pods", "kubernetes.io/gpu", "requests.nvidia.com/gpu", "nvidia.com/gpu/0", "-nvidia.com/gpu"} {
+		cfg.extendedResources = map[string]string{name: "1"}
+		assert.Error(t, cfg.validate(), name)
+	}
+	cfg.extendedResources = nil
+
+	cfg.taints = []corev1.Taint{{Key: "dedicated", Effect: "Invalid"}}
+	assert.Error(t, cfg.validate())
+	cfg.taints = nil
+
+	WithNodepoolDeployTimeout(0)(&cfg)
+	assert.Error(t, cfg.validate())
+}
+
+func TestNodepoolConfigQuantity(t *testing.T) {
+	for _, tc := range []struct {
+		cpu            string
+		memory         string
+		expectedCPU    string
+		expectedMemory string
+		hasErr         bool
+	}{
+		{cpu: "8", memory: "16", expectedCPU: "8", expectedMemory: "16Gi"},
+		{cpu: "3500m", memory: "12Gi", expectedCPU: "3500m", expectedMemory: "12Gi"},
+		{cpu: "2", memory: "12.5", expectedCPU: "2", expectedMemory: "12800Mi"},
+		{cpu: "0", memory: "16", hasErr: true},
+		{cpu: "8", memory: "-1", hasErr: true},
+		{cpu: "eight", memory: "16", hasErr: true},
+		{cpu: "8", memory: "16GB", hasErr: true},
+	} {
+		cfg := defaultNodepoolCfg
+		cfg.name = "example"
+		cfg.cpu = tc.cpu
+		cfg.memory = tc.memory
+
+		err := cfg.validate()
+		if tc.hasErr {
+			assert.Error(t, err, "cpu=%s memory=%s", tc.cpu, tc.memory)
+			continue
+		}
+		require.NoError(t, err, "cpu=%s memory=%s", tc.cpu, tc.memory)
+
+		cpu, err := cfg.cpuQuantity()
+		require.NoError(t, err)
+		assert.Equal(t, tc.expectedCPU, cpu.String())
+
+		memory, err := cfg.memoryQuantity()
+		require.NoError(t, err)
+		assert.Equal(t, tc.expectedMemory, memory.String())
+	}
+}
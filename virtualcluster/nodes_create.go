@@ -6,7 +6,6 @@ package virtualcluster
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/Azure/kperf/helmcli"
 	"github.com/Azure/kperf/manifests"
@@ -16,7 +15,6 @@ import (
 //
 // TODO:
 // 1. create a new package to define ErrNotFound, ErrAlreadyExists, ... errors.
-// 2. support configurable timeout.
 //
 // FIXME:
 //
@@ -56,7 +54,7 @@ func CreateNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string
 		return fmt.Errorf("nodepool %s already exists", cfg.nodeHelmReleaseName())
 	}
 
-	err = installNodeLifecycleDef(ctx, kubeCfgPath)
+	err = installNodeLifecycleDef(ctx, kubeCfgPath, cfg.deployTimeout)
 	if err != nil {
 		return fmt.Errorf("failed to install node lifecycle def: %w", err)
 	}
@@ -68,7 +66,7 @@ func CreateNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string
 	defer func() {
 		// NOTE: Try best to cleanup. If there is leaky resources after
 		// force stop, like kill process, it needs cleanup manually.
-		if retErr != nil {
+		if retErr != nil && cfg.atomic {
 			_ = cleanupFn()
 		}
 	}()
@@ -94,7 +92,8 @@ func CreateNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string
 	if err != nil {
 		return fmt.Errorf("failed to create helm release client: %w", err)
 	}
-	return releaseCli.Deploy(ctx, 30*time.Minute)
+	releaseCli.SetAtomic(cfg.atomic)
+	return releaseCli.Deploy(ctx, cfg.deployTimeout)
 }
 
 // createNodepoolController creates node controller release.
@@ -121,7 +120,8 @@ func createNodepoolController(ctx context.Context, kubeCfgPath string, cfg *node
 		return nil, fmt.Errorf("failed to create helm release client: %w", err)
 	}
 
-	if err := releaseCli.Deploy(ctx, 30*time.Minute); err != nil {
+	releaseCli.SetAtomic(cfg.atomic)
+	if err := releaseCli.Deploy(ctx, cfg.deployTimeout); err != nil {
 		return nil, fmt.Errorf("failed to deploy virtual node controller: %w", err)
 	}
 	return releaseCli.Uninstall, nil
@@ -0,0 +1,191 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	apitypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// virtualnodePoolLabel is the node label key whose value is the node pool
+// name.
+//
+// NOTE: Please align with ../manifests/virtualcluster/nodes/templates/nodes.tpl
+const virtualnodePoolLabel = "alpha.kperf.io/nodepool"
+
+// DrainResult is the summary of draining a node pool.
+type DrainResult struct {
+	// Nodes is the number of nodes in the node pool.
+	Nodes int
+	// CordonedNodes is the number of nodes which were schedulable before.
+	CordonedNodes int
+	// EvictedPods is the number of pods evicted.
+	EvictedPods int
+	// SkippedPods is the number of pods which aren't evicted, like
+	// DaemonSet pods or pods blocked by PodDisruptionBudget.
+	SkippedPods int
+}
+
+// CordonNodepool marks all the virtual nodes in a node pool unschedulable.
+// It returns the number of nodes and the number of nodes cordoned by this
+// call.
+func CordonNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string) (nodes int, cordoned int, _ error) {
+	clientset, err := newNodepoolClientset(kubeCfgPath, nodepoolName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	nodeList, err := listNodepoolNodes(ctx, clientset, nodepoolName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, node := range nodeList {
+		changed, err := cordonNode(ctx, clientset, node)
+		if err != nil {
+			return len(nodeList), cordoned, err
+		}
+		if changed {
+			cordoned++
+		}
+	}
+	return len(nodeList), cordoned, nil
+}
+
+// DrainNodepool cordons all the virtual nodes in a node pool and evicts
+// their pods by eviction API. The DaemonSet pods are skipped, just like
+// `kubectl drain --ignore-daemonsets`. The gracePeriodSeconds < 0 means
+// using pod's default value.
+func DrainNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string, gracePeriodSeconds int64) (*DrainResult, error) {
+	clientset, err := newNodepoolClientset(kubeCfgPath, nodepoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList, err := listNodepoolNodes(ctx, clientset, nodepoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &DrainResult{Nodes: len(nodeList)}
+	for _, node := range nodeList {
+		changed, err := cordonNode(ctx, clientset, node)
+		if err != nil {
+			return res, err
+		}
+		if changed {
+			res.CordonedNodes++
+		}
+	}
+
+	var deleteOpts *metav1.DeleteOptions
+	if gracePeriodSeconds >= 0 {
+		deleteOpts = &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriodSeconds}
+	}
+
+	for _, node := range nodeList {
+		pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", node.Name).String(),
+		})
+		if err != nil {
+			return res, fmt.Errorf("failed to list pods on node %s: %w", node.Name, err)
+		}
+
+		for _, pod := range pods.Items {
+			if isDaemonSetPod(&pod) || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				res.SkippedPods++
+				continue
+			}
+
+			err := clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+				DeleteOptions: deleteOpts,
+			})
+			switch {
+			case err == nil:
+				res.EvictedPods++
+			case apierrors.IsNotFound(err):
+			case apierrors.IsTooManyRequests(err):
+				// Blocked by PodDisruptionBudget.
+				res.SkippedPods++
+			default:
+				return res, fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+	return res, nil
+}
+
+// newNodepoolClientset validates node pool name and returns clientset.
+func newNodepoolClientset(kubeCfgPath string, nodepoolName string) (kubernetes.Interface, error) {
+	cfg := defaultNodepoolCfg
+	cfg.name = nodepoolName
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	restCfg, err := clientcmd.BuildConfigFromFlags("", kubeCfgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+	return clientset, nil
+}
+
+// listNodepoolNodes returns all the virtual nodes in a node pool.
+func listNodepoolNodes(ctx context.Context, clientset kubernetes.Interface, nodepoolName string) ([]corev1.Node, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", virtualnodePoolLabel, nodepoolName),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes in nodepool %s: %w", nodepoolName, err)
+	}
+
+	if len(nodes.Items) == 0 {
+		return nil, fmt.Errorf("no nodes found in nodepool %s", nodepoolName)
+	}
+	return nodes.Items, nil
+}
+
+// cordonNode marks node unschedulable. It returns false if node is already
+// unschedulable.
+func cordonNode(ctx context.Context, clientset kubernetes.Interface, node corev1.Node) (bool, error) {
+	if node.Spec.Unschedulable {
+		return false, nil
+	}
+
+	_, err := clientset.CoreV1().Nodes().Patch(ctx, node.Name,
+		apitypes.StrategicMergePatchType,
+		[]byte(`{"spec":{"unschedulable":true}}`),
+		metav1.PatchOptions{},
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to cordon node %s: %w", node.Name, err)
+	}
+	return true, nil
+}
+
+// isDaemonSetPod returns true if pod is owned by DaemonSet.
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" && ref.Controller != nil && *ref.Controller {
+			return true
+		}
+	}
+	return false
+}
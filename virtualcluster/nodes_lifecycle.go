@@ -12,8 +12,8 @@ import (
 	"github.com/Azure/kperf/manifests"
 )
 
-func installNodeLifecycleDef(ctx context.Context, kubeCfgPath string) error {
-	err := installNodeLifecycleCRD(ctx, kubeCfgPath)
+func installNodeLifecycleDef(ctx context.Context, kubeCfgPath string, timeout time.Duration) error {
+	err := installNodeLifecycleCRD(ctx, kubeCfgPath, timeout)
 	if err != nil {
 		return fmt.Errorf("failed to install node lifecycle CRD: %w", err)
 	}
@@ -33,10 +33,10 @@ func installNodeLifecycleDef(ctx context.Context, kubeCfgPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create helm release client: %w", err)
 	}
-	return releaseCli.Deploy(ctx, 30*time.Minute)
+	return releaseCli.Deploy(ctx, timeout)
 }
 
-func installNodeLifecycleCRD(ctx context.Context, kubeCfgPath string) error {
+func installNodeLifecycleCRD(ctx context.Context, kubeCfgPath string, timeout time.Duration) error {
 	crdCh, err := manifests.LoadChart(virtualnodeLifecycleCRDChartName)
 	if err != nil {
 		return fmt.Errorf("failed to load virtual node lifecycle CRD chart: %w", err)
@@ -52,5 +52,5 @@ func installNodeLifecycleCRD(ctx context.Context, kubeCfgPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create helm release client: %w", err)
 	}
-	return releaseCli.Deploy(ctx, 30*time.Minute)
+	return releaseCli.Deploy(ctx, timeout)
 }
@@ -9,12 +9,39 @@ import (
 	"strings"
 
 	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/Azure/kperf/helmcli"
 )
 
+// Nodepool is the nodepool added by the vc nodepool add command.
+type Nodepool struct {
+	*release.Release
+
+	// ReadyNodes is the number of nodes in Ready condition.
+	ReadyNodes int
+}
+
+// NodepoolConfigInt returns the number by key in values of nodepool's helm
+// release. The number can be decoded as int64 or float64 from the release.
+func NodepoolConfigInt(values map[string]interface{}, key string) (int, error) {
+	switch v := values[key].(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("unexpected %s value: %v", key, values[key])
+	}
+}
+
 // ListNodeppol lists nodepools added by the vc nodeppool add command.
-func ListNodepools(_ context.Context, kubeconfigPath string) ([]*release.Release, error) {
+func ListNodepools(ctx context.Context, kubeconfigPath string) ([]*Nodepool, error) {
 	listCli, err := helmcli.NewListCli(kubeconfigPath, virtualnodeReleaseNamespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create helm list client: %w", err)
@@ -25,14 +52,60 @@ func ListNodepools(_ context.Context, kubeconfigPath string) ([]*release.Release
 		return nil, fmt.Errorf("failed to list nodepool: %w", err)
 	}
 
+	restCfg, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	readyNodes, err := countReadyNodesByNodepool(ctx, clientset)
+	if err != nil {
+		return nil, err
+	}
+
 	// NOTE: Skip node controllers
-	res := make([]*release.Release, 0, len(releases)/2)
+	res := make([]*Nodepool, 0, len(releases)/2)
 	for idx := range releases {
 		r := releases[idx]
 		if strings.HasSuffix(r.Name, reservedNodepoolSuffixName) || strings.HasPrefix(r.Name, reservedLifecyclePrefixName) {
 			continue
 		}
-		res = append(res, r)
+		// NOTE: It's zero if the node controller hasn't registered
+		// nodes yet.
+		res = append(res, &Nodepool{Release: r, ReadyNodes: readyNodes[r.Name]})
 	}
 	return res, nil
 }
+
+// countReadyNodesByNodepool returns the number of nodes in Ready condition
+// for each nodepool.
+func countReadyNodesByNodepool(ctx context.Context, clientset kubernetes.Interface) (map[string]int, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: virtualnodePoolLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list virtual nodes: %w", err)
+	}
+
+	res := map[string]int{}
+	for _, node := range nodes.Items {
+		if isNodeReady(&node) {
+			res[node.Labels[virtualnodePoolLabel]]++
+		}
+	}
+	return res, nil
+}
+
+// isNodeReady returns true if node is in Ready condition.
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
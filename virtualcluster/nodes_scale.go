@@ -0,0 +1,76 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/kperf/helmcli"
+
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// ScaleNodepool changes the number of virtual nodes in an existing node pool
+// in place. The existing nodes and pods scheduled onto them are kept when
+// scaling up.
+func ScaleNodepool(ctx context.Context, kubeCfgPath string, nodepoolName string, replicas int) error {
+	cfg := defaultNodepoolCfg
+	cfg.name = nodepoolName
+	cfg.count = replicas
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	getCli, err := helmcli.NewGetCli(kubeCfgPath, virtualnodeReleaseNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to create helm get client: %w", err)
+	}
+
+	nodeRel, err := getCli.Get(cfg.nodeHelmReleaseName())
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return fmt.Errorf("nodepool %s doesn't exist", nodepoolName)
+		}
+		return fmt.Errorf("failed to get nodepool %s: %w", cfg.nodeHelmReleaseName(), err)
+	}
+
+	current, err := NodepoolConfigInt(nodeRel.Config, "replicas")
+	if err != nil {
+		return fmt.Errorf("failed to get replicas of nodepool %s: %w", cfg.nodeHelmReleaseName(), err)
+	}
+
+	// NOTE: The releases upgraded in this call are rolled back as well if
+	// any following upgrade fails, so that the node controllers and nodes
+	// stay consistent.
+	upgraded := make([]*upgradedRelease, 0, 2)
+	for _, name := range scaleNodepoolReleaseNames(&cfg, current) {
+		rel, err := upgradeNodepoolRelease(ctx, kubeCfgPath, getCli, name,
+			helmcli.StringPathValuesApplier(fmt.Sprintf("replicas=%d", replicas)))
+		if err != nil {
+			err = fmt.Errorf("failed to scale release %s to %d: %w", name, replicas, err)
+			if len(upgraded) > 0 {
+				err = fmt.Errorf("%w (%s)", err, strings.Join(rollbackUpgradedReleases(upgraded), "; "))
+			}
+			return err
+		}
+		upgraded = append(upgraded, rel)
+	}
+	return nil
+}
+
+// scaleNodepoolReleaseNames returns the releases to upgrade in order.
+//
+// NOTE: Just like CreateNodepool, the node controllers should be ready
+// before nodes when scaling up. When scaling down, the nodes should be
+// removed before their controllers so that they aren't marked NotReady.
+func scaleNodepoolReleaseNames(cfg *nodepoolConfig, current int) []string {
+	if cfg.count >= current {
+		return []string{cfg.nodeControllerHelmReleaseName(), cfg.nodeHelmReleaseName()}
+	}
+	return []string{cfg.nodeHelmReleaseName(), cfg.nodeControllerHelmReleaseName()}
+}
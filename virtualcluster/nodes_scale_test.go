@@ -0,0 +1,62 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaleNodepoolReleaseNames(t *testing.T) {
+	cfg := defaultNodepoolCfg
+	cfg.name = "example"
+
+	// scale up
+	cfg.count = 20
+	assert.Equal(t, []string{"example-controller", "example"}, scaleNodepoolReleaseNames(&cfg, 10))
+
+	// scale down
+	cfg.count = 5
+	assert.Equal(t, []string{"example", "example-controller"}, scaleNodepoolReleaseNames(&cfg, 10))
+}
+
+func TestNodepoolConfigInt(t *testing.T) {
+	for _, v := range []interface{}{10, int64(10), float64(10)} {
+		replicas, err := NodepoolConfigInt(map[string]interface{}{"replicas": v}, "replicas")
+		require.NoError(t, err)
+		assert.Equal(t, 10, replicas)
+	}
+
+	_, err := NodepoolConfigInt(map[string]interface{}{}, "replicas")
+	assert.Error(t, err)
+	_, err = NodepoolConfigInt(map[string]interface{}{"maxPods": "110"}, "maxPods")
+	assert.Error(t, err)
+}
+
+func TestRollbackUpgradedReleases(t *testing.T) {
+	var rolledBack []string
+	newRelease := func(name string, revision int, err error) *upgradedRelease {
+		return &upgradedRelease{
+			name:     name,
+			revision: revision,
+			rollback: func() error {
+				rolledBack = append(rolledBack, name)
+				return err
+			},
+		}
+	}
+
+	res := rollbackUpgradedReleases([]*upgradedRelease{
+		newRelease("example-controller", 3, nil),
+		newRelease("example", 5, errors.New("boom")),
+	})
+	assert.Equal(t, []string{"example", "example-controller"}, rolledBack)
+	assert.Equal(t, []string{
+		"failed to roll back release example to revision 5: boom",
+		"release example-controller rolled back to revision 3",
+	}, res)
+}
@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Azure/kperf/helmcli"
+
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// UpdateNodepoolLabels merges labels into the labels of virtual nodes in an
+// existing node pool in place.
+func UpdateNodepoolLabels(ctx context.Context, kubeCfgPath string, nodepoolName string, labels map[string]string) error {
+	if len(labels) == 0 {
+		return fmt.Errorf("required at least one label")
+	}
+
+	cfg := defaultNodepoolCfg
+	cfg.name = nodepoolName
+	cfg.labels = labels
+
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	getCli, err := helmcli.NewGetCli(kubeCfgPath, virtualnodeReleaseNamespace)
+	if err != nil {
+		return fmt.Errorf("failed to create helm get client: %w", err)
+	}
+
+	nodeLabelsYaml, err := cfg.renderNodeLabels()
+	if err != nil {
+		return err
+	}
+
+	nodeLabelsApplier, err := helmcli.YAMLValuesApplier(nodeLabelsYaml)
+	if err != nil {
+		return err
+	}
+
+	_, err = getCli.Get(cfg.nodeHelmReleaseName())
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return fmt.Errorf("nodepool %s doesn't exist", nodepoolName)
+	}
+
+	_, err = upgradeNodepoolRelease(ctx, kubeCfgPath, getCli, cfg.nodeHelmReleaseName(), nodeLabelsApplier)
+	if err != nil {
+		return fmt.Errorf("failed to update labels of nodepool %s: %w", nodepoolName, err)
+	}
+	return nil
+}
+
+// upgradedRelease is the release upgraded by upgradeNodepoolRelease.
+type upgradedRelease struct {
+	name string
+	// revision is the revision before upgrade.
+	revision int
+	// rollback rolls back the release to revision.
+	rollback func() error
+}
+
+// upgradeNodepoolRelease upgrades an existing release with the chart and
+// values of that release, so that only the values changed by appliers are
+// applied. The release is rolled back to the current revision if upgrade
+// fails. Otherwise, it returns the revision before upgrade so that caller
+// can roll back it if the following upgrades fail.
+func upgradeNodepoolRelease(ctx context.Context, kubeCfgPath string, getCli *helmcli.GetCli,
+	name string, valuesAppliers ...helmcli.ValuesApplier) (*upgradedRelease, error) {
+
+	rel, err := getCli.Get(name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return nil, fmt.Errorf("release %s doesn't exist", name)
+		}
+		return nil, fmt.Errorf("failed to get release %s: %w", name, err)
+	}
+
+	releaseCli, err := helmcli.NewReleaseCli(
+		kubeCfgPath,
+		virtualnodeReleaseNamespace,
+		name,
+		rel.Chart,
+		virtualnodeReleaseLabels,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create helm release client: %w", err)
+	}
+
+	values, err := releaseCli.GetValues(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: Roll back explicitly instead of atomic upgrade so that the
+	// error can tell which revision the release is rolled back to.
+	releaseCli.SetAtomic(false)
+
+	appliers := append([]helmcli.ValuesApplier{helmcli.MapValuesApplier(values)}, valuesAppliers...)
+	if err := releaseCli.Deploy(ctx, 30*time.Minute, appliers...); err != nil {
+		if rerr := releaseCli.Rollback(name, rel.Version); rerr != nil {
+			return nil, fmt.Errorf("%w (%v)", err, rerr)
+		}
+		return nil, fmt.Errorf("%w (rolled back to revision %d)", err, rel.Version)
+	}
+	return &upgradedRelease{
+		name:     name,
+		revision: rel.Version,
+		rollback: func() error {
+			return releaseCli.Rollback(name, rel.Version)
+		},
+	}, nil
+}
+
+// rollbackUpgradedReleases rolls back the releases in reverse order and
+// returns the result of each release, like "release x rolled back to
+// revision 1".
+func rollbackUpgradedReleases(upgraded []*upgradedRelease) []string {
+	res := make([]string, 0, len(upgraded))
+	for i := len(upgraded) - 1; i >= 0; i-- {
+		r := upgraded[i]
+		if err := r.rollback(); err != nil {
+			res = append(res, fmt.Sprintf("failed to roll back release %s to revision %d: %v", r.name, r.revision, err))
+			continue
+		}
+		res = append(res, fmt.Sprintf("release %s rolled back to revision %d", r.name, r.revision))
+	}
+	return res
+}
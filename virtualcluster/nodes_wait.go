@@ -0,0 +1,78 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// waitNodepoolReadyInterval is the interval to check nodes' readiness.
+const waitNodepoolReadyInterval = 5 * time.Second
+
+// WaitNodepoolReady waits until the expected number of nodes in a node pool
+// are in Ready condition. It returns error with the number of ready nodes
+// if timeout.
+func WaitNodepoolReady(ctx context.Context, kubeCfgPath string, nodepoolName string, expected int, timeout time.Duration) error {
+	clientset, err := newNodepoolClientset(kubeCfgPath, nodepoolName)
+	if err != nil {
+		return err
+	}
+	return waitNodepoolReady(ctx, clientset, nodepoolName, expected, timeout, waitNodepoolReadyInterval)
+}
+
+func waitNodepoolReady(ctx context.Context, clientset kubernetes.Interface,
+	nodepoolName string, expected int, timeout, interval time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ready := 0
+	for {
+		n, err := countReadyNodes(ctx, clientset, nodepoolName)
+		switch {
+		case err == nil:
+			ready = n
+			if ready >= expected {
+				return nil
+			}
+			klog.V(2).Infof("Waiting for nodepool %s: %d/%d nodes are ready", nodepoolName, ready, expected)
+		case ctx.Err() == nil:
+			klog.Warningf("Failed to count ready nodes in nodepool %s: %v", nodepoolName, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for nodepool %s: only %d/%d nodes are ready: %w",
+				nodepoolName, ready, expected, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// countReadyNodes returns the number of nodes in Ready condition in a node pool.
+func countReadyNodes(ctx context.Context, clientset kubernetes.Interface, nodepoolName string) (int, error) {
+	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", virtualnodePoolLabel, nodepoolName),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list nodes in nodepool %s: %w", nodepoolName, err)
+	}
+
+	ready := 0
+	for _, node := range nodes.Items {
+		if isNodeReady(&node) {
+			ready++
+		}
+	}
+	return ready, nil
+}
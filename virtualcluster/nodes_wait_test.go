@@ -0,0 +1,56 @@
+// Copyright (c) Microsoft Corporation.
+// Licensed under the MIT License.
+
+package virtualcluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newTestNode(name, nodepool string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{virtualnodePoolLabel: nodepool},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func TestWaitNodepoolReady(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		newTestNode("example-0", "example", true),
+		newTestNode("example-1", "example", false),
+		newTestNode("other-0", "other", true),
+	)
+
+	ctx := context.Background()
+
+	err := waitNodepoolReady(ctx, clientset, "example", 2, 200*time.Millisecond, 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only 1/2 nodes are ready")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = clientset.CoreV1().Nodes().Update(ctx, newTestNode("example-1", "example", true), metav1.UpdateOptions{})
+	}()
+	require.NoError(t, waitNodepoolReady(ctx, clientset, "example", 2, 5*time.Second, 50*time.Millisecond))
+
+	counts, err := countReadyNodesByNodepool(ctx, clientset)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"example": 2, "other": 1}, counts)
+}